@@ -0,0 +1,75 @@
+package billing
+
+import (
+	"fmt"
+	"snook/app/data/entities"
+	"time"
+)
+
+// EligibilityContext describes the session a promotion is evaluated against.
+type EligibilityContext struct {
+	Now time.Time
+	// At is when the day-of-week and time-of-day rules are judged. Zero skips
+	// them for promotions that already passed them when they were applied.
+	At         time.Time
+	TableType  string
+	IsMember   bool
+	Spend      float64
+	TotalUses  int64
+	MemberUses int64
+}
+
+// CheckEligibility returns the reasons promo cannot be applied, or nil when
+// every rule passes.
+func CheckEligibility(promo entities.Promotion, ec EligibilityContext) []string {
+	var reasons []string
+	if promo.Status != "ACTIVE" {
+		reasons = append(reasons, "promotion is not active")
+	}
+	if !promo.StartDate.IsZero() && ec.Now.Before(promo.StartDate) {
+		reasons = append(reasons, "promotion starts on "+promo.StartDate.In(time.Local).Format("2006-01-02"))
+	}
+	if !promo.EndDate.IsZero() && !ec.Now.Before(promo.EndDate.AddDate(0, 0, 1)) {
+		reasons = append(reasons, "promotion ended on "+promo.EndDate.In(time.Local).Format("2006-01-02"))
+	}
+	if len(promo.TableTypes) > 0 && !containsString(promo.TableTypes, ec.TableType) {
+		reasons = append(reasons, "not valid for table type "+ec.TableType)
+	}
+	// A happy hour is priced on the minutes played inside its window, so the
+	// time of the check does not matter.
+	if promo.Type != "HAPPY_HOUR" && !ec.At.IsZero() {
+		at := ec.At.In(time.Local)
+		if !OnDayOfWeek(at, promo.DaysOfWeek) {
+			reasons = append(reasons, "not valid on "+at.Weekday().String())
+		}
+		if !InTimeWindow(at, promo.StartTimeOfDay, promo.EndTimeOfDay) {
+			reasons = append(reasons, fmt.Sprintf("only valid between %s and %s", promo.StartTimeOfDay, promo.EndTimeOfDay))
+		}
+	}
+	if promo.MemberOnly && !ec.IsMember {
+		reasons = append(reasons, "members only")
+	}
+	if promo.MinSpend > 0 && ec.Spend < promo.MinSpend {
+		reasons = append(reasons, fmt.Sprintf("minimum spend of %.2f not reached", promo.MinSpend))
+	}
+	if promo.MaxUses > 0 && ec.TotalUses >= int64(promo.MaxUses) {
+		reasons = append(reasons, "promotion usage limit reached")
+	}
+	if promo.MaxUsesPerCustomer > 0 {
+		if !ec.IsMember {
+			reasons = append(reasons, "member required for per-customer usage limit")
+		} else if ec.MemberUses >= int64(promo.MaxUsesPerCustomer) {
+			reasons = append(reasons, "customer usage limit reached")
+		}
+	}
+	return reasons
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
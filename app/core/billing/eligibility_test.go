@@ -0,0 +1,62 @@
+package billing
+
+import (
+	"reflect"
+	"snook/app/data/entities"
+	"testing"
+	"time"
+)
+
+func TestCheckEligibility(t *testing.T) {
+	// Monday 19 October 2026, 19:00 shop time.
+	now := time.Date(2026, 10, 19, 19, 0, 0, 0, time.Local)
+	active := func(p entities.Promotion) entities.Promotion {
+		p.Status = "ACTIVE"
+		return p
+	}
+	tests := []struct {
+		name  string
+		promo entities.Promotion
+		ec    EligibilityContext
+		want  []string
+	}{
+		{"no rules", active(entities.Promotion{}), EligibilityContext{Now: now, At: now}, nil},
+		{"inactive", entities.Promotion{Status: "INACTIVE"}, EligibilityContext{Now: now, At: now},
+			[]string{"promotion is not active"}},
+		{"not started", active(entities.Promotion{StartDate: time.Date(2026, 10, 20, 0, 0, 0, 0, time.Local)}),
+			EligibilityContext{Now: now, At: now}, []string{"promotion starts on 2026-10-20"}},
+		{"end date is inclusive", active(entities.Promotion{EndDate: time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local)}),
+			EligibilityContext{Now: now, At: now}, nil},
+		{"ended", active(entities.Promotion{EndDate: time.Date(2026, 10, 18, 0, 0, 0, 0, time.Local)}),
+			EligibilityContext{Now: now, At: now}, []string{"promotion ended on 2026-10-18"}},
+		{"table type", active(entities.Promotion{TableTypes: []string{"VIP"}}),
+			EligibilityContext{Now: now, At: now, TableType: "STANDARD"}, []string{"not valid for table type STANDARD"}},
+		{"day of week", active(entities.Promotion{DaysOfWeek: []int{0, 6}}),
+			EligibilityContext{Now: now, At: now}, []string{"not valid on Monday"}},
+		{"outside time window", active(entities.Promotion{StartTimeOfDay: "20:00", EndTimeOfDay: "22:00"}),
+			EligibilityContext{Now: now, At: now}, []string{"only valid between 20:00 and 22:00"}},
+		{"zero At skips day and time", active(entities.Promotion{DaysOfWeek: []int{0}, StartTimeOfDay: "20:00", EndTimeOfDay: "22:00"}),
+			EligibilityContext{Now: now}, nil},
+		{"happy hour ignores time of check", active(entities.Promotion{Type: "HAPPY_HOUR", StartTimeOfDay: "14:00", EndTimeOfDay: "17:00"}),
+			EligibilityContext{Now: now, At: now}, nil},
+		{"members only", active(entities.Promotion{MemberOnly: true}),
+			EligibilityContext{Now: now, At: now}, []string{"members only"}},
+		{"minimum spend", active(entities.Promotion{MinSpend: 500}),
+			EligibilityContext{Now: now, At: now, Spend: 499.99}, []string{"minimum spend of 500.00 not reached"}},
+		{"usage limit", active(entities.Promotion{MaxUses: 10}),
+			EligibilityContext{Now: now, At: now, TotalUses: 10}, []string{"promotion usage limit reached"}},
+		{"per-customer limit needs a member", active(entities.Promotion{MaxUsesPerCustomer: 1}),
+			EligibilityContext{Now: now, At: now}, []string{"member required for per-customer usage limit"}},
+		{"per-customer limit reached", active(entities.Promotion{MaxUsesPerCustomer: 1}),
+			EligibilityContext{Now: now, At: now, IsMember: true, MemberUses: 1}, []string{"customer usage limit reached"}},
+		{"several reasons", entities.Promotion{Status: "INACTIVE", MemberOnly: true},
+			EligibilityContext{Now: now, At: now}, []string{"promotion is not active", "members only"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CheckEligibility(tt.promo, tt.ec); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckEligibility() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package billing
+
+import (
+	"snook/app/data/entities"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AvailableAt reports whether t falls in one of the availability windows.
+// An item without windows is always available.
+func AvailableAt(windows []entities.AvailabilityWindow, t time.Time) bool {
+	if len(windows) == 0 {
+		return true
+	}
+	for _, w := range windows {
+		if OnDayOfWeek(t, w.DaysOfWeek) && InTimeWindow(t, w.StartTimeOfDay, w.EndTimeOfDay) {
+			return true
+		}
+	}
+	return false
+}
+
+// PriceListInEffect reports whether the price list applies at t.
+func PriceListInEffect(list entities.PriceList, t time.Time) bool {
+	if list.Status != "ACTIVE" {
+		return false
+	}
+	if list.StartDate != nil && t.Before(*list.StartDate) {
+		return false
+	}
+	if list.EndDate != nil && t.After(*list.EndDate) {
+		return false
+	}
+	t = t.In(time.Local)
+	return OnDayOfWeek(t, list.DaysOfWeek) && InTimeWindow(t, list.StartTimeOfDay, list.EndTimeOfDay)
+}
+
+// MenuItemPrice returns the price of the item at t and the price list it
+// comes from, or the regular price and nil when no list in effect covers the
+// item. Higher priority lists win, then the most recently created.
+func MenuItemPrice(item entities.MenuItem, lists []entities.PriceList, t time.Time) (float64, *primitive.ObjectID) {
+	price := item.Price
+	var best *entities.PriceList
+	for i := range lists {
+		list := &lists[i]
+		if !PriceListInEffect(*list, t) {
+			continue
+		}
+		for _, line := range list.Items {
+			if line.MenuItemId != item.Id {
+				continue
+			}
+			if best == nil || list.Priority > best.Priority ||
+				list.Priority == best.Priority && list.CreatedDate.After(best.CreatedDate) {
+				best = list
+				price = line.Price
+			}
+			break
+		}
+	}
+	if best == nil {
+		return price, nil
+	}
+	return price, &best.Id
+}
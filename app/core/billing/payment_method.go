@@ -0,0 +1,118 @@
+package billing
+
+import (
+	"fmt"
+	"regexp"
+	"snook/app/data/entities"
+	"strings"
+)
+
+var methodCodePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{0,19}$`)
+
+// reservedMethods are booked by the system itself and cannot be configured.
+var reservedMethods = map[string]bool{"POINTS": true}
+
+// DefaultPaymentMethods is the catalog used until settings define one.
+func DefaultPaymentMethods() []entities.PaymentMethod {
+	return []entities.PaymentMethod{
+		{Code: "CASH", Label: "Cash", Active: true, CashInDrawer: true},
+		{Code: "TRANSFER", Label: "Bank transfer", Active: true},
+		{Code: "CARD", Label: "Card", Active: true},
+		{Code: "QR", Label: "QR payment", Active: true},
+		{Code: "OUTSTANDING", Label: "Outstanding", Active: true},
+	}
+}
+
+// PaymentMethods returns the configured catalog, or the default one when
+// none is configured.
+func PaymentMethods(setting entities.Setting) []entities.PaymentMethod {
+	if len(setting.PaymentMethods) == 0 {
+		return DefaultPaymentMethods()
+	}
+	return setting.PaymentMethods
+}
+
+// PaymentMethodCode normalizes a payment type as entered, so "cash" and
+// " Cash" both become CASH.
+func PaymentMethodCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// ResolvePaymentMethod finds the active method for a payment type.
+func ResolvePaymentMethod(methods []entities.PaymentMethod, code string) (entities.PaymentMethod, error) {
+	code = PaymentMethodCode(code)
+	for _, m := range methods {
+		if m.Code != code {
+			continue
+		}
+		if !m.Active {
+			return m, fmt.Errorf("payment method %s is not active", code)
+		}
+		return m, nil
+	}
+	return entities.PaymentMethod{}, fmt.Errorf("unknown payment method %q", code)
+}
+
+// DefaultCashMethod is the first active method that goes into the drawer,
+// used when a payment names no type.
+func DefaultCashMethod(methods []entities.PaymentMethod) (entities.PaymentMethod, error) {
+	for _, m := range methods {
+		if m.Active && m.CashInDrawer {
+			return m, nil
+		}
+	}
+	return entities.PaymentMethod{}, fmt.Errorf("no active cash payment method, a payment type is required")
+}
+
+// GatewayChargeable reports whether a payment gateway can collect the
+// method. Cash goes into the drawer and OUTSTANDING is not paid at all.
+func GatewayChargeable(method entities.PaymentMethod) bool {
+	return !method.CashInDrawer && method.Code != "OUTSTANDING"
+}
+
+// ValidatePaymentMethods normalizes a catalog and checks that its codes are
+// well formed and unique and its fees are percentages.
+func ValidatePaymentMethods(methods []entities.PaymentMethod) ([]entities.PaymentMethod, error) {
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("at least one payment method is required")
+	}
+	seen := map[string]bool{}
+	valid := make([]entities.PaymentMethod, len(methods))
+	for i, m := range methods {
+		m.Code = PaymentMethodCode(m.Code)
+		m.Label = strings.TrimSpace(m.Label)
+		switch {
+		case !methodCodePattern.MatchString(m.Code):
+			return nil, fmt.Errorf("payment method %d: code must be letters, digits or _ and start with a letter", i+1)
+		case reservedMethods[m.Code]:
+			return nil, fmt.Errorf("payment method %s is reserved", m.Code)
+		case seen[m.Code]:
+			return nil, fmt.Errorf("payment method %s is listed twice", m.Code)
+		case m.FeePct < 0 || m.FeePct > 100:
+			return nil, fmt.Errorf("payment method %s: fee must be between 0 and 100 percent", m.Code)
+		}
+		if m.Label == "" {
+			m.Label = m.Code
+		}
+		seen[m.Code] = true
+		valid[i] = m
+	}
+	return valid, nil
+}
+
+// PaymentFee is what the method costs on amount, such as a card fee.
+func PaymentFee(method entities.PaymentMethod, amount float64) float64 {
+	return round2(amount * method.FeePct / 100)
+}
+
+// CashMethodCodes lists the methods whose money goes into the cash drawer,
+// active or not, since earlier payments may still use a retired method.
+func CashMethodCodes(methods []entities.PaymentMethod) []string {
+	codes := []string{}
+	for _, m := range methods {
+		if m.CashInDrawer {
+			codes = append(codes, m.Code)
+		}
+	}
+	return codes
+}
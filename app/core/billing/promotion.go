@@ -0,0 +1,180 @@
+package billing
+
+import (
+	"math"
+	"snook/app/data/entities"
+	"sort"
+)
+
+// BillContext holds the session figures promotions are calculated from.
+type BillContext struct {
+	PlayedMins  float64
+	TableCharge float64
+	RatePerHour float64
+	Intervals   []Interval
+	Orders      []entities.TableOrder
+}
+
+// ApplyPromotions calculates the discount of each promotion in priority
+// order. Each discount is capped by what is left of the charge it targets,
+// and a non-stackable promotion is never combined with any other. Automatic
+// promotions that come to nothing are left out so they never block others.
+func ApplyPromotions(promos []entities.Promotion, bc BillContext) []entities.AppliedPromotion {
+	sorted := make([]entities.Promotion, len(promos))
+	copy(sorted, promos)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Priority > sorted[j].Priority })
+
+	remainingTable := bc.TableCharge
+	remainingFood := 0.0
+	for _, o := range bc.Orders {
+		remainingFood += o.Total
+	}
+
+	var applied []entities.AppliedPromotion
+	exclusive := false
+	for _, promo := range sorted {
+		if exclusive || (len(applied) > 0 && !promo.Stackable) {
+			continue
+		}
+		var discount float64
+		switch promo.Type {
+		case "FREE_HOURS":
+			if promo.PlayHours > 0 && (bc.PlayedMins/60) >= promo.PlayHours {
+				discount = promo.FreeHours * bc.RatePerHour
+			}
+			discount, remainingTable = take(discount, remainingTable)
+		case "DISCOUNT_PCT":
+			discount, remainingTable = take(remainingTable*promo.DiscountPct/100, remainingTable)
+		case "HAPPY_HOUR":
+			mins := MinutesInWindow(bc.Intervals, promo.DaysOfWeek, promo.StartTimeOfDay, promo.EndTimeOfDay)
+			discount, remainingTable = take(mins/60*bc.RatePerHour*promo.DiscountPct/100, remainingTable)
+		case "DISCOUNT_AMT":
+			discount, remainingTable, remainingFood = takeBoth(promo.DiscountAmt, remainingTable, remainingFood)
+		case "BUY_X_GET_Y":
+			discount, remainingFood = take(buyXGetYDiscount(promo, bc.Orders), remainingFood)
+		case "CATEGORY_PCT":
+			discount, remainingFood = take(categoryTotal(promo.Category, bc.Orders)*promo.DiscountPct/100, remainingFood)
+		case "BUNDLE":
+			discount, remainingTable, remainingFood = takeBoth(bundleDiscount(promo, bc), remainingTable, remainingFood)
+		}
+		if promo.AutoApply && round2(discount) == 0 {
+			continue
+		}
+		applied = append(applied, entities.AppliedPromotion{
+			PromotionId: promo.Id,
+			Name:        promo.Name,
+			Type:        promo.Type,
+			Priority:    promo.Priority,
+			Stackable:   promo.Stackable,
+			Auto:        promo.AutoApply,
+			Discount:    round2(discount),
+		})
+		if !promo.Stackable {
+			exclusive = true
+		}
+	}
+	return applied
+}
+
+// CanStack reports whether promo may be added next to the already applied ones.
+func CanStack(promo entities.Promotion, applied []entities.AppliedPromotion) bool {
+	if len(applied) == 0 {
+		return true
+	}
+	if !promo.Stackable {
+		return false
+	}
+	for _, a := range applied {
+		if !a.Stackable {
+			return false
+		}
+	}
+	return true
+}
+
+func take(amount, remaining float64) (float64, float64) {
+	if amount < 0 {
+		amount = 0
+	}
+	if amount > remaining {
+		amount = remaining
+	}
+	return amount, remaining - amount
+}
+
+func takeBoth(amount, remainingTable, remainingFood float64) (float64, float64, float64) {
+	fromTable, remainingTable := take(amount, remainingTable)
+	fromFood, remainingFood := take(amount-fromTable, remainingFood)
+	return fromTable + fromFood, remainingTable, remainingFood
+}
+
+func buyXGetYDiscount(promo entities.Promotion, orders []entities.TableOrder) float64 {
+	if promo.MenuItemId == nil || promo.BuyQuantity <= 0 || promo.GetQuantity <= 0 {
+		return 0
+	}
+	var unitPrices []float64
+	for _, o := range orders {
+		if o.MenuItemId != *promo.MenuItemId || o.Quantity <= 0 {
+			continue
+		}
+		unit := o.Total / float64(o.Quantity)
+		for i := 0; i < o.Quantity; i++ {
+			unitPrices = append(unitPrices, unit)
+		}
+	}
+	free := len(unitPrices) / (promo.BuyQuantity + promo.GetQuantity) * promo.GetQuantity
+	sort.Float64s(unitPrices)
+	discount := 0.0
+	for i := 0; i < free; i++ {
+		discount += unitPrices[i]
+	}
+	return discount
+}
+
+func categoryTotal(category string, orders []entities.TableOrder) float64 {
+	total := 0.0
+	for _, o := range orders {
+		if category != "" && o.Category == category {
+			total += o.Total
+		}
+	}
+	return total
+}
+
+// bundleDiscount prices as many complete bundles as the session contains at
+// the bundle price instead of the regular table rate and item prices.
+func bundleDiscount(promo entities.Promotion, bc BillContext) float64 {
+	if promo.BundlePrice <= 0 || (promo.PlayHours <= 0 && len(promo.BundleItems) == 0) {
+		return 0
+	}
+	count := math.MaxInt32
+	if promo.PlayHours > 0 {
+		count = int(math.Floor(bc.PlayedMins / 60 / promo.PlayHours))
+	}
+	regular := promo.PlayHours * bc.RatePerHour
+	for _, item := range promo.BundleItems {
+		quantity := 0
+		unit := 0.0
+		for _, o := range bc.Orders {
+			if o.MenuItemId == item.MenuItemId && o.Quantity > 0 {
+				quantity += o.Quantity
+				unit = o.Total / float64(o.Quantity)
+			}
+		}
+		if item.Quantity <= 0 {
+			continue
+		}
+		if n := quantity / item.Quantity; n < count {
+			count = n
+		}
+		regular += unit * float64(item.Quantity)
+	}
+	if count <= 0 || count == math.MaxInt32 || regular <= promo.BundlePrice {
+		return 0
+	}
+	return float64(count) * (regular - promo.BundlePrice)
+}
+
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,114 @@
+package billing
+
+import (
+	"reflect"
+	"snook/app/data/entities"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestApplyPromotions(t *testing.T) {
+	beer := primitive.NewObjectID()
+	monday := func(hour, min int) time.Time {
+		return time.Date(2026, 10, 19, hour, min, 0, 0, time.Local)
+	}
+	bill := BillContext{
+		PlayedMins:  150,
+		TableCharge: 300,
+		RatePerHour: 120,
+		Intervals:   []Interval{{Start: monday(17, 0), End: monday(19, 30)}},
+		Orders: []entities.TableOrder{
+			{MenuItemId: beer, Category: "Drinks", Quantity: 3, Total: 180},
+			{MenuItemId: primitive.NewObjectID(), Category: "Food", Quantity: 1, Total: 120},
+		},
+	}
+	tests := []struct {
+		name   string
+		promos []entities.Promotion
+		want   map[string]float64
+	}{
+		{"percentage of table charge",
+			[]entities.Promotion{{Name: "pct", Type: "DISCOUNT_PCT", DiscountPct: 10}},
+			map[string]float64{"pct": 30}},
+		{"free hours once enough is played",
+			[]entities.Promotion{{Name: "free", Type: "FREE_HOURS", PlayHours: 2, FreeHours: 1}},
+			map[string]float64{"free": 120}},
+		{"free hours not yet earned",
+			[]entities.Promotion{{Name: "free", Type: "FREE_HOURS", PlayHours: 3, FreeHours: 1}},
+			map[string]float64{"free": 0}},
+		{"fixed amount spills over to food and is capped",
+			[]entities.Promotion{{Name: "amt", Type: "DISCOUNT_AMT", DiscountAmt: 1000}},
+			map[string]float64{"amt": 600}},
+		{"happy hour counts minutes inside the window",
+			[]entities.Promotion{{Name: "happy", Type: "HAPPY_HOUR", StartTimeOfDay: "18:00", EndTimeOfDay: "20:00", DiscountPct: 50}},
+			map[string]float64{"happy": 90}},
+		{"buy two get one free",
+			[]entities.Promotion{{Name: "beer", Type: "BUY_X_GET_Y", MenuItemId: &beer, BuyQuantity: 2, GetQuantity: 1}},
+			map[string]float64{"beer": 60}},
+		{"category percentage",
+			[]entities.Promotion{{Name: "drinks", Type: "CATEGORY_PCT", Category: "Drinks", DiscountPct: 50}},
+			map[string]float64{"drinks": 90}},
+		{"non-stackable excludes the rest",
+			[]entities.Promotion{
+				{Name: "low", Type: "DISCOUNT_AMT", DiscountAmt: 50, Priority: 1, Stackable: true},
+				{Name: "high", Type: "DISCOUNT_PCT", DiscountPct: 10, Priority: 2},
+			},
+			map[string]float64{"high": 30}},
+		{"stackable promotions share the remaining charge",
+			[]entities.Promotion{
+				{Name: "second", Type: "DISCOUNT_PCT", DiscountPct: 100, Priority: 1, Stackable: true},
+				{Name: "first", Type: "DISCOUNT_AMT", DiscountAmt: 100, Priority: 2, Stackable: true},
+			},
+			map[string]float64{"first": 100, "second": 200}},
+		{"automatic promotion worth nothing does not block others",
+			[]entities.Promotion{
+				{Name: "late", Type: "HAPPY_HOUR", StartTimeOfDay: "22:00", EndTimeOfDay: "23:00", DiscountPct: 50, Priority: 2, AutoApply: true},
+				{Name: "pct", Type: "DISCOUNT_PCT", DiscountPct: 10, Priority: 1},
+			},
+			map[string]float64{"pct": 30}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]float64{}
+			for _, a := range ApplyPromotions(tt.promos, bill) {
+				got[a.Name] = a.Discount
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ApplyPromotions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinutesInWindow(t *testing.T) {
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2026, 10, day, hour, min, 0, 0, time.Local)
+	}
+	tests := []struct {
+		name       string
+		intervals  []Interval
+		days       []int
+		start, end string
+		want       float64
+	}{
+		{"empty window covers the whole interval", []Interval{{at(19, 10, 0), at(19, 12, 30)}}, nil, "", "", 150},
+		{"partly inside", []Interval{{at(19, 17, 0), at(19, 19, 0)}}, nil, "18:00", "20:00", 60},
+		{"outside", []Interval{{at(19, 10, 0), at(19, 12, 0)}}, nil, "18:00", "20:00", 0},
+		{"pauses split the intervals", []Interval{{at(19, 18, 0), at(19, 18, 30)}, {at(19, 19, 0), at(19, 21, 0)}}, nil, "18:00", "20:00", 90},
+		{"window past midnight", []Interval{{at(19, 23, 0), at(20, 2, 0)}}, nil, "22:00", "01:00", 120},
+		{"window past midnight started the day before", []Interval{{at(20, 0, 0), at(20, 3, 0)}}, nil, "22:00", "01:00", 60},
+		{"wrong day", []Interval{{at(19, 18, 0), at(19, 20, 0)}}, []int{0, 6}, "18:00", "20:00", 0},
+		{"window belongs to the day it starts", []Interval{{at(20, 0, 0), at(20, 1, 0)}}, []int{1}, "22:00", "02:00", 60},
+		{"spans several days", []Interval{{at(19, 19, 0), at(21, 19, 0)}}, nil, "18:00", "20:00", 60 + 120 + 60},
+		{"invalid clock", []Interval{{at(19, 18, 0), at(19, 20, 0)}}, nil, "6pm", "20:00", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MinutesInWindow(tt.intervals, tt.days, tt.start, tt.end); got != tt.want {
+				t.Errorf("MinutesInWindow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
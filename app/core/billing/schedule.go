@@ -0,0 +1,120 @@
+package billing
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ParseClock converts an "HH:MM" string into minutes after midnight.
+func ParseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, errors.New("time must be in HH:MM format")
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// ValidateTimeWindow accepts an empty window or a pair of HH:MM clocks.
+func ValidateTimeWindow(start, end string) error {
+	if start == "" && end == "" {
+		return nil
+	}
+	if start == "" || end == "" {
+		return errors.New("both start and end time are required")
+	}
+	if _, err := ParseClock(start); err != nil {
+		return err
+	}
+	if _, err := ParseClock(end); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ValidateDaysOfWeek accepts weekday numbers from 0 (Sunday) to 6 (Saturday).
+func ValidateDaysOfWeek(days []int) error {
+	for _, d := range days {
+		if d < 0 || d > 6 {
+			return fmt.Errorf("invalid day of week %d", d)
+		}
+	}
+	return nil
+}
+
+// InTimeWindow reports whether t falls inside [start, end). A window whose end
+// is before its start wraps past midnight. An empty window always matches.
+func InTimeWindow(t time.Time, start, end string) bool {
+	if start == "" || end == "" {
+		return true
+	}
+	from, err := ParseClock(start)
+	if err != nil {
+		return false
+	}
+	to, err := ParseClock(end)
+	if err != nil {
+		return false
+	}
+	now := t.Hour()*60 + t.Minute()
+	if from <= to {
+		return now >= from && now < to
+	}
+	return now >= from || now < to
+}
+
+// OnDayOfWeek reports whether t falls on one of days. No days means every day.
+func OnDayOfWeek(t time.Time, days []int) bool {
+	if len(days) == 0 {
+		return true
+	}
+	for _, d := range days {
+		if int(t.Weekday()) == d {
+			return true
+		}
+	}
+	return false
+}
+
+// MinutesInWindow counts the minutes of intervals that fall inside the daily
+// time window on the given days. A window that wraps past midnight belongs to
+// the day it starts on. An empty window covers the whole day.
+func MinutesInWindow(intervals []Interval, days []int, start, end string) float64 {
+	from, to := 0, 24*60
+	if start != "" && end != "" {
+		var err error
+		if from, err = ParseClock(start); err != nil {
+			return 0
+		}
+		if to, err = ParseClock(end); err != nil {
+			return 0
+		}
+		if to <= from {
+			to += 24 * 60
+		}
+	}
+	total := 0.0
+	for _, iv := range intervals {
+		// Stored times decode as UTC; windows are shop hours, so they are
+		// laid out in local time.
+		y, m, d := iv.Start.In(time.Local).Date()
+		day := time.Date(y, m, d-1, 0, 0, 0, 0, time.Local)
+		for day.Before(iv.End) {
+			if OnDayOfWeek(day, days) {
+				windowStart := day.Add(time.Duration(from) * time.Minute)
+				windowEnd := day.Add(time.Duration(to) * time.Minute)
+				if iv.Start.After(windowStart) {
+					windowStart = iv.Start
+				}
+				if iv.End.Before(windowEnd) {
+					windowEnd = iv.End
+				}
+				if windowEnd.After(windowStart) {
+					total += windowEnd.Sub(windowStart).Minutes()
+				}
+			}
+			day = day.AddDate(0, 0, 1)
+		}
+	}
+	return total
+}
@@ -0,0 +1,75 @@
+package billing
+
+import (
+	"math"
+	"snook/app/data/entities"
+	"time"
+)
+
+// PlayedMinutes is the session time up to now, excluding pauses.
+func PlayedMinutes(session entities.TableSession, now time.Time) float64 {
+	mins := now.Sub(session.StartTime).Minutes() - session.TotalPausedMins
+	if session.PausedAt != nil {
+		mins -= now.Sub(*session.PausedAt).Minutes()
+	}
+	if mins < 0 {
+		return 0
+	}
+	return mins
+}
+
+// Interval is a span of time the table was in play.
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// PlayedIntervals splits the session up to now into the spans between pauses.
+// Paused minutes of older sessions that carry no pause history are taken off
+// the end of the last span.
+func PlayedIntervals(session entities.TableSession, now time.Time) []Interval {
+	pauses := session.Pauses
+	if session.PausedAt != nil && (len(pauses) == 0 || pauses[len(pauses)-1].End != nil) {
+		pauses = append(pauses, entities.PauseInterval{Start: *session.PausedAt})
+	}
+	var intervals []Interval
+	cursor := session.StartTime
+	recordedMins := 0.0
+	for _, p := range pauses {
+		end := now
+		if p.End != nil {
+			end = *p.End
+			recordedMins += end.Sub(p.Start).Minutes()
+		}
+		if p.Start.After(cursor) {
+			intervals = append(intervals, Interval{Start: cursor, End: p.Start})
+		}
+		if end.After(cursor) {
+			cursor = end
+		}
+	}
+	if now.After(cursor) {
+		intervals = append(intervals, Interval{Start: cursor, End: now})
+	}
+	missing := time.Duration((session.TotalPausedMins - recordedMins) * float64(time.Minute))
+	for i := len(intervals) - 1; i >= 0 && missing > 0; i-- {
+		length := intervals[i].End.Sub(intervals[i].Start)
+		if length > missing {
+			intervals[i].End = intervals[i].End.Add(-missing)
+			break
+		}
+		missing -= length
+		intervals = intervals[:i]
+	}
+	return intervals
+}
+
+// TableCharge bills started hours with a one hour minimum.
+func TableCharge(playedMins, ratePerHour float64) float64 {
+	billableMins := playedMins
+	if billableMins < 60 {
+		billableMins = 60
+	}
+	hours := math.Round((billableMins/60)*1e6) / 1e6
+	return math.Round(math.Ceil(hours)*ratePerHour*100) / 100
+}
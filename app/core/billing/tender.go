@@ -0,0 +1,55 @@
+package billing
+
+import (
+	"fmt"
+	"math"
+)
+
+// Tender is one way a bill is paid. Tendered is what the customer handed
+// over; Amount is the part of the bill it settles. Cash tenders go into the
+// drawer and may be overpaid.
+type Tender struct {
+	Type     string
+	Cash     bool
+	Tendered float64
+	Amount   float64
+}
+
+// SettleTenders checks that the tenders cover the amount due and works out
+// the change. Only cash can be overpaid; the change is taken back from the
+// cash tenders, last one first.
+func SettleTenders(due float64, tenders []Tender) ([]Tender, float64, error) {
+	due = round2(math.Max(due, 0))
+	total, nonCash := 0.0, 0.0
+	settled := make([]Tender, len(tenders))
+	for i, t := range tenders {
+		t.Tendered = round2(t.Tendered)
+		if t.Tendered <= 0 {
+			return nil, 0, fmt.Errorf("payment %d must have an amount above zero", i+1)
+		}
+		t.Amount = t.Tendered
+		total += t.Tendered
+		if !t.Cash {
+			nonCash += t.Tendered
+		}
+		settled[i] = t
+	}
+	total, nonCash = round2(total), round2(nonCash)
+	if total < due {
+		return nil, 0, fmt.Errorf("payments of %.2f do not cover the amount due of %.2f", total, due)
+	}
+	if nonCash > due {
+		return nil, 0, fmt.Errorf("non-cash payments of %.2f exceed the amount due of %.2f", nonCash, due)
+	}
+	change := round2(total - due)
+	left := change
+	for i := len(settled) - 1; i >= 0 && left > 0; i-- {
+		if !settled[i].Cash {
+			continue
+		}
+		taken := math.Min(settled[i].Amount, left)
+		settled[i].Amount = round2(settled[i].Amount - taken)
+		left = round2(left - taken)
+	}
+	return settled, change, nil
+}
@@ -0,0 +1,51 @@
+package billing
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSettleTenders(t *testing.T) {
+	cash := func(tendered float64) Tender { return Tender{Type: "CASH", Cash: true, Tendered: tendered} }
+	card := func(tendered float64) Tender { return Tender{Type: "CARD", Tendered: tendered} }
+	tests := []struct {
+		name        string
+		due         float64
+		tenders     []Tender
+		wantAmounts []float64
+		wantChange  float64
+		wantErr     string
+	}{
+		{"exact cash", 250, []Tender{cash(250)}, []float64{250}, 0, ""},
+		{"cash with change", 250, []Tender{cash(500)}, []float64{250}, 250, ""},
+		{"card and cash with change", 250, []Tender{card(200), cash(100)}, []float64{200, 50}, 50, ""},
+		{"change taken from the last cash tender first", 250, []Tender{cash(200), cash(100)}, []float64{200, 50}, 50, ""},
+		{"change spread over cash tenders", 100, []Tender{cash(100), card(50), cash(20)}, []float64{50, 50, 0}, 70, ""},
+		{"rounds to satang", 99.995, []Tender{cash(100)}, []float64{100}, 0, ""},
+		{"nothing due", -10, []Tender{cash(20)}, []float64{0}, 20, ""},
+		{"short", 250, []Tender{card(100), cash(100)}, nil, 0, "payments of 200.00 do not cover the amount due of 250.00"},
+		{"card overpaid", 250, []Tender{card(300)}, nil, 0, "non-cash payments of 300.00 exceed the amount due of 250.00"},
+		{"zero tender", 250, []Tender{cash(250), card(0)}, nil, 0, "payment 2 must have an amount above zero"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settled, change, err := SettleTenders(tt.due, tt.tenders)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("SettleTenders() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SettleTenders() error = %v", err)
+			}
+			var amounts []float64
+			for _, s := range settled {
+				amounts = append(amounts, s.Amount)
+			}
+			if !reflect.DeepEqual(amounts, tt.wantAmounts) || change != tt.wantChange {
+				t.Errorf("SettleTenders() = %v, change %v, want %v, change %v", amounts, change, tt.wantAmounts, tt.wantChange)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package businessday
+
+import (
+	"fmt"
+	"time"
+)
+
+const Layout = "2006-01-02"
+
+// Date returns the business day t falls in. A business day starts at the
+// cutover hour, so with a cutover of 6 a session started at 02:00 still
+// belongs to the day before.
+func Date(t time.Time, cutoverHour int) string {
+	return t.In(time.Local).Add(-time.Duration(cutoverHour) * time.Hour).Format(Layout)
+}
+
+// Bounds returns the first and last instant of a business day.
+func Bounds(date string, cutoverHour int) (time.Time, time.Time, error) {
+	day, err := time.ParseInLocation(Layout, date, time.Local)
+	if err != nil {
+		return day, day, fmt.Errorf("invalid business date %q", date)
+	}
+	start := day.Add(time.Duration(cutoverHour) * time.Hour)
+	return start, start.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
+
+// Span returns the first instant of the first business day and the last
+// instant of the last one.
+func Span(startDate, endDate string, cutoverHour int) (time.Time, time.Time, error) {
+	start, _, err := Bounds(startDate, cutoverHour)
+	if err != nil {
+		return start, start, err
+	}
+	_, end, err := Bounds(endDate, cutoverHour)
+	return start, end, err
+}
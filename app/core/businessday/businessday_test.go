@@ -0,0 +1,67 @@
+package businessday
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDate(t *testing.T) {
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2026, 10, day, hour, min, 0, 0, time.Local)
+	}
+	tests := []struct {
+		name    string
+		t       time.Time
+		cutover int
+		want    string
+	}{
+		{"midnight cutover", at(19, 0, 0), 0, "2026-10-19"},
+		{"before the cutover belongs to the day before", at(20, 2, 0), 6, "2026-10-19"},
+		{"last minute before the cutover", at(20, 5, 59), 6, "2026-10-19"},
+		{"at the cutover", at(20, 6, 0), 6, "2026-10-20"},
+		{"evening", at(19, 23, 30), 6, "2026-10-19"},
+		{"first of the month", at(1, 3, 0), 6, "2026-09-30"},
+		{"stored as UTC", at(20, 2, 0).UTC(), 6, "2026-10-19"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Date(tt.t, tt.cutover); got != tt.want {
+				t.Errorf("Date() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpan(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end string
+		cutover    int
+		wantStart  time.Time
+		wantEnd    time.Time
+		wantErr    bool
+	}{
+		{"one day at midnight", "2026-10-19", "2026-10-19", 0,
+			time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local),
+			time.Date(2026, 10, 20, 0, 0, 0, 0, time.Local).Add(-time.Nanosecond), false},
+		{"several days with a cutover", "2026-10-19", "2026-10-21", 6,
+			time.Date(2026, 10, 19, 6, 0, 0, 0, time.Local),
+			time.Date(2026, 10, 22, 6, 0, 0, 0, time.Local).Add(-time.Nanosecond), false},
+		{"invalid start", "19/10/2026", "2026-10-21", 6, time.Time{}, time.Time{}, true},
+		{"invalid end", "2026-10-19", "", 6, time.Time{}, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := Span(tt.start, tt.end, tt.cutover)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Span() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Errorf("Span() = %v, %v, want %v, %v", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
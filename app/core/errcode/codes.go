@@ -20,6 +20,9 @@ const (
 const (
 	TS_BAD_REQUEST_001 = "TS-400-001" // invalid request body
 	TS_BAD_REQUEST_002 = "TS-400-002" // create/update/delete failed
+	TS_BAD_REQUEST_003 = "TS-400-003" // promotion not eligible
+	TS_BAD_REQUEST_004 = "TS-400-004" // payments do not settle the bill
+	TS_CONFLICT_001    = "TS-409-001" // session already closed
 	TS_INTERNAL_001    = "TS-500-001" // internal server error
 )
 
@@ -34,6 +37,7 @@ const (
 const (
 	MC_BAD_REQUEST_001 = "MC-400-001" // invalid request body
 	MC_BAD_REQUEST_002 = "MC-400-002" // create/update/delete failed
+	MC_CONFLICT_001    = "MC-409-001" // category still has items
 	MC_INTERNAL_001    = "MC-500-001" // internal server error
 )
 
@@ -41,21 +45,63 @@ const (
 const (
 	MI_BAD_REQUEST_001 = "MI-400-001" // invalid request body
 	MI_BAD_REQUEST_002 = "MI-400-002" // create/update/delete failed
+	MI_BAD_REQUEST_003 = "MI-400-003" // import file has row errors
+	MI_CONFLICT_001    = "MI-409-001" // duplicate code or item has variants
 	MI_INTERNAL_001    = "MI-500-001" // internal server error
 )
 
+// ─── Price List (PL) ────────────────────────────────────────────────────────
+const (
+	PL_BAD_REQUEST_001 = "PL-400-001" // invalid request body
+	PL_BAD_REQUEST_002 = "PL-400-002" // create/update/delete failed
+	PL_INTERNAL_001    = "PL-500-001" // internal server error
+)
+
+// ─── Ingredient (IG) ────────────────────────────────────────────────────────
+const (
+	IG_BAD_REQUEST_001 = "IG-400-001" // invalid request body
+	IG_BAD_REQUEST_002 = "IG-400-002" // create/update/delete failed
+	IG_INTERNAL_001    = "IG-500-001" // internal server error
+)
+
+// ─── Stock Take (ST) ────────────────────────────────────────────────────────
+const (
+	ST_BAD_REQUEST_001 = "ST-400-001" // invalid request body
+	ST_BAD_REQUEST_002 = "ST-400-002" // create/update failed
+	ST_CONFLICT_001    = "ST-409-001" // stock take status conflict
+	ST_INTERNAL_001    = "ST-500-001" // internal server error
+)
+
 // ─── Table Order (TO) ───────────────────────────────────────────────────────
 const (
 	TO_BAD_REQUEST_001 = "TO-400-001" // invalid request body
 	TO_BAD_REQUEST_002 = "TO-400-002" // create/update/delete failed
+	TO_CONFLICT_001    = "TO-409-001" // out of stock
+	TO_CONFLICT_002    = "TO-409-002" // session is closed
+	TO_CONFLICT_003    = "TO-409-003" // order adjustment conflict
 	TO_INTERNAL_001    = "TO-500-001" // internal server error
 )
 
+// ─── Order Ticket (OT) ──────────────────────────────────────────────────────
+const (
+	OT_BAD_REQUEST_001 = "OT-400-001" // invalid request body
+	OT_BAD_REQUEST_002 = "OT-400-002" // ticket not found
+	OT_CONFLICT_001    = "OT-409-001" // invalid status transition
+	OT_INTERNAL_001    = "OT-500-001" // internal server error
+)
+
 // ─── Payment (PY) ───────────────────────────────────────────────────────────
 const (
-	PY_BAD_REQUEST_001 = "PY-400-001" // invalid request body
-	PY_BAD_REQUEST_002 = "PY-400-002" // create/update/delete failed
-	PY_INTERNAL_001    = "PY-500-001" // internal server error
+	PY_BAD_REQUEST_001  = "PY-400-001" // invalid request body
+	PY_BAD_REQUEST_002  = "PY-400-002" // create/update/delete failed
+	PY_BAD_REQUEST_003  = "PY-400-003" // payment cannot be reversed
+	PY_UNAUTHORIZED_001 = "PY-401-001" // webhook signature invalid
+	PY_NOT_FOUND_001    = "PY-404-001" // payment provider not found
+	PY_CONFLICT_001     = "PY-409-001" // payment request is no longer pending
+	PY_CONFLICT_002     = "PY-409-002" // payment reversal is no longer pending
+	PY_CONFLICT_003     = "PY-409-003" // reversal exceeds the amount left on the payment
+	PY_INTERNAL_001     = "PY-500-001" // internal server error
+	PY_BAD_GATEWAY_001  = "PY-502-001" // payment gateway request failed
 )
 
 // ─── Creditor (CR) ──────────────────────────────────────────────────────────
@@ -65,6 +111,15 @@ const (
 	CR_INTERNAL_001    = "CR-500-001" // internal server error
 )
 
+// ─── Cash Shift (CS) ────────────────────────────────────────────────────────
+const (
+	CS_BAD_REQUEST_001 = "CS-400-001" // invalid request body
+	CS_BAD_REQUEST_002 = "CS-400-002" // open/update/close failed
+	CS_CONFLICT_001    = "CS-409-001" // cashier already has an open shift
+	CS_CONFLICT_002    = "CS-409-002" // shift is already closed
+	CS_INTERNAL_001    = "CS-500-001" // internal server error
+)
+
 // ─── Promotion (PM) ─────────────────────────────────────────────────────────
 const (
 	PM_BAD_REQUEST_001 = "PM-400-001" // invalid request body
@@ -100,6 +155,44 @@ const (
 	RP_INTERNAL_001    = "RP-500-001" // internal server error
 )
 
+// ─── Loyalty (LY) ───────────────────────────────────────────────────────────
+const (
+	LY_BAD_REQUEST_001 = "LY-400-001" // invalid request body
+	LY_BAD_REQUEST_002 = "LY-400-002" // create/update/adjust failed
+	LY_INTERNAL_001    = "LY-500-001" // internal server error
+)
+
+// ─── Voucher (VC) ───────────────────────────────────────────────────────────
+const (
+	VC_BAD_REQUEST_001 = "VC-400-001" // invalid request body
+	VC_BAD_REQUEST_002 = "VC-400-002" // create/update failed
+	VC_INTERNAL_001    = "VC-500-001" // internal server error
+)
+
+// ─── Supplier (SP) ──────────────────────────────────────────────────────────
+const (
+	SP_BAD_REQUEST_001 = "SP-400-001" // invalid request body
+	SP_BAD_REQUEST_002 = "SP-400-002" // create/update failed
+	SP_INTERNAL_001    = "SP-500-001" // internal server error
+)
+
+// ─── Purchase Order (PO) ────────────────────────────────────────────────────
+const (
+	PO_BAD_REQUEST_001 = "PO-400-001" // invalid request body
+	PO_BAD_REQUEST_002 = "PO-400-002" // create/update/receive failed
+	PO_CONFLICT_001    = "PO-409-001" // purchase order no longer editable
+	PO_INTERNAL_001    = "PO-500-001" // internal server error
+)
+
+// ─── Day Close (DC) ─────────────────────────────────────────────────────────
+const (
+	DC_BAD_REQUEST_001 = "DC-400-001" // invalid request / missing params
+	DC_BAD_REQUEST_002 = "DC-400-002" // day close failed
+	DC_CONFLICT_001    = "DC-409-001" // business day is closed
+	DC_CONFLICT_002    = "DC-409-002" // business day still has open sessions
+	DC_INTERNAL_001    = "DC-500-001" // internal server error
+)
+
 // ─── System (SY) ────────────────────────────────────────────────────────────
 const (
 	SY_NOT_FOUND_001 = "SY-404-001" // route not found
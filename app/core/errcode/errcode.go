@@ -7,14 +7,19 @@ import (
 )
 
 type AppError struct {
-	ErrCode string `json:"errcode"`
-	Error   string `json:"error"`
+	ErrCode string   `json:"errcode"`
+	Error   string   `json:"error"`
+	Details []string `json:"details,omitempty"`
 }
 
 func Abort(ctx *gin.Context, httpStatus int, code string, msg string) {
 	ctx.AbortWithStatusJSON(httpStatus, AppError{ErrCode: code, Error: msg})
 }
 
+func AbortWithDetails(ctx *gin.Context, httpStatus int, code string, msg string, details []string) {
+	ctx.AbortWithStatusJSON(httpStatus, AppError{ErrCode: code, Error: msg, Details: details})
+}
+
 func AbortByCode(ctx *gin.Context, code string, msg string) {
 	info, ok := GetCodeInfo(code)
 	if !ok {
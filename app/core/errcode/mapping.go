@@ -23,6 +23,9 @@ var codeMap = map[string]CodeInfo{
 	// ─── Table Session (TS) ─────────────────────────────────────────────────
 	TS_BAD_REQUEST_001: {http.StatusBadRequest, "invalid request body"},
 	TS_BAD_REQUEST_002: {http.StatusBadRequest, "operation failed"},
+	TS_BAD_REQUEST_003: {http.StatusBadRequest, "promotion not eligible"},
+	TS_BAD_REQUEST_004: {http.StatusBadRequest, "payments do not settle the bill"},
+	TS_CONFLICT_001:    {http.StatusConflict, "session already closed"},
 	TS_INTERNAL_001:    {http.StatusInternalServerError, "internal server error"},
 
 	// ─── Booking (BK) ───────────────────────────────────────────────────────
@@ -33,28 +36,70 @@ var codeMap = map[string]CodeInfo{
 	// ─── Menu Category (MC) ─────────────────────────────────────────────────
 	MC_BAD_REQUEST_001: {http.StatusBadRequest, "invalid request body"},
 	MC_BAD_REQUEST_002: {http.StatusBadRequest, "create/update/delete failed"},
+	MC_CONFLICT_001:    {http.StatusConflict, "category still has items"},
 	MC_INTERNAL_001:    {http.StatusInternalServerError, "internal server error"},
 
 	// ─── Menu Item (MI) ─────────────────────────────────────────────────────
 	MI_BAD_REQUEST_001: {http.StatusBadRequest, "invalid request body"},
 	MI_BAD_REQUEST_002: {http.StatusBadRequest, "create/update/delete failed"},
+	MI_BAD_REQUEST_003: {http.StatusBadRequest, "import file has row errors"},
+	MI_CONFLICT_001:    {http.StatusConflict, "duplicate code or item has variants"},
 	MI_INTERNAL_001:    {http.StatusInternalServerError, "internal server error"},
 
+	// ─── Price List (PL) ────────────────────────────────────────────────────
+	PL_BAD_REQUEST_001: {http.StatusBadRequest, "invalid request body"},
+	PL_BAD_REQUEST_002: {http.StatusBadRequest, "create/update/delete failed"},
+	PL_INTERNAL_001:    {http.StatusInternalServerError, "internal server error"},
+
+	// ─── Ingredient (IG) ────────────────────────────────────────────────────
+	IG_BAD_REQUEST_001: {http.StatusBadRequest, "invalid request body"},
+	IG_BAD_REQUEST_002: {http.StatusBadRequest, "create/update/delete failed"},
+	IG_INTERNAL_001:    {http.StatusInternalServerError, "internal server error"},
+
+	// ─── Stock Take (ST) ────────────────────────────────────────────────────
+	ST_BAD_REQUEST_001: {http.StatusBadRequest, "invalid request body"},
+	ST_BAD_REQUEST_002: {http.StatusBadRequest, "create/update failed"},
+	ST_CONFLICT_001:    {http.StatusConflict, "stock take status conflict"},
+	ST_INTERNAL_001:    {http.StatusInternalServerError, "internal server error"},
+
 	// ─── Table Order (TO) ───────────────────────────────────────────────────
 	TO_BAD_REQUEST_001: {http.StatusBadRequest, "invalid request body"},
 	TO_BAD_REQUEST_002: {http.StatusBadRequest, "create/update/delete failed"},
+	TO_CONFLICT_001:    {http.StatusConflict, "out of stock"},
+	TO_CONFLICT_002:    {http.StatusConflict, "session is closed"},
+	TO_CONFLICT_003:    {http.StatusConflict, "order adjustment conflict"},
 	TO_INTERNAL_001:    {http.StatusInternalServerError, "internal server error"},
 
+	// ─── Order Ticket (OT) ──────────────────────────────────────────────────
+	OT_BAD_REQUEST_001: {http.StatusBadRequest, "invalid request body"},
+	OT_BAD_REQUEST_002: {http.StatusBadRequest, "ticket not found"},
+	OT_CONFLICT_001:    {http.StatusConflict, "invalid status transition"},
+	OT_INTERNAL_001:    {http.StatusInternalServerError, "internal server error"},
+
 	// ─── Payment (PY) ───────────────────────────────────────────────────────
-	PY_BAD_REQUEST_001: {http.StatusBadRequest, "invalid request body"},
-	PY_BAD_REQUEST_002: {http.StatusBadRequest, "create/update/delete failed"},
-	PY_INTERNAL_001:    {http.StatusInternalServerError, "internal server error"},
+	PY_BAD_REQUEST_001:  {http.StatusBadRequest, "invalid request body"},
+	PY_BAD_REQUEST_002:  {http.StatusBadRequest, "create/update/delete failed"},
+	PY_BAD_REQUEST_003:  {http.StatusBadRequest, "payment cannot be reversed"},
+	PY_UNAUTHORIZED_001: {http.StatusUnauthorized, "webhook signature invalid"},
+	PY_NOT_FOUND_001:    {http.StatusNotFound, "payment provider not found"},
+	PY_CONFLICT_001:     {http.StatusConflict, "payment request is no longer pending"},
+	PY_CONFLICT_002:     {http.StatusConflict, "payment reversal is no longer pending"},
+	PY_CONFLICT_003:     {http.StatusConflict, "reversal exceeds the amount left on the payment"},
+	PY_INTERNAL_001:     {http.StatusInternalServerError, "internal server error"},
+	PY_BAD_GATEWAY_001:  {http.StatusBadGateway, "payment gateway request failed"},
 
 	// ─── Creditor (CR) ──────────────────────────────────────────────────────
 	CR_BAD_REQUEST_001: {http.StatusBadRequest, "invalid request body"},
 	CR_BAD_REQUEST_002: {http.StatusBadRequest, "create/update/delete failed"},
 	CR_INTERNAL_001:    {http.StatusInternalServerError, "internal server error"},
 
+	// ─── Cash Shift (CS) ────────────────────────────────────────────────────
+	CS_BAD_REQUEST_001: {http.StatusBadRequest, "invalid request body"},
+	CS_BAD_REQUEST_002: {http.StatusBadRequest, "open/update/close failed"},
+	CS_CONFLICT_001:    {http.StatusConflict, "cashier already has an open shift"},
+	CS_CONFLICT_002:    {http.StatusConflict, "shift is already closed"},
+	CS_INTERNAL_001:    {http.StatusInternalServerError, "internal server error"},
+
 	// ─── Promotion (PM) ─────────────────────────────────────────────────────
 	PM_BAD_REQUEST_001: {http.StatusBadRequest, "invalid request body"},
 	PM_BAD_REQUEST_002: {http.StatusBadRequest, "create/update/delete failed"},
@@ -80,6 +125,34 @@ var codeMap = map[string]CodeInfo{
 	RP_BAD_REQUEST_002: {http.StatusBadRequest, "report generation failed"},
 	RP_INTERNAL_001:    {http.StatusInternalServerError, "internal server error"},
 
+	// ─── Loyalty (LY) ───────────────────────────────────────────────────────
+	LY_BAD_REQUEST_001: {http.StatusBadRequest, "invalid request body"},
+	LY_BAD_REQUEST_002: {http.StatusBadRequest, "create/update/adjust failed"},
+	LY_INTERNAL_001:    {http.StatusInternalServerError, "internal server error"},
+
+	// ─── Voucher (VC) ───────────────────────────────────────────────────────
+	VC_BAD_REQUEST_001: {http.StatusBadRequest, "invalid request body"},
+	VC_BAD_REQUEST_002: {http.StatusBadRequest, "create/update failed"},
+	VC_INTERNAL_001:    {http.StatusInternalServerError, "internal server error"},
+
+	// ─── Supplier (SP) ──────────────────────────────────────────────────────
+	SP_BAD_REQUEST_001: {http.StatusBadRequest, "invalid request body"},
+	SP_BAD_REQUEST_002: {http.StatusBadRequest, "create/update failed"},
+	SP_INTERNAL_001:    {http.StatusInternalServerError, "internal server error"},
+
+	// ─── Purchase Order (PO) ────────────────────────────────────────────────
+	PO_BAD_REQUEST_001: {http.StatusBadRequest, "invalid request body"},
+	PO_BAD_REQUEST_002: {http.StatusBadRequest, "create/update/receive failed"},
+	PO_CONFLICT_001:    {http.StatusConflict, "purchase order no longer editable"},
+	PO_INTERNAL_001:    {http.StatusInternalServerError, "internal server error"},
+
+	// ─── Day Close (DC) ─────────────────────────────────────────────────────
+	DC_BAD_REQUEST_001: {http.StatusBadRequest, "invalid request / missing params"},
+	DC_BAD_REQUEST_002: {http.StatusBadRequest, "day close failed"},
+	DC_CONFLICT_001:    {http.StatusConflict, "business day is closed"},
+	DC_CONFLICT_002:    {http.StatusConflict, "business day still has open sessions"},
+	DC_INTERNAL_001:    {http.StatusInternalServerError, "internal server error"},
+
 	// ─── System (SY) ────────────────────────────────────────────────────────
 	SY_NOT_FOUND_001: {http.StatusNotFound, "route not found"},
 	SY_FORBIDDEN_001: {http.StatusForbidden, "invalid request, restricted endpoint"},
@@ -0,0 +1,87 @@
+package gateway
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// FakeSignatureHeader carries the hex HMAC-SHA256 of the webhook body.
+const FakeSignatureHeader = "X-Fake-Signature"
+
+// FakeProvider accepts every charge and lets the caller produce the
+// webhooks a real gateway would send, signed with the shared secret.
+type FakeProvider struct {
+	Secret string
+}
+
+type fakeEvent struct {
+	Id       string  `json:"id"`
+	Type     string  `json:"type"`
+	ChargeId string  `json:"chargeId"`
+	Amount   float64 `json:"amount"`
+}
+
+func NewFakeProvider(secret string) *FakeProvider {
+	return &FakeProvider{Secret: secret}
+}
+
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakeProvider) CreateCharge(_ context.Context, charge Charge) (ChargeResult, error) {
+	if charge.Amount <= 0 {
+		return ChargeResult{}, errors.New("charge amount must be above zero")
+	}
+	ref := "fake_ch_" + randomHex(12)
+	return ChargeResult{
+		ProviderRef: ref,
+		Payload:     fmt.Sprintf("fake://pay/%s?amount=%.2f&currency=%s", ref, charge.Amount, charge.Currency),
+	}, nil
+}
+
+func (p *FakeProvider) ParseWebhook(header http.Header, body []byte) (Event, error) {
+	if p.Secret == "" {
+		return Event{}, ErrInvalidSignature
+	}
+	signature, err := hex.DecodeString(header.Get(FakeSignatureHeader))
+	if err != nil || !hmac.Equal(signature, p.mac(body)) {
+		return Event{}, ErrInvalidSignature
+	}
+	var e fakeEvent
+	if err := json.Unmarshal(body, &e); err != nil {
+		return Event{}, err
+	}
+	if e.Id == "" || e.ChargeId == "" {
+		return Event{}, errors.New("webhook event needs an id and a chargeId")
+	}
+	return Event{Id: e.Id, Type: e.Type, ProviderRef: e.ChargeId, Amount: e.Amount}, nil
+}
+
+// NewEvent builds the body and signature of a webhook about a charge.
+func (p *FakeProvider) NewEvent(eventType, providerRef string, amount float64) ([]byte, string, error) {
+	body, err := json.Marshal(fakeEvent{Id: "fake_ev_" + randomHex(12), Type: eventType, ChargeId: providerRef, Amount: amount})
+	if err != nil {
+		return nil, "", err
+	}
+	return body, hex.EncodeToString(p.mac(body)), nil
+}
+
+func (p *FakeProvider) mac(body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(p.Secret))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,54 @@
+package gateway
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestFakeProviderParseWebhook(t *testing.T) {
+	provider := NewFakeProvider("secret")
+	body, signature, err := provider.NewEvent("charge.succeeded", "fake_ch_1", 250)
+	if err != nil {
+		t.Fatalf("NewEvent() error = %v", err)
+	}
+	other, otherSignature, err := NewFakeProvider("other").NewEvent("charge.succeeded", "fake_ch_1", 250)
+	if err != nil {
+		t.Fatalf("NewEvent() error = %v", err)
+	}
+	tampered := append([]byte{}, body...)
+	tampered[len(tampered)-2] = '9'
+
+	tests := []struct {
+		name      string
+		provider  *FakeProvider
+		body      []byte
+		signature string
+		wantErr   error
+	}{
+		{"valid signature", provider, body, signature, nil},
+		{"missing signature", provider, body, "", ErrInvalidSignature},
+		{"signature is not hex", provider, body, "not-hex", ErrInvalidSignature},
+		{"signed with another secret", provider, other, otherSignature, ErrInvalidSignature},
+		{"body changed after signing", provider, tampered, signature, ErrInvalidSignature},
+		{"no secret configured", NewFakeProvider(""), body, signature, ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.signature != "" {
+				header.Set(FakeSignatureHeader, tt.signature)
+			}
+			event, err := tt.provider.ParseWebhook(header, tt.body)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseWebhook() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if event.Type != "charge.succeeded" || event.ProviderRef != "fake_ch_1" || event.Amount != 250 || event.Id == "" {
+				t.Errorf("ParseWebhook() = %+v", event)
+			}
+		})
+	}
+}
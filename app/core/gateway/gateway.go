@@ -0,0 +1,66 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Webhook event types understood by the payment feature.
+const (
+	EventChargeSucceeded = "charge.succeeded"
+	EventChargeFailed    = "charge.failed"
+)
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Charge asks a provider to collect an amount for a payment intent.
+type Charge struct {
+	Reference string
+	Amount    float64
+	Currency  string
+	Method    string
+}
+
+// ChargeResult is what the customer needs to pay a charge: a QR payload or
+// a page to open, depending on the provider and method.
+type ChargeResult struct {
+	ProviderRef string
+	Payload     string
+	RedirectUrl string
+}
+
+// Event is a verified webhook delivery. Providers deliver at least once, so
+// the same Id can arrive more than once.
+type Event struct {
+	Id          string
+	Type        string
+	ProviderRef string
+	Amount      float64
+}
+
+// Provider is a payment gateway. ParseWebhook must reject deliveries whose
+// signature does not verify.
+type Provider interface {
+	Name() string
+	CreateCharge(ctx context.Context, charge Charge) (ChargeResult, error)
+	ParseWebhook(header http.Header, body []byte) (Event, error)
+}
+
+// NewProvider builds the provider selected by PAYMENT_PROVIDER. Only the
+// local "fake" provider is built in, for development and testing. Any other
+// name stops startup rather than taking payments through the fake one.
+func NewProvider() Provider {
+	name := os.Getenv("PAYMENT_PROVIDER")
+	if name != "" && name != "fake" {
+		logrus.Fatal("unsupported payment provider ", name)
+	}
+	secret := os.Getenv("PAYMENT_WEBHOOK_SECRET")
+	if secret == "" {
+		logrus.Warn("PAYMENT_WEBHOOK_SECRET is not set, payment webhooks will be rejected")
+	}
+	return NewFakeProvider(secret)
+}
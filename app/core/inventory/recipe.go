@@ -0,0 +1,40 @@
+package inventory
+
+import (
+	"math"
+	"snook/app/data/entities"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Availability returns how many portions of the recipe the ingredient stock
+// covers. An ingredient missing from stock counts as empty.
+func Availability(recipe []entities.RecipeLine, stock map[primitive.ObjectID]float64) int {
+	if len(recipe) == 0 {
+		return 0
+	}
+	portions := math.MaxInt32
+	for _, line := range recipe {
+		if line.Quantity <= 0 {
+			continue
+		}
+		// Round first so 0.3 / 0.1 is not counted as 2 portions.
+		n := int(math.Floor(math.Round(stock[line.IngredientId]/line.Quantity*1e6) / 1e6))
+		if n < portions {
+			portions = n
+		}
+	}
+	if portions < 0 || portions == math.MaxInt32 {
+		return 0
+	}
+	return portions
+}
+
+// RecipeCost is the cost of goods of one portion at the current ingredient costs.
+func RecipeCost(recipe []entities.RecipeLine, costs map[primitive.ObjectID]float64) float64 {
+	total := 0.0
+	for _, line := range recipe {
+		total += line.Quantity * costs[line.IngredientId]
+	}
+	return math.Round(total*100) / 100
+}
@@ -0,0 +1,119 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+const (
+	// MaxImageBytes limits the size of an uploaded image file.
+	MaxImageBytes = 5 << 20
+	// MaxImagePixels guards against images that decode to huge bitmaps.
+	MaxImagePixels = 40_000_000
+	// ThumbnailSize is the longest side of a thumbnail in pixels.
+	ThumbnailSize = 320
+)
+
+var allowedTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// Image is a validated upload together with its thumbnail. Thumbnails are
+// JPEG, or PNG when the original is a PNG so transparency is kept.
+type Image struct {
+	ContentType          string
+	Ext                  string
+	Data                 []byte
+	ThumbnailContentType string
+	ThumbnailExt         string
+	Thumbnail            []byte
+}
+
+// ProcessImage checks the file type from its content, bounds its size and
+// dimensions and renders a thumbnail.
+func ProcessImage(data []byte) (Image, error) {
+	if len(data) == 0 {
+		return Image{}, errors.New("image is empty")
+	}
+	if len(data) > MaxImageBytes {
+		return Image{}, fmt.Errorf("image must not be larger than %d MB", MaxImageBytes>>20)
+	}
+	contentType := http.DetectContentType(data)
+	ext, ok := allowedTypes[contentType]
+	if !ok {
+		return Image{}, errors.New("image must be a JPEG, PNG or GIF")
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, errors.New("image could not be read")
+	}
+	if config.Width*config.Height > MaxImagePixels {
+		return Image{}, errors.New("image dimensions are too large")
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, errors.New("image could not be read")
+	}
+	result := Image{ContentType: contentType, Ext: ext, Data: data}
+	thumb := resize(src, ThumbnailSize)
+	var buf bytes.Buffer
+	if contentType == "image/png" {
+		err = png.Encode(&buf, thumb)
+		result.ThumbnailContentType, result.ThumbnailExt = "image/png", ".png"
+	} else {
+		err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 85})
+		result.ThumbnailContentType, result.ThumbnailExt = "image/jpeg", ".jpg"
+	}
+	if err != nil {
+		return Image{}, err
+	}
+	result.Thumbnail = buf.Bytes()
+	return result, nil
+}
+
+// resize scales src down so its longest side is at most size, averaging the
+// source pixels that fall into each target pixel. Smaller images are only
+// copied.
+func resize(src image.Image, size int) *image.NRGBA {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	tw, th := w, h
+	if w > size || h > size {
+		if w >= h {
+			tw, th = size, max(1, h*size/w)
+		} else {
+			tw, th = max(1, w*size/h), size
+		}
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0 := bounds.Min.Y + y*h/th
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*h/th)
+		for x := 0; x < tw; x++ {
+			x0 := bounds.Min.X + x*w/tw
+			x1 := max(x0+1, bounds.Min.X+(x+1)*w/tw)
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := color.NRGBAModel.Convert(src.At(sx, sy)).(color.NRGBA)
+					r += uint64(c.R)
+					g += uint64(c.G)
+					b += uint64(c.B)
+					a += uint64(c.A)
+					n++
+				}
+			}
+			dst.SetNRGBA(x, y, color.NRGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(b / n), A: uint8(a / n)})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,81 @@
+package promptpay
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PromptPay application id of a merchant presented QR code.
+const applicationId = "A000000677010111"
+
+// Payload builds the EMVCo merchant presented QR payload of a PromptPay
+// transfer of amount to id. The id is a mobile number, a national or tax id
+// of 13 digits or an e-wallet id of 15 digits. With an amount the code is
+// dynamic, meant to be paid once.
+func Payload(id string, amount float64) (string, error) {
+	account, err := accountTag(id)
+	if err != nil {
+		return "", err
+	}
+	if amount < 0 {
+		return "", errors.New("amount must not be negative")
+	}
+	initiation := "11"
+	if amount > 0 {
+		initiation = "12"
+	}
+	var b strings.Builder
+	b.WriteString(tlv("00", "01"))
+	b.WriteString(tlv("01", initiation))
+	b.WriteString(tlv("29", tlv("00", applicationId)+account))
+	b.WriteString(tlv("53", "764"))
+	if amount > 0 {
+		b.WriteString(tlv("54", fmt.Sprintf("%.2f", amount)))
+	}
+	b.WriteString(tlv("58", "TH"))
+	b.WriteString("6304")
+	return b.String() + fmt.Sprintf("%04X", crc16(b.String())), nil
+}
+
+// accountTag maps a PromptPay id to its tag inside the merchant account
+// information.
+func accountTag(id string) (string, error) {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, id)
+	switch {
+	case len(digits) == 10 && digits[0] == '0':
+		return tlv("01", "0066"+digits[1:]), nil
+	case len(digits) == 11 && strings.HasPrefix(digits, "66"):
+		return tlv("01", "00"+digits), nil
+	case len(digits) == 13:
+		return tlv("02", digits), nil
+	case len(digits) == 15:
+		return tlv("03", digits), nil
+	}
+	return "", errors.New("PromptPay id must be a mobile number, a 13 digit tax id or a 15 digit e-wallet id")
+}
+
+func tlv(tag, value string) string {
+	return fmt.Sprintf("%s%02d%s", tag, len(value), value)
+}
+
+// crc16 is CRC-16/CCITT-FALSE as EMVCo requires.
+func crc16(data string) uint16 {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
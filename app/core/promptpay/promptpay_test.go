@@ -0,0 +1,44 @@
+package promptpay
+
+import "testing"
+
+func TestPayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		amount  float64
+		want    string
+		wantErr bool
+	}{
+		{"mobile without amount", "081-234-5678", 0,
+			"00020101021129370016A0000006770101110113006681234567853037645802TH6304823E", false},
+		{"mobile with amount", "0812345678", 150.5,
+			"00020101021229370016A0000006770101110113006681234567853037645406150.505802TH6304CAAD", false},
+		{"mobile with country code", "+66812345678", 150.5,
+			"00020101021229370016A0000006770101110113006681234567853037645406150.505802TH6304CAAD", false},
+		{"tax id", "1-2345-67890-12-3", 1000,
+			"00020101021229370016A00000067701011102131234567890123530376454071000.005802TH6304366D", false},
+		{"e-wallet", "123456789012345", 0,
+			"00020101021129390016A000000677010111031512345678901234553037645802TH6304AC13", false},
+		{"short id", "12345", 100, "", true},
+		{"negative amount", "0812345678", -1, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Payload(tt.id, tt.amount)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Payload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Payload() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCRC16(t *testing.T) {
+	// The CRC-16/CCITT-FALSE check value.
+	if got := crc16("123456789"); got != 0x29B1 {
+		t.Errorf("crc16() = %04X, want 29B1", got)
+	}
+}
@@ -0,0 +1,44 @@
+package spreadsheet
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+// Sheet is a named table of cells. The first row holds the column headers.
+// Cells of the columns listed in Numeric are written to XLSX as numbers,
+// every other cell as text so codes such as barcodes keep leading zeros.
+type Sheet struct {
+	Name    string
+	Rows    [][]string
+	Numeric map[int]bool
+}
+
+// ReadCSV reads all records of a CSV file, dropping a UTF-8 byte order mark
+// left by spreadsheet programs.
+func ReadCSV(r io.Reader) ([][]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > 0 && len(rows[0]) > 0 {
+		rows[0][0] = strings.TrimPrefix(rows[0][0], "\ufeff")
+	}
+	return rows, nil
+}
+
+// WriteCSV writes the rows with a byte order mark so Excel opens the file as
+// UTF-8.
+func WriteCSV(w io.Writer, rows [][]string) error {
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
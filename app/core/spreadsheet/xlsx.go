@@ -0,0 +1,273 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// maxXLSXPartBytes bounds each uncompressed part so a small upload cannot
+// expand into a huge document.
+const maxXLSXPartBytes = 50 << 20
+
+// maxXLSXColumns bounds the columns of a row, as a cell reference far to the
+// right would otherwise pad the row with thousands of empty cells.
+const maxXLSXColumns = 256
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RId  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		Id     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	for _, r := range t.Runs {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			R  string   `xml:"r,attr"`
+			T  string   `xml:"t,attr"`
+			V  string   `xml:"v"`
+			Is xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// ReadXLSX reads the cell values of every worksheet in workbook order.
+// Formulas are read as their cached values. A sheet with a row past maxRows
+// or a cell past maxXLSXColumns is refused before its rows are laid out.
+func ReadXLSX(data []byte, maxRows int) ([]Sheet, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errors.New("file is not a valid xlsx workbook")
+	}
+	parts := map[string]*zip.File{}
+	for _, f := range archive.File {
+		parts[f.Name] = f
+	}
+	var workbook xlsxWorkbook
+	if err := readXLSXPart(parts, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	var rels xlsxRelationships
+	if err := readXLSXPart(parts, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	targets := map[string]string{}
+	for _, rel := range rels.Relationships {
+		target := strings.TrimPrefix(rel.Target, "/")
+		if !strings.HasPrefix(target, "xl/") {
+			target = path.Join("xl", target)
+		}
+		targets[rel.Id] = target
+	}
+	var shared xlsxSharedStrings
+	if _, ok := parts["xl/sharedStrings.xml"]; ok {
+		if err := readXLSXPart(parts, "xl/sharedStrings.xml", &shared); err != nil {
+			return nil, err
+		}
+	}
+	var sheets []Sheet
+	for _, s := range workbook.Sheets {
+		var ws xlsxWorksheet
+		if err := readXLSXPart(parts, targets[s.RId], &ws); err != nil {
+			return nil, err
+		}
+		sheet := Sheet{Name: s.Name}
+		for i, row := range ws.Rows {
+			index := row.R - 1
+			if index < 0 {
+				index = i
+			}
+			if index >= maxRows {
+				return nil, fmt.Errorf("%s sheet has too many rows", s.Name)
+			}
+			for len(sheet.Rows) <= index {
+				sheet.Rows = append(sheet.Rows, nil)
+			}
+			var cells []string
+			for j, c := range row.Cells {
+				col := j
+				if c.R != "" {
+					if col, err = columnIndex(c.R); err != nil {
+						return nil, err
+					}
+				}
+				if col >= maxXLSXColumns {
+					return nil, fmt.Errorf("%s sheet has too many columns", s.Name)
+				}
+				for len(cells) <= col {
+					cells = append(cells, "")
+				}
+				switch c.T {
+				case "s":
+					n, err := strconv.Atoi(c.V)
+					if err != nil || n < 0 || n >= len(shared.Items) {
+						return nil, fmt.Errorf("cell %s refers to a missing shared string", c.R)
+					}
+					cells[col] = shared.Items[n].String()
+				case "inlineStr":
+					cells[col] = c.Is.String()
+				default:
+					cells[col] = c.V
+				}
+			}
+			sheet.Rows[index] = cells
+		}
+		sheets = append(sheets, sheet)
+	}
+	return sheets, nil
+}
+
+func readXLSXPart(parts map[string]*zip.File, name string, v any) error {
+	f, ok := parts[name]
+	if !ok {
+		return fmt.Errorf("xlsx part %s is missing", name)
+	}
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if err := xml.NewDecoder(io.LimitReader(r, maxXLSXPartBytes)).Decode(v); err != nil {
+		return fmt.Errorf("xlsx part %s could not be read", name)
+	}
+	return nil
+}
+
+// columnIndex turns the letters of a cell reference such as "AB12" into a
+// zero based column index.
+func columnIndex(ref string) (int, error) {
+	col := 0
+	for _, r := range ref {
+		if r >= '0' && r <= '9' {
+			break
+		}
+		if r < 'A' || r > 'Z' {
+			return 0, fmt.Errorf("invalid cell reference %s", ref)
+		}
+		col = col*26 + int(r-'A'+1)
+		if col > 16384 {
+			return 0, fmt.Errorf("invalid cell reference %s", ref)
+		}
+	}
+	if col == 0 {
+		return 0, fmt.Errorf("invalid cell reference %s", ref)
+	}
+	return col - 1, nil
+}
+
+func columnName(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
+
+// WriteXLSX writes the sheets as a minimal workbook without styles.
+func WriteXLSX(w io.Writer, sheets []Sheet) error {
+	archive := zip.NewWriter(w)
+	var contentTypes, workbook, rels strings.Builder
+	contentTypes.WriteString(xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	workbook.WriteString(xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	rels.WriteString(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i, sheet := range sheets {
+		n := i + 1
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escapeXML(sheet.Name), n, n)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+	}
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	rels.WriteString(`</Relationships>`)
+
+	files := []struct{ name, body string }{
+		{"[Content_Types].xml", contentTypes.String()},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", rels.String()},
+	}
+	for i, sheet := range sheets {
+		files = append(files, struct{ name, body string }{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), worksheetXML(sheet)})
+	}
+	for _, f := range files {
+		part, err := archive.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(part, f.body); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func worksheetXML(sheet Sheet) string {
+	var b strings.Builder
+	b.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, row := range sheet.Rows {
+		fmt.Fprintf(&b, `<row r="%d">`, i+1)
+		for j, value := range row {
+			if value == "" {
+				continue
+			}
+			ref := columnName(j) + strconv.Itoa(i+1)
+			if _, err := strconv.ParseFloat(value, 64); err == nil && i > 0 && sheet.Numeric[j] {
+				fmt.Fprintf(&b, `<c r="%s"><v>%s</v></c>`, ref, value)
+				continue
+			}
+			fmt.Fprintf(&b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, escapeXML(value))
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.String()
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,78 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestReadXLSX(t *testing.T) {
+	wide := func(n int) []string {
+		row := make([]string, n)
+		row[n-1] = "last"
+		return row
+	}
+	tests := []struct {
+		name    string
+		rows    [][]string
+		maxRows int
+		wantErr string
+	}{
+		{"header and one row", [][]string{{"code", "name"}, {"001", "Cola"}}, 10, ""},
+		{"rows up to the limit", [][]string{{"code"}, {"1"}, {"2"}}, 3, ""},
+		{"rows past the limit", [][]string{{"code"}, {"1"}, {"2"}, {"3"}}, 3, "Menu sheet has too many rows"},
+		{"columns up to the limit", [][]string{wide(maxXLSXColumns)}, 10, ""},
+		{"columns past the limit", [][]string{wide(maxXLSXColumns + 1)}, 10, "Menu sheet has too many columns"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteXLSX(&buf, []Sheet{{Name: "Menu", Rows: tt.rows}}); err != nil {
+				t.Fatalf("WriteXLSX() error = %v", err)
+			}
+			sheets, err := ReadXLSX(buf.Bytes(), tt.maxRows)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("ReadXLSX() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadXLSX() error = %v", err)
+			}
+			if len(sheets) != 1 || sheets[0].Name != "Menu" || !reflect.DeepEqual(sheets[0].Rows, tt.rows) {
+				t.Errorf("ReadXLSX() = %v, want rows %q", sheets, tt.rows)
+			}
+		})
+	}
+}
+
+func TestReadXLSXRejectsOtherFiles(t *testing.T) {
+	if _, err := ReadXLSX([]byte("code,name\n001,Cola\n"), 10); err == nil {
+		t.Error("ReadXLSX() accepted a CSV file")
+	}
+}
+
+func TestColumnIndex(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    int
+		wantErr bool
+	}{
+		{"A1", 0, false},
+		{"Z9", 25, false},
+		{"AA10", 26, false},
+		{"XFD1", 16383, false},
+		{"XFE1", 0, true},
+		{"a1", 0, true},
+		{"12", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := columnIndex(tt.ref)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("columnIndex(%q) = %d, %v, want %d, error %v", tt.ref, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
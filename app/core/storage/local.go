@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LocalRoute is where the API serves files of the local storage.
+const LocalRoute = "/uploads"
+
+// LocalStorage writes files below Dir and links them under PublicUrl.
+type LocalStorage struct {
+	Dir       string
+	PublicUrl string
+}
+
+func NewLocalStorage(dir, publicUrl string) *LocalStorage {
+	return &LocalStorage{Dir: dir, PublicUrl: strings.TrimSuffix(publicUrl, "/")}
+}
+
+func (s *LocalStorage) Save(_ context.Context, key, _ string, body io.Reader) (string, error) {
+	file, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".upload-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return "", err
+	}
+	return s.PublicUrl + "/" + key, nil
+}
+
+func (s *LocalStorage) Delete(_ context.Context, key string) error {
+	file, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) KeyOf(url string) (string, bool) {
+	key, ok := strings.CutPrefix(url, s.PublicUrl+"/")
+	return key, ok && key != ""
+}
+
+// path maps a key into Dir and rejects keys that would escape it.
+func (s *LocalStorage) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "..") {
+		return "", errors.New("invalid storage key")
+	}
+	return filepath.Join(s.Dir, filepath.FromSlash(clean)), nil
+}
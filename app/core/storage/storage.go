@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Storage keeps uploaded files and serves them from a public URL. Keys are
+// slash separated paths such as "menu-items/<id>/<name>.jpg".
+type Storage interface {
+	Save(ctx context.Context, key, contentType string, body io.Reader) (string, error)
+	Delete(ctx context.Context, key string) error
+	// KeyOf returns the key of a URL this storage produced.
+	KeyOf(url string) (string, bool)
+}
+
+// NewStorage builds the storage selected by STORAGE_DRIVER. Only "local" is
+// built in, and any other driver stops the server.
+func NewStorage() Storage {
+	driver := os.Getenv("STORAGE_DRIVER")
+	if driver != "" && driver != "local" {
+		logrus.Fatal("unsupported storage driver ", driver)
+	}
+	dir := os.Getenv("STORAGE_LOCAL_DIR")
+	if dir == "" {
+		dir = "uploads"
+	}
+	publicUrl := os.Getenv("STORAGE_PUBLIC_URL")
+	if publicUrl == "" {
+		publicUrl = LocalRoute
+	}
+	return NewLocalStorage(dir, publicUrl)
+}
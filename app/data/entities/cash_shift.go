@@ -0,0 +1,67 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CashShift is one cashier's turn at the drawer. Cash taken and paid out by
+// the cashier while the shift is open is expected in the drawer at close;
+// the figures are worked out live while open and frozen when it closes.
+type CashShift struct {
+	Id           primitive.ObjectID `bson:"_id" json:"id"`
+	UserId       string             `bson:"userId" json:"userId"`
+	Status       string             `bson:"status" json:"status"` // OPEN or CLOSED
+	OpeningFloat float64            `bson:"openingFloat" json:"openingFloat"`
+	Movements    []CashMovement     `bson:"movements" json:"movements"`
+	CountedCash  *float64           `bson:"countedCash,omitempty" json:"countedCash,omitempty"`
+	OverShort    *float64           `bson:"overShort,omitempty" json:"overShort,omitempty"`
+	Note         string             `bson:"note" json:"note"`
+	OpenedDate   time.Time          `bson:"openedDate" json:"openedDate"`
+	ClosedBy     string             `bson:"closedBy,omitempty" json:"closedBy,omitempty"`
+	ClosedDate   *time.Time         `bson:"closedDate,omitempty" json:"closedDate,omitempty"`
+	CashSummary  `bson:",inline"`
+}
+
+// CashMovement is cash put into (PAY_IN) or taken out of (PAY_OUT) the
+// drawer for something other than a sale or an expense, such as change
+// brought from the bank.
+type CashMovement struct {
+	Id          primitive.ObjectID `bson:"_id" json:"id"`
+	Type        string             `bson:"type" json:"type"`
+	Amount      float64            `bson:"amount" json:"amount"`
+	Reason      string             `bson:"reason" json:"reason"`
+	CreatedBy   string             `bson:"createdBy" json:"createdBy"`
+	CreatedDate time.Time          `bson:"createdDate" json:"createdDate"`
+}
+
+// CashSummary is how the expected cash of a shift is made up. Sales are net
+// of change and refunds.
+type CashSummary struct {
+	CashSales    float64 `bson:"cashSales" json:"cashSales"`
+	CreditorCash float64 `bson:"creditorCash" json:"creditorCash"`
+	CashExpenses float64 `bson:"cashExpenses" json:"cashExpenses"`
+	PayIns       float64 `bson:"payIns" json:"payIns"`
+	PayOuts      float64 `bson:"payOuts" json:"payOuts"`
+	ExpectedCash float64 `bson:"expectedCash" json:"expectedCash"`
+}
+
+// CashierOverShort totals the closed shifts of one cashier.
+type CashierOverShort struct {
+	UserId       string  `json:"userId"`
+	Shifts       int     `json:"shifts"`
+	ExpectedCash float64 `json:"expectedCash"`
+	CountedCash  float64 `json:"countedCash"`
+	OverShort    float64 `json:"overShort"`
+}
+
+// CashShiftReport is the over/short of closed shifts, per shift and per
+// cashier.
+type CashShiftReport struct {
+	StartDate      string             `json:"startDate"`
+	EndDate        string             `json:"endDate"`
+	TotalOverShort float64            `json:"totalOverShort"`
+	Cashiers       []CashierOverShort `json:"cashiers"`
+	Shifts         []CashShift        `json:"shifts"`
+}
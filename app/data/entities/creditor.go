@@ -24,11 +24,12 @@ type Creditor struct {
 }
 
 type CreditorPayment struct {
-	Id         primitive.ObjectID `bson:"_id" json:"id"`
-	CreditorId primitive.ObjectID `bson:"creditorId" json:"creditorId"`
-	Amount     float64            `bson:"amount" json:"amount"`
-	Type       string             `bson:"type" json:"type"`
-	Note       string             `bson:"note" json:"note"`
-	CreatedBy  string             `bson:"createdBy" json:"-"`
-	CreatedDate time.Time         `bson:"createdDate" json:"createdDate"`
+	Id          primitive.ObjectID `bson:"_id" json:"id"`
+	CreditorId  primitive.ObjectID `bson:"creditorId" json:"creditorId"`
+	Amount      float64            `bson:"amount" json:"amount"`
+	Fee         float64            `bson:"fee,omitempty" json:"fee,omitempty"`
+	Type        string             `bson:"type" json:"type"`
+	Note        string             `bson:"note" json:"note"`
+	CreatedBy   string             `bson:"createdBy" json:"-"`
+	CreatedDate time.Time          `bson:"createdDate" json:"createdDate"`
 }
@@ -0,0 +1,47 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DayClose is the Z report of a business day. It is written once when the
+// day is closed and never changed; writes that would fall in a closed day
+// are refused.
+type DayClose struct {
+	Id                primitive.ObjectID `bson:"_id" json:"id"`
+	Number            int                `bson:"number" json:"number"`
+	BusinessDate      string             `bson:"businessDate" json:"businessDate"`
+	StartTime         time.Time          `bson:"startTime" json:"startTime"`
+	EndTime           time.Time          `bson:"endTime" json:"endTime"`
+	Sessions          int                `bson:"sessions" json:"sessions"`
+	TableCharge       float64            `bson:"tableCharge" json:"tableCharge"`
+	FoodTotal         float64            `bson:"foodTotal" json:"foodTotal"`
+	Discount          float64            `bson:"discount" json:"discount"`
+	PromotionDiscount float64            `bson:"promotionDiscount" json:"promotionDiscount"`
+	PointsDiscount    float64            `bson:"pointsDiscount" json:"pointsDiscount"`
+	GrandTotal        float64            `bson:"grandTotal" json:"grandTotal"`
+	Payments          []DayPaymentTotal  `bson:"payments" json:"payments"`
+	PaymentTotal      float64            `bson:"paymentTotal" json:"paymentTotal"`
+	PaymentFees       float64            `bson:"paymentFees" json:"paymentFees"`
+	Refunds           DayCount           `bson:"refunds" json:"refunds"`
+	PaymentVoids      DayCount           `bson:"paymentVoids" json:"paymentVoids"`
+	OrderVoids        DayCount           `bson:"orderVoids" json:"orderVoids"`
+	Expenses          DayCount           `bson:"expenses" json:"expenses"`
+	ClosedBy          string             `bson:"closedBy" json:"closedBy"`
+	ClosedDate        time.Time          `bson:"closedDate" json:"closedDate"`
+}
+
+// DayPaymentTotal is what was taken with one payment method, net of refunds.
+type DayPaymentTotal struct {
+	Type   string  `bson:"type" json:"type"`
+	Count  int     `bson:"count" json:"count"`
+	Amount float64 `bson:"amount" json:"amount"`
+	Fee    float64 `bson:"fee" json:"fee"`
+}
+
+type DayCount struct {
+	Count  int     `bson:"count" json:"count"`
+	Amount float64 `bson:"amount" json:"amount"`
+}
@@ -11,6 +11,7 @@ type Expense struct {
 	Category    string             `bson:"category" json:"category"`
 	Description string             `bson:"description" json:"description"`
 	Amount      float64            `bson:"amount" json:"amount"`
+	PaymentType string             `bson:"paymentType,omitempty" json:"paymentType,omitempty"`
 	Date        time.Time          `bson:"date" json:"date"`
 	CreatedBy   string             `bson:"createdBy" json:"-"`
 	CreatedDate time.Time          `bson:"createdDate" json:"createdDate"`
@@ -0,0 +1,52 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Ingredient struct {
+	Id            primitive.ObjectID `bson:"_id" json:"id"`
+	Name          string             `bson:"name" json:"name"`
+	Unit          string             `bson:"unit" json:"unit"`
+	Quantity      float64            `bson:"quantity" json:"quantity"`
+	CostPrice     float64            `bson:"costPrice" json:"costPrice"`
+	LowStockLevel float64            `bson:"lowStockLevel" json:"lowStockLevel"`
+	Status        string             `bson:"status" json:"status"`
+	CreatedBy     string             `bson:"createdBy" json:"-"`
+	CreatedDate   time.Time          `bson:"createdDate" json:"createdDate"`
+	UpdatedBy     string             `bson:"updatedBy" json:"-"`
+	UpdatedDate   time.Time          `bson:"updatedDate" json:"-"`
+}
+
+// IngredientMovement is the ledger entry of an ingredient. Quantities are
+// fractional as ingredients are measured in their own unit.
+type IngredientMovement struct {
+	Id             primitive.ObjectID  `bson:"_id" json:"id"`
+	IngredientId   primitive.ObjectID  `bson:"ingredientId" json:"ingredientId"`
+	IngredientName string              `bson:"ingredientName" json:"ingredientName"`
+	Type           string              `bson:"type" json:"type"`
+	Quantity       float64             `bson:"quantity" json:"quantity"`
+	BalanceAfter   float64             `bson:"balanceAfter" json:"balanceAfter"`
+	UnitCost       float64             `bson:"unitCost" json:"unitCost"`
+	RefType        string              `bson:"refType,omitempty" json:"refType,omitempty"`
+	RefId          *primitive.ObjectID `bson:"refId,omitempty" json:"refId,omitempty"`
+	Reason         string              `bson:"reason" json:"reason"`
+	CreatedBy      string              `bson:"createdBy" json:"createdBy"`
+	CreatedDate    time.Time           `bson:"createdDate" json:"createdDate"`
+}
+
+type RecipeLine struct {
+	IngredientId primitive.ObjectID `bson:"ingredientId" json:"ingredientId"`
+	Name         string             `bson:"name" json:"name"`
+	Quantity     float64            `bson:"quantity" json:"quantity"`
+	Unit         string             `bson:"unit" json:"unit"`
+}
+
+type OrderIngredient struct {
+	IngredientId primitive.ObjectID `bson:"ingredientId" json:"ingredientId"`
+	Name         string             `bson:"name" json:"name"`
+	Quantity     float64            `bson:"quantity" json:"quantity"`
+	UnitCost     float64            `bson:"unitCost" json:"unitCost"`
+}
@@ -0,0 +1,35 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Member struct {
+	Id             primitive.ObjectID `bson:"_id" json:"id"`
+	Name           string             `bson:"name" json:"name"`
+	Phone          string             `bson:"phone" json:"phone"`
+	Points         int                `bson:"points" json:"points"`
+	LifetimePoints int                `bson:"lifetimePoints" json:"lifetimePoints"`
+	Status         string             `bson:"status" json:"status"`
+	Note           string             `bson:"note" json:"note"`
+	CreatedBy      string             `bson:"createdBy" json:"-"`
+	CreatedDate    time.Time          `bson:"createdDate" json:"createdDate"`
+	UpdatedBy      string             `bson:"updatedBy" json:"-"`
+	UpdatedDate    time.Time          `bson:"updatedDate" json:"-"`
+}
+
+type LoyaltyTransaction struct {
+	Id           primitive.ObjectID  `bson:"_id" json:"id"`
+	MemberId     primitive.ObjectID  `bson:"memberId" json:"memberId"`
+	SessionId    *primitive.ObjectID `bson:"sessionId,omitempty" json:"sessionId,omitempty"`
+	Type         string              `bson:"type" json:"type"`
+	Points       int                 `bson:"points" json:"points"`
+	Remaining    int                 `bson:"remaining" json:"remaining"`
+	BalanceAfter int                 `bson:"balanceAfter" json:"balanceAfter"`
+	ExpiresAt    *time.Time          `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"`
+	Reason       string              `bson:"reason" json:"reason"`
+	CreatedBy    string              `bson:"createdBy" json:"createdBy"`
+	CreatedDate  time.Time           `bson:"createdDate" json:"createdDate"`
+}
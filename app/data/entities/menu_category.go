@@ -10,8 +10,18 @@ type MenuCategory struct {
 	Id          primitive.ObjectID `bson:"_id" json:"id"`
 	Name        string             `bson:"name" json:"name"`
 	SortOrder   int                `bson:"sortOrder" json:"sortOrder"`
+	Station     string             `bson:"station" json:"station"`
+	TaxClass    string             `bson:"taxClass" json:"taxClass"`
+	Hidden      bool               `bson:"hidden" json:"hidden"`
 	CreatedBy   string             `bson:"createdBy" json:"-"`
 	CreatedDate time.Time          `bson:"createdDate" json:"createdDate"`
 	UpdatedBy   string             `bson:"updatedBy" json:"-"`
 	UpdatedDate time.Time          `bson:"updatedDate" json:"-"`
 }
+
+// CategoryMigration reports the result of linking menu items to categories
+// by id.
+type CategoryMigration struct {
+	CategoriesCreated []string `json:"categoriesCreated"`
+	ItemsLinked       int64    `json:"itemsLinked"`
+}
@@ -0,0 +1,30 @@
+package entities
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// MenuImportReport previews or records a bulk menu import. Rows are listed
+// per sheet in file order; row numbers match the spreadsheet.
+type MenuImportReport struct {
+	DryRun  bool              `json:"dryRun"`
+	Applied bool              `json:"applied"`
+	Summary MenuImportSummary `json:"summary"`
+	Rows    []MenuImportRow   `json:"rows"`
+}
+
+type MenuImportSummary struct {
+	CategoriesCreated int `json:"categoriesCreated"`
+	CategoriesUpdated int `json:"categoriesUpdated"`
+	ItemsCreated      int `json:"itemsCreated"`
+	ItemsUpdated      int `json:"itemsUpdated"`
+	Unchanged         int `json:"unchanged"`
+	Errors            int `json:"errors"`
+}
+
+type MenuImportRow struct {
+	Sheet  string              `json:"sheet"`
+	Row    int                 `json:"row"`
+	Key    string              `json:"key"`
+	Action string              `json:"action"` // CREATE, UPDATE, UNCHANGED or ERROR
+	Id     *primitive.ObjectID `json:"id,omitempty"`
+	Errors []string            `json:"errors,omitempty"`
+}
@@ -7,19 +7,32 @@ import (
 )
 
 type MenuItem struct {
-	Id          primitive.ObjectID `bson:"_id" json:"id"`
-	Name        string             `bson:"name" json:"name"`
-	Category    string             `bson:"category" json:"category"`
-	Price       float64            `bson:"price" json:"price"`
-	CostPrice   float64            `bson:"costPrice" json:"costPrice"`
-	Quantity    int                `bson:"quantity" json:"quantity"`
-	Unit        string             `bson:"unit" json:"unit"`
-	Status      string             `bson:"status" json:"status"`
-	ImageUrl    string             `bson:"imageUrl" json:"imageUrl"`
-	CreatedBy   string             `bson:"createdBy" json:"-"`
-	CreatedDate time.Time          `bson:"createdDate" json:"createdDate"`
-	UpdatedBy   string             `bson:"updatedBy" json:"-"`
-	UpdatedDate time.Time          `bson:"updatedDate" json:"-"`
+	Id           primitive.ObjectID   `bson:"_id" json:"id"`
+	Name         string               `bson:"name" json:"name"`
+	CategoryId   *primitive.ObjectID  `bson:"categoryId,omitempty" json:"categoryId,omitempty"`
+	Category     string               `bson:"category" json:"category"`
+	ParentId     *primitive.ObjectID  `bson:"parentId,omitempty" json:"parentId,omitempty"`
+	VariantName  string               `bson:"variantName" json:"variantName"`
+	Sku          string               `bson:"sku" json:"sku"`
+	Barcode      string               `bson:"barcode" json:"barcode"`
+	Price        float64              `bson:"price" json:"price"`
+	CostPrice    float64              `bson:"costPrice" json:"costPrice"`
+	Quantity     int                  `bson:"quantity" json:"quantity"`
+	Unit         string               `bson:"unit" json:"unit"`
+	Status       string               `bson:"status" json:"status"`
+	ImageUrl     string               `bson:"imageUrl" json:"imageUrl"`
+	ThumbnailUrl string               `bson:"thumbnailUrl" json:"thumbnailUrl"`
+	Recipe       []RecipeLine         `bson:"recipe" json:"recipe"`
+	Modifiers    []ModifierGroup      `bson:"modifiers" json:"modifiers"`
+	Availability []AvailabilityWindow `bson:"availability" json:"availability"`
+	// Filled in from the schedule and the active price list when listing.
+	Available    bool                `bson:"-" json:"available"`
+	RegularPrice float64             `bson:"-" json:"regularPrice,omitempty"`
+	PriceListId  *primitive.ObjectID `bson:"-" json:"priceListId,omitempty"`
+	CreatedBy    string              `bson:"createdBy" json:"-"`
+	CreatedDate  time.Time           `bson:"createdDate" json:"createdDate"`
+	UpdatedBy    string              `bson:"updatedBy" json:"-"`
+	UpdatedDate  time.Time           `bson:"updatedDate" json:"-"`
 }
 
 type LowStockMenuItem struct {
@@ -0,0 +1,32 @@
+package entities
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// ModifierGroup is a set of options offered with a menu item, such as
+// "Ice" or "Extras". A required group needs at least one option chosen and
+// a single-select group at most one.
+type ModifierGroup struct {
+	Id          primitive.ObjectID `bson:"_id" json:"id"`
+	Name        string             `bson:"name" json:"name"`
+	Required    bool               `bson:"required" json:"required"`
+	MultiSelect bool               `bson:"multiSelect" json:"multiSelect"`
+	MaxSelect   int                `bson:"maxSelect" json:"maxSelect"`
+	Options     []ModifierOption   `bson:"options" json:"options"`
+}
+
+type ModifierOption struct {
+	Id         primitive.ObjectID `bson:"_id" json:"id"`
+	Name       string             `bson:"name" json:"name"`
+	PriceDelta float64            `bson:"priceDelta" json:"priceDelta"`
+	Status     string             `bson:"status" json:"status"`
+}
+
+// OrderModifier is an option chosen on an order line, copied from the menu
+// item at the time of ordering.
+type OrderModifier struct {
+	GroupId    primitive.ObjectID `bson:"groupId" json:"groupId"`
+	GroupName  string             `bson:"groupName" json:"groupName"`
+	OptionId   primitive.ObjectID `bson:"optionId" json:"optionId"`
+	Name       string             `bson:"name" json:"name"`
+	PriceDelta float64            `bson:"priceDelta" json:"priceDelta"`
+}
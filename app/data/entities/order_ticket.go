@@ -0,0 +1,44 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OrderTicket is an order line on its way through a preparation station. It
+// moves from QUEUED to PREPARING, READY and SERVED, or is CANCELLED when the
+// order is removed.
+type OrderTicket struct {
+	Id          primitive.ObjectID `bson:"_id" json:"id"`
+	OrderId     primitive.ObjectID `bson:"orderId" json:"orderId"`
+	SessionId   primitive.ObjectID `bson:"sessionId" json:"sessionId"`
+	TableName   string             `bson:"tableName" json:"tableName"`
+	MenuItemId  primitive.ObjectID `bson:"menuItemId" json:"menuItemId"`
+	Name        string             `bson:"name" json:"name"`
+	Category    string             `bson:"category" json:"category"`
+	Station     string             `bson:"station" json:"station"`
+	Quantity    int                `bson:"quantity" json:"quantity"`
+	Modifiers   []OrderModifier    `bson:"modifiers,omitempty" json:"modifiers,omitempty"`
+	Status      string             `bson:"status" json:"status"`
+	QueuedDate  time.Time          `bson:"queuedDate" json:"queuedDate"`
+	StartedDate *time.Time         `bson:"startedDate,omitempty" json:"startedDate,omitempty"`
+	ReadyDate   *time.Time         `bson:"readyDate,omitempty" json:"readyDate,omitempty"`
+	ServedDate  *time.Time         `bson:"servedDate,omitempty" json:"servedDate,omitempty"`
+	CreatedBy   string             `bson:"createdBy" json:"-"`
+	UpdatedBy   string             `bson:"updatedBy" json:"-"`
+	UpdatedDate time.Time          `bson:"updatedDate" json:"updatedDate"`
+}
+
+// StationPrepMetrics summarises ticket timings of one station in minutes.
+// Wait is queued to started, prep is started (or queued) to ready and
+// serve is ready to served.
+type StationPrepMetrics struct {
+	Station      string  `bson:"_id" json:"station"`
+	Tickets      int     `bson:"tickets" json:"tickets"`
+	Completed    int     `bson:"completed" json:"completed"`
+	AvgWaitMins  float64 `bson:"avgWaitMins" json:"avgWaitMins"`
+	AvgPrepMins  float64 `bson:"avgPrepMins" json:"avgPrepMins"`
+	MaxPrepMins  float64 `bson:"maxPrepMins" json:"maxPrepMins"`
+	AvgServeMins float64 `bson:"avgServeMins" json:"avgServeMins"`
+}
@@ -7,11 +7,22 @@ import (
 )
 
 type Payment struct {
-	Id          primitive.ObjectID `bson:"_id" json:"id"`
-	SessionId   primitive.ObjectID `bson:"sessionId" json:"sessionId"`
-	Type        string             `bson:"type" json:"type"`
-	Amount      float64            `bson:"amount" json:"amount"`
-	Note        string             `bson:"note" json:"note"`
-	CreatedBy   string             `bson:"createdBy" json:"-"`
-	CreatedDate time.Time          `bson:"createdDate" json:"createdDate"`
+	Id         primitive.ObjectID  `bson:"_id" json:"id"`
+	SessionId  primitive.ObjectID  `bson:"sessionId" json:"sessionId"`
+	Type       string              `bson:"type" json:"type"`
+	Amount     float64             `bson:"amount" json:"amount"`
+	Fee        float64             `bson:"fee,omitempty" json:"fee,omitempty"`
+	Tendered   float64             `bson:"tendered,omitempty" json:"tendered,omitempty"`
+	Change     float64             `bson:"change,omitempty" json:"change,omitempty"`
+	Note       string              `bson:"note" json:"note"`
+	IntentId   *primitive.ObjectID `bson:"intentId,omitempty" json:"intentId,omitempty"`
+	Provider   string              `bson:"provider,omitempty" json:"provider,omitempty"`
+	Reference  string              `bson:"reference,omitempty" json:"reference,omitempty"`
+	Status     string              `bson:"status,omitempty" json:"status,omitempty"`
+	ReversalOf *primitive.ObjectID `bson:"reversalOf,omitempty" json:"reversalOf,omitempty"`
+	ReversalId *primitive.ObjectID `bson:"reversalId,omitempty" json:"reversalId,omitempty"`
+	// PaidOutBy is the cashier whose drawer a reversal was paid from.
+	PaidOutBy   string    `bson:"paidOutBy,omitempty" json:"paidOutBy,omitempty"`
+	CreatedBy   string    `bson:"createdBy" json:"-"`
+	CreatedDate time.Time `bson:"createdDate" json:"createdDate"`
 }
@@ -0,0 +1,49 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PaymentIntent is a payment the customer was asked to make, such as a
+// PromptPay QR code or a gateway charge. It only becomes a Payment once staff
+// or the gateway's webhook confirm the money arrived.
+type PaymentIntent struct {
+	Id            primitive.ObjectID  `bson:"_id" json:"id"`
+	SessionId     primitive.ObjectID  `bson:"sessionId" json:"sessionId"`
+	Method        string              `bson:"method" json:"method"`
+	Provider      string              `bson:"provider,omitempty" json:"provider,omitempty"`
+	ProviderRef   string              `bson:"providerRef,omitempty" json:"providerRef,omitempty"`
+	Amount        float64             `bson:"amount" json:"amount"`
+	Fee           float64             `bson:"fee,omitempty" json:"fee,omitempty"`
+	Payload       string              `bson:"payload" json:"payload"`
+	RedirectUrl   string              `bson:"redirectUrl,omitempty" json:"redirectUrl,omitempty"`
+	Status        string              `bson:"status" json:"status"` // PENDING, CONFIRMED, FAILED or CANCELLED
+	PaymentId     *primitive.ObjectID `bson:"paymentId,omitempty" json:"paymentId,omitempty"`
+	Note          string              `bson:"note" json:"note"`
+	ConfirmedBy   string              `bson:"confirmedBy,omitempty" json:"confirmedBy,omitempty"`
+	ConfirmedDate *time.Time          `bson:"confirmedDate,omitempty" json:"confirmedDate,omitempty"`
+	CreatedBy     string              `bson:"createdBy" json:"-"`
+	CreatedDate   time.Time           `bson:"createdDate" json:"createdDate"`
+	UpdatedBy     string              `bson:"updatedBy" json:"-"`
+	UpdatedDate   time.Time           `bson:"updatedDate" json:"-"`
+}
+
+// PaymentEvent is a webhook delivery from a payment gateway. Provider and
+// EventId are unique, so a delivery is applied once however often it is
+// retried.
+type PaymentEvent struct {
+	Id           primitive.ObjectID  `bson:"_id" json:"id"`
+	Provider     string              `bson:"provider" json:"provider"`
+	EventId      string              `bson:"eventId" json:"eventId"`
+	Type         string              `bson:"type" json:"type"`
+	ProviderRef  string              `bson:"providerRef" json:"providerRef"`
+	Amount       float64             `bson:"amount" json:"amount"`
+	IntentId     *primitive.ObjectID `bson:"intentId,omitempty" json:"intentId,omitempty"`
+	PaymentId    *primitive.ObjectID `bson:"paymentId,omitempty" json:"paymentId,omitempty"`
+	Result       string              `bson:"result" json:"result"` // APPLIED or IGNORED
+	Note         string              `bson:"note" json:"note"`
+	NeedsRefund  bool                `bson:"needsRefund,omitempty" json:"needsRefund,omitempty"`
+	ReceivedDate time.Time           `bson:"receivedDate" json:"receivedDate"`
+}
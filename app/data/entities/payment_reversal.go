@@ -0,0 +1,28 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PaymentReversal is a request to refund or void a payment. Nothing changes
+// in the books until an admin approves it; the approval then books a negative
+// Payment of the same type that refers back to the original.
+type PaymentReversal struct {
+	Id           primitive.ObjectID  `bson:"_id" json:"id"`
+	PaymentId    primitive.ObjectID  `bson:"paymentId" json:"paymentId"`
+	SessionId    primitive.ObjectID  `bson:"sessionId" json:"sessionId"`
+	Type         string              `bson:"type" json:"type"` // REFUND or VOID
+	PaymentType  string              `bson:"paymentType" json:"paymentType"`
+	Amount       float64             `bson:"amount" json:"amount"`
+	Reason       string              `bson:"reason" json:"reason"`
+	Status       string              `bson:"status" json:"status"` // PENDING, APPROVED or REJECTED
+	EntryId      *primitive.ObjectID `bson:"entryId,omitempty" json:"entryId,omitempty"`
+	PaidOutBy    string              `bson:"paidOutBy,omitempty" json:"paidOutBy,omitempty"`
+	ReviewNote   string              `bson:"reviewNote,omitempty" json:"reviewNote,omitempty"`
+	ReviewedBy   string              `bson:"reviewedBy,omitempty" json:"reviewedBy,omitempty"`
+	ReviewedDate *time.Time          `bson:"reviewedDate,omitempty" json:"reviewedDate,omitempty"`
+	CreatedBy    string              `bson:"createdBy" json:"createdBy"`
+	CreatedDate  time.Time           `bson:"createdDate" json:"createdDate"`
+}
@@ -0,0 +1,39 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PriceList overrides menu item prices while it is in effect: between its
+// optional start and end dates, on its days of week and inside its daily
+// time window. When several lists apply the highest priority wins.
+type PriceList struct {
+	Id             primitive.ObjectID `bson:"_id" json:"id"`
+	Name           string             `bson:"name" json:"name"`
+	StartDate      *time.Time         `bson:"startDate,omitempty" json:"startDate,omitempty"`
+	EndDate        *time.Time         `bson:"endDate,omitempty" json:"endDate,omitempty"`
+	DaysOfWeek     []int              `bson:"daysOfWeek" json:"daysOfWeek"`
+	StartTimeOfDay string             `bson:"startTimeOfDay" json:"startTimeOfDay"`
+	EndTimeOfDay   string             `bson:"endTimeOfDay" json:"endTimeOfDay"`
+	Priority       int                `bson:"priority" json:"priority"`
+	Items          []PriceListItem    `bson:"items" json:"items"`
+	Status         string             `bson:"status" json:"status"`
+	CreatedBy      string             `bson:"createdBy" json:"-"`
+	CreatedDate    time.Time          `bson:"createdDate" json:"createdDate"`
+	UpdatedBy      string             `bson:"updatedBy" json:"-"`
+	UpdatedDate    time.Time          `bson:"updatedDate" json:"-"`
+}
+
+type PriceListItem struct {
+	MenuItemId primitive.ObjectID `bson:"menuItemId" json:"menuItemId"`
+	Price      float64            `bson:"price" json:"price"`
+}
+
+// AvailabilityWindow is a weekly time slot in which a menu item is sold.
+type AvailabilityWindow struct {
+	DaysOfWeek     []int  `bson:"daysOfWeek" json:"daysOfWeek"`
+	StartTimeOfDay string `bson:"startTimeOfDay" json:"startTimeOfDay"`
+	EndTimeOfDay   string `bson:"endTimeOfDay" json:"endTimeOfDay"`
+}
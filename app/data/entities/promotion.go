@@ -7,20 +7,51 @@ import (
 )
 
 type Promotion struct {
-	Id          primitive.ObjectID `bson:"_id" json:"id"`
+	Id                 primitive.ObjectID  `bson:"_id" json:"id"`
+	Name               string              `bson:"name" json:"name"`
+	Description        string              `bson:"description" json:"description"`
+	Type               string              `bson:"type" json:"type"`
+	PlayHours          float64             `bson:"playHours" json:"playHours"`
+	FreeHours          float64             `bson:"freeHours" json:"freeHours"`
+	DiscountPct        float64             `bson:"discountPct" json:"discountPct"`
+	DiscountAmt        float64             `bson:"discountAmt" json:"discountAmt"`
+	TableTypes         []string            `bson:"tableTypes" json:"tableTypes"`
+	StartDate          time.Time           `bson:"startDate" json:"startDate"`
+	EndDate            time.Time           `bson:"endDate" json:"endDate"`
+	DaysOfWeek         []int               `bson:"daysOfWeek" json:"daysOfWeek"`
+	StartTimeOfDay     string              `bson:"startTimeOfDay" json:"startTimeOfDay"`
+	EndTimeOfDay       string              `bson:"endTimeOfDay" json:"endTimeOfDay"`
+	MemberOnly         bool                `bson:"memberOnly" json:"memberOnly"`
+	MinSpend           float64             `bson:"minSpend" json:"minSpend"`
+	MaxUses            int                 `bson:"maxUses" json:"maxUses"`
+	MaxUsesPerCustomer int                 `bson:"maxUsesPerCustomer" json:"maxUsesPerCustomer"`
+	Priority           int                 `bson:"priority" json:"priority"`
+	Stackable          bool                `bson:"stackable" json:"stackable"`
+	AutoApply          bool                `bson:"autoApply" json:"autoApply"`
+	MenuItemId         *primitive.ObjectID `bson:"menuItemId,omitempty" json:"menuItemId,omitempty"`
+	BuyQuantity        int                 `bson:"buyQuantity" json:"buyQuantity"`
+	GetQuantity        int                 `bson:"getQuantity" json:"getQuantity"`
+	Category           string              `bson:"category" json:"category"`
+	BundlePrice        float64             `bson:"bundlePrice" json:"bundlePrice"`
+	BundleItems        []BundleItem        `bson:"bundleItems" json:"bundleItems"`
+	Status             string              `bson:"status" json:"status"`
+	CreatedBy          string              `bson:"createdBy" json:"-"`
+	CreatedDate        time.Time           `bson:"createdDate" json:"createdDate"`
+	UpdatedBy          string              `bson:"updatedBy" json:"-"`
+	UpdatedDate        time.Time           `bson:"updatedDate" json:"-"`
+}
+
+type BundleItem struct {
+	MenuItemId primitive.ObjectID `bson:"menuItemId" json:"menuItemId"`
+	Quantity   int                `bson:"quantity" json:"quantity"`
+}
+
+type AppliedPromotion struct {
+	PromotionId primitive.ObjectID `bson:"promotionId" json:"promotionId"`
 	Name        string             `bson:"name" json:"name"`
-	Description string             `bson:"description" json:"description"`
 	Type        string             `bson:"type" json:"type"`
-	PlayHours   float64            `bson:"playHours" json:"playHours"`
-	FreeHours   float64            `bson:"freeHours" json:"freeHours"`
-	DiscountPct float64            `bson:"discountPct" json:"discountPct"`
-	DiscountAmt float64            `bson:"discountAmt" json:"discountAmt"`
-	TableTypes  []string           `bson:"tableTypes" json:"tableTypes"`
-	StartDate   time.Time          `bson:"startDate" json:"startDate"`
-	EndDate     time.Time          `bson:"endDate" json:"endDate"`
-	Status      string             `bson:"status" json:"status"`
-	CreatedBy   string             `bson:"createdBy" json:"-"`
-	CreatedDate time.Time          `bson:"createdDate" json:"createdDate"`
-	UpdatedBy   string             `bson:"updatedBy" json:"-"`
-	UpdatedDate time.Time          `bson:"updatedDate" json:"-"`
+	Priority    int                `bson:"priority" json:"priority"`
+	Stackable   bool               `bson:"stackable" json:"stackable"`
+	Auto        bool               `bson:"auto" json:"auto"`
+	Discount    float64            `bson:"discount" json:"discount"`
 }
@@ -0,0 +1,60 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Supplier struct {
+	Id          primitive.ObjectID `bson:"_id" json:"id"`
+	Name        string             `bson:"name" json:"name"`
+	ContactName string             `bson:"contactName" json:"contactName"`
+	Phone       string             `bson:"phone" json:"phone"`
+	Email       string             `bson:"email" json:"email"`
+	Address     string             `bson:"address" json:"address"`
+	TaxId       string             `bson:"taxId" json:"taxId"`
+	Note        string             `bson:"note" json:"note"`
+	Status      string             `bson:"status" json:"status"`
+	CreatedBy   string             `bson:"createdBy" json:"-"`
+	CreatedDate time.Time          `bson:"createdDate" json:"createdDate"`
+	UpdatedBy   string             `bson:"updatedBy" json:"-"`
+	UpdatedDate time.Time          `bson:"updatedDate" json:"-"`
+}
+
+type PurchaseOrder struct {
+	Id           primitive.ObjectID   `bson:"_id" json:"id"`
+	PoNumber     string               `bson:"poNumber" json:"poNumber"`
+	SupplierId   primitive.ObjectID   `bson:"supplierId" json:"supplierId"`
+	SupplierName string               `bson:"supplierName" json:"supplierName"`
+	Status       string               `bson:"status" json:"status"`
+	Items        []PurchaseOrderItem  `bson:"items" json:"items"`
+	Total        float64              `bson:"total" json:"total"`
+	ExpectedDate *time.Time           `bson:"expectedDate,omitempty" json:"expectedDate,omitempty"`
+	ReceivedDate *time.Time           `bson:"receivedDate,omitempty" json:"receivedDate,omitempty"`
+	ExpenseIds   []primitive.ObjectID `bson:"expenseIds" json:"expenseIds"`
+	Note         string               `bson:"note" json:"note"`
+	CreatedBy    string               `bson:"createdBy" json:"-"`
+	CreatedDate  time.Time            `bson:"createdDate" json:"createdDate"`
+	UpdatedBy    string               `bson:"updatedBy" json:"-"`
+	UpdatedDate  time.Time            `bson:"updatedDate" json:"-"`
+}
+
+type PurchaseOrderItem struct {
+	MenuItemId       primitive.ObjectID `bson:"menuItemId" json:"menuItemId"`
+	Name             string             `bson:"name" json:"name"`
+	Quantity         int                `bson:"quantity" json:"quantity"`
+	ReceivedQuantity int                `bson:"receivedQuantity" json:"receivedQuantity"`
+	UnitCost         float64            `bson:"unitCost" json:"unitCost"`
+	Total            float64            `bson:"total" json:"total"`
+	// ReceivedUnitCost is the average cost of the quantity received so far,
+	// which can differ from the ordered UnitCost.
+	ReceivedUnitCost float64 `bson:"receivedUnitCost,omitempty" json:"receivedUnitCost,omitempty"`
+}
+
+// GoodsReceipt is one delivery received against a purchase order.
+type GoodsReceipt struct {
+	PurchaseOrder PurchaseOrder   `json:"purchaseOrder"`
+	Movements     []StockMovement `json:"movements"`
+	Expense       Expense         `json:"expense"`
+}
@@ -14,6 +14,26 @@ type Setting struct {
 	CompanyTaxId   string             `bson:"companyTaxId" json:"companyTaxId"`
 	ReceiptFooter  string             `bson:"receiptFooter" json:"receiptFooter"`
 	PromptPayId    string             `bson:"promptPayId" json:"promptPayId"`
+	CutoverHour    int                `bson:"cutoverHour" json:"cutoverHour"`
+	Loyalty        LoyaltySetting     `bson:"loyalty" json:"loyalty"`
+	PaymentMethods []PaymentMethod    `bson:"paymentMethods" json:"paymentMethods"`
 	UpdatedBy      string             `bson:"updatedBy" json:"-"`
 	UpdatedDate    time.Time          `bson:"updatedDate" json:"-"`
 }
+
+type LoyaltySetting struct {
+	TablePointsPerBaht float64 `bson:"tablePointsPerBaht" json:"tablePointsPerBaht"`
+	FoodPointsPerBaht  float64 `bson:"foodPointsPerBaht" json:"foodPointsPerBaht"`
+	PointValue         float64 `bson:"pointValue" json:"pointValue"`
+	ExpiryDays         int     `bson:"expiryDays" json:"expiryDays"`
+}
+
+// PaymentMethod is an entry of the payment methods catalog. Every payment is
+// recorded under the Code of an active method.
+type PaymentMethod struct {
+	Code         string  `bson:"code" json:"code"`
+	Label        string  `bson:"label" json:"label"`
+	Active       bool    `bson:"active" json:"active"`
+	CashInDrawer bool    `bson:"cashInDrawer" json:"cashInDrawer"`
+	FeePct       float64 `bson:"feePct" json:"feePct"`
+}
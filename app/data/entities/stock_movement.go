@@ -0,0 +1,33 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StockMovement is one entry of the inventory ledger. Quantity is signed, so
+// the sum of an item's movements equals its quantity on hand.
+type StockMovement struct {
+	Id           primitive.ObjectID  `bson:"_id" json:"id"`
+	MenuItemId   primitive.ObjectID  `bson:"menuItemId" json:"menuItemId"`
+	MenuItemName string              `bson:"menuItemName" json:"menuItemName"`
+	Type         string              `bson:"type" json:"type"`
+	Quantity     int                 `bson:"quantity" json:"quantity"`
+	BalanceAfter int                 `bson:"balanceAfter" json:"balanceAfter"`
+	UnitCost     float64             `bson:"unitCost" json:"unitCost"`
+	RefType      string              `bson:"refType,omitempty" json:"refType,omitempty"`
+	RefId        *primitive.ObjectID `bson:"refId,omitempty" json:"refId,omitempty"`
+	Reason       string              `bson:"reason" json:"reason"`
+	CreatedBy    string              `bson:"createdBy" json:"createdBy"`
+	CreatedDate  time.Time           `bson:"createdDate" json:"createdDate"`
+}
+
+type StockReconciliation struct {
+	MenuItemId    primitive.ObjectID `bson:"menuItemId" json:"menuItemId"`
+	Name          string             `bson:"name" json:"name"`
+	OnHand        int                `bson:"onHand" json:"onHand"`
+	LedgerBalance int                `bson:"ledgerBalance" json:"ledgerBalance"`
+	Difference    int                `bson:"difference" json:"difference"`
+	Movements     int                `bson:"movements" json:"movements"`
+}
@@ -0,0 +1,65 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StockTake is a physical count of menu item stock. It moves from OPEN to
+// SUBMITTED once counting is done, and to APPROVED once the variances have
+// been posted to the ledger.
+type StockTake struct {
+	Id            primitive.ObjectID `bson:"_id" json:"id"`
+	Category      string             `bson:"category" json:"category"`
+	Status        string             `bson:"status" json:"status"`
+	Note          string             `bson:"note" json:"note"`
+	Lines         []StockTakeLine    `bson:"lines" json:"lines"`
+	SubmittedBy   string             `bson:"submittedBy,omitempty" json:"submittedBy,omitempty"`
+	SubmittedDate *time.Time         `bson:"submittedDate,omitempty" json:"submittedDate,omitempty"`
+	ApprovedBy    string             `bson:"approvedBy,omitempty" json:"approvedBy,omitempty"`
+	ApprovedDate  *time.Time         `bson:"approvedDate,omitempty" json:"approvedDate,omitempty"`
+	InProgress    bool               `bson:"inProgress,omitempty" json:"-"`
+	CreatedBy     string             `bson:"createdBy" json:"createdBy"`
+	CreatedDate   time.Time          `bson:"createdDate" json:"createdDate"`
+	UpdatedBy     string             `bson:"updatedBy" json:"-"`
+	UpdatedDate   time.Time          `bson:"updatedDate" json:"-"`
+}
+
+// StockTakeLine holds the count of one item. SystemQuantity is the quantity
+// on hand when the count was entered, so sales during the count do not show
+// up as variance.
+type StockTakeLine struct {
+	MenuItemId      primitive.ObjectID `bson:"menuItemId" json:"menuItemId"`
+	Name            string             `bson:"name" json:"name"`
+	Category        string             `bson:"category" json:"category"`
+	SystemQuantity  int                `bson:"systemQuantity" json:"systemQuantity"`
+	CountedQuantity *int               `bson:"countedQuantity" json:"countedQuantity"`
+	Variance        int                `bson:"variance" json:"variance"`
+	UnitCost        float64            `bson:"unitCost" json:"unitCost"`
+	CountedBy       string             `bson:"countedBy,omitempty" json:"countedBy,omitempty"`
+	CountedDate     *time.Time         `bson:"countedDate,omitempty" json:"countedDate,omitempty"`
+}
+
+type StockVariance struct {
+	MenuItemId      primitive.ObjectID `json:"menuItemId"`
+	Name            string             `json:"name"`
+	Category        string             `json:"category"`
+	SystemQuantity  int                `json:"systemQuantity"`
+	CountedQuantity int                `json:"countedQuantity"`
+	Variance        int                `json:"variance"`
+	UnitCost        float64            `json:"unitCost"`
+	VarianceValue   float64            `json:"varianceValue"`
+}
+
+type StockVarianceReport struct {
+	StockTakeId       primitive.ObjectID `json:"stockTakeId"`
+	Status            string             `json:"status"`
+	ItemsCounted      int                `json:"itemsCounted"`
+	ItemsUncounted    int                `json:"itemsUncounted"`
+	ItemsWithVariance int                `json:"itemsWithVariance"`
+	ShortageValue     float64            `json:"shortageValue"`
+	SurplusValue      float64            `json:"surplusValue"`
+	NetVarianceValue  float64            `json:"netVarianceValue"`
+	Lines             []StockVariance    `json:"lines"`
+}
@@ -7,15 +7,51 @@ import (
 )
 
 type TableOrder struct {
-	Id         primitive.ObjectID `bson:"_id" json:"id"`
-	SessionId  primitive.ObjectID `bson:"sessionId" json:"sessionId"`
-	MenuItemId primitive.ObjectID `bson:"menuItemId" json:"menuItemId"`
-	Name       string             `bson:"name" json:"name"`
-	Price      float64            `bson:"price" json:"price"`
-	CostPrice  float64            `bson:"costPrice" json:"costPrice"`
-	Quantity   int                `bson:"quantity" json:"quantity"`
-	Discount   float64            `bson:"discount" json:"discount"`
-	Total      float64            `bson:"total" json:"total"`
-	CreatedBy  string             `bson:"createdBy" json:"-"`
-	CreatedDate time.Time         `bson:"createdDate" json:"createdDate"`
+	Id               primitive.ObjectID  `bson:"_id" json:"id"`
+	SessionId        primitive.ObjectID  `bson:"sessionId" json:"sessionId"`
+	MenuItemId       primitive.ObjectID  `bson:"menuItemId" json:"menuItemId"`
+	Name             string              `bson:"name" json:"name"`
+	CategoryId       *primitive.ObjectID `bson:"categoryId,omitempty" json:"categoryId,omitempty"`
+	Category         string              `bson:"category" json:"category"`
+	TaxClass         string              `bson:"taxClass,omitempty" json:"taxClass,omitempty"`
+	BasePrice        float64             `bson:"basePrice" json:"basePrice"`
+	Price            float64             `bson:"price" json:"price"`
+	PriceListId      *primitive.ObjectID `bson:"priceListId,omitempty" json:"priceListId,omitempty"`
+	CostPrice        float64             `bson:"costPrice" json:"costPrice"`
+	Quantity         int                 `bson:"quantity" json:"quantity"`
+	Discount         float64             `bson:"discount" json:"discount"`
+	Total            float64             `bson:"total" json:"total"`
+	Modifiers        []OrderModifier     `bson:"modifiers,omitempty" json:"modifiers,omitempty"`
+	Ingredients      []OrderIngredient   `bson:"ingredients,omitempty" json:"ingredients,omitempty"`
+	Status           string              `bson:"status" json:"status"`
+	OriginalQuantity int                 `bson:"originalQuantity" json:"originalQuantity"`
+	Adjustments      []OrderAdjustment   `bson:"adjustments,omitempty" json:"adjustments,omitempty"`
+	CreatedBy        string              `bson:"createdBy" json:"-"`
+	CreatedDate      time.Time           `bson:"createdDate" json:"createdDate"`
+}
+
+// OrderAdjustment records a quantity change or void of an order line.
+// Reductions and voids by staff stay PENDING until a manager approves them.
+type OrderAdjustment struct {
+	Id            primitive.ObjectID `bson:"_id" json:"id"`
+	Type          string             `bson:"type" json:"type"`
+	FromQuantity  int                `bson:"fromQuantity" json:"fromQuantity"`
+	ToQuantity    int                `bson:"toQuantity" json:"toQuantity"`
+	Discount      float64            `bson:"discount" json:"discount"`
+	Reason        string             `bson:"reason" json:"reason"`
+	Status        string             `bson:"status" json:"status"`
+	RequestedBy   string             `bson:"requestedBy" json:"requestedBy"`
+	RequestedDate time.Time          `bson:"requestedDate" json:"requestedDate"`
+	ApprovedBy    string             `bson:"approvedBy,omitempty" json:"approvedBy,omitempty"`
+	ApprovedDate  *time.Time         `bson:"approvedDate,omitempty" json:"approvedDate,omitempty"`
+}
+
+// VoidReport totals the approved voids and reductions requested by one staff
+// member, valued at the order line price.
+type VoidReport struct {
+	StaffId    string  `bson:"_id" json:"staffId"`
+	Voids      int     `bson:"voids" json:"voids"`
+	Reductions int     `bson:"reductions" json:"reductions"`
+	Quantity   int     `bson:"quantity" json:"quantity"`
+	Value      float64 `bson:"value" json:"value"`
 }
@@ -7,57 +7,56 @@ import (
 )
 
 type TableSession struct {
-	Id              primitive.ObjectID `bson:"_id" json:"id"`
-	TableId         primitive.ObjectID `bson:"tableId" json:"tableId"`
-	TableName       string             `bson:"tableName" json:"tableName"`
-	TableType       string             `bson:"tableType" json:"tableType"`
-	RatePerHour     float64            `bson:"ratePerHour" json:"ratePerHour"`
-	Status          string             `bson:"status" json:"status"`
-	StartTime       time.Time          `bson:"startTime" json:"startTime"`
-	EndTime         *time.Time         `bson:"endTime,omitempty" json:"endTime,omitempty"`
-	PausedAt        *time.Time         `bson:"pausedAt,omitempty" json:"pausedAt,omitempty"`
-	TotalPausedMins float64            `bson:"totalPausedMins" json:"totalPausedMins"`
-	DurationMins    float64            `bson:"durationMins" json:"durationMins"`
-	TableCharge     float64            `bson:"tableCharge" json:"tableCharge"`
-	FoodTotal       float64            `bson:"foodTotal" json:"foodTotal"`
-	Discount        float64            `bson:"discount" json:"discount"`
-	PromotionId     *primitive.ObjectID `bson:"promotionId,omitempty" json:"promotionId,omitempty"`
-	PromotionName   string             `bson:"promotionName" json:"promotionName"`
-	PromotionDiscount float64          `bson:"promotionDiscount" json:"promotionDiscount"`
-	GrandTotal      float64            `bson:"grandTotal" json:"grandTotal"`
-	Note            string             `bson:"note" json:"note"`
-	CreatedBy       string             `bson:"createdBy" json:"-"`
-	CreatedDate     time.Time          `bson:"createdDate" json:"createdDate"`
-	UpdatedBy       string             `bson:"updatedBy" json:"-"`
-	UpdatedDate     time.Time          `bson:"updatedDate" json:"-"`
+	Id                  primitive.ObjectID  `bson:"_id" json:"id"`
+	TableId             primitive.ObjectID  `bson:"tableId" json:"tableId"`
+	TableName           string              `bson:"tableName" json:"tableName"`
+	TableType           string              `bson:"tableType" json:"tableType"`
+	RatePerHour         float64             `bson:"ratePerHour" json:"ratePerHour"`
+	Status              string              `bson:"status" json:"status"`
+	StartTime           time.Time           `bson:"startTime" json:"startTime"`
+	EndTime             *time.Time          `bson:"endTime,omitempty" json:"endTime,omitempty"`
+	PausedAt            *time.Time          `bson:"pausedAt,omitempty" json:"pausedAt,omitempty"`
+	Pauses              []PauseInterval     `bson:"pauses" json:"pauses"`
+	TotalPausedMins     float64             `bson:"totalPausedMins" json:"totalPausedMins"`
+	DurationMins        float64             `bson:"durationMins" json:"durationMins"`
+	TableCharge         float64             `bson:"tableCharge" json:"tableCharge"`
+	FoodTotal           float64             `bson:"foodTotal" json:"foodTotal"`
+	Discount            float64             `bson:"discount" json:"discount"`
+	PromotionId         *primitive.ObjectID `bson:"promotionId,omitempty" json:"promotionId,omitempty"`
+	PromotionName       string              `bson:"promotionName" json:"promotionName"`
+	PromotionDiscount   float64             `bson:"promotionDiscount" json:"promotionDiscount"`
+	PromotionRejections []string            `bson:"promotionRejections,omitempty" json:"promotionRejections,omitempty"`
+	Promotions          []AppliedPromotion  `bson:"promotions" json:"promotions"`
+	Vouchers            []SessionVoucher    `bson:"vouchers" json:"vouchers"`
+	MemberId            *primitive.ObjectID `bson:"memberId,omitempty" json:"memberId,omitempty"`
+	MemberName          string              `bson:"memberName" json:"memberName"`
+	PointsRedeemed      int                 `bson:"pointsRedeemed" json:"pointsRedeemed"`
+	PointsDiscount      float64             `bson:"pointsDiscount" json:"pointsDiscount"`
+	PointsEarned        int                 `bson:"pointsEarned" json:"pointsEarned"`
+	GrandTotal          float64             `bson:"grandTotal" json:"grandTotal"`
+	Note                string              `bson:"note" json:"note"`
+	CreatedBy           string              `bson:"createdBy" json:"-"`
+	CreatedDate         time.Time           `bson:"createdDate" json:"createdDate"`
+	UpdatedBy           string              `bson:"updatedBy" json:"-"`
+	UpdatedDate         time.Time           `bson:"updatedDate" json:"-"`
+}
+
+type PauseInterval struct {
+	Start time.Time  `bson:"start" json:"start"`
+	End   *time.Time `bson:"end,omitempty" json:"end,omitempty"`
 }
 
 type TableSessionDetail struct {
-	Id              primitive.ObjectID `bson:"_id" json:"id"`
-	TableId         primitive.ObjectID `bson:"tableId" json:"tableId"`
-	TableName       string             `bson:"tableName" json:"tableName"`
-	TableType       string             `bson:"tableType" json:"tableType"`
-	RatePerHour     float64            `bson:"ratePerHour" json:"ratePerHour"`
-	Status          string             `bson:"status" json:"status"`
-	StartTime       time.Time          `bson:"startTime" json:"startTime"`
-	EndTime         *time.Time         `bson:"endTime,omitempty" json:"endTime,omitempty"`
-	PausedAt        *time.Time         `bson:"pausedAt,omitempty" json:"pausedAt,omitempty"`
-	TotalPausedMins float64            `bson:"totalPausedMins" json:"totalPausedMins"`
-	DurationMins    float64            `bson:"durationMins" json:"durationMins"`
-	TableCharge     float64            `bson:"tableCharge" json:"tableCharge"`
-	FoodTotal       float64            `bson:"foodTotal" json:"foodTotal"`
-	Discount        float64            `bson:"discount" json:"discount"`
-	PromotionId     *primitive.ObjectID `bson:"promotionId,omitempty" json:"promotionId,omitempty"`
-	PromotionName   string             `bson:"promotionName" json:"promotionName"`
-	PromotionDiscount float64          `bson:"promotionDiscount" json:"promotionDiscount"`
-	GrandTotal      float64            `bson:"grandTotal" json:"grandTotal"`
-	Note            string             `bson:"note" json:"note"`
-	CreatedBy       string             `bson:"createdBy" json:"-"`
-	CreatedDate     time.Time          `bson:"createdDate" json:"createdDate"`
-	UpdatedBy       string             `bson:"updatedBy" json:"-"`
-	UpdatedDate     time.Time          `bson:"updatedDate" json:"-"`
-	Orders          []TableOrder       `json:"orders"`
-	Payments        []Payment          `json:"payments"`
+	TableSession `bson:",inline"`
+	Orders       []TableOrder `json:"orders"`
+	Payments     []Payment    `json:"payments"`
+}
+
+// CheckoutResult is a session closed by checkout with the payments it took.
+type CheckoutResult struct {
+	TableSession `bson:",inline"`
+	Payments     []Payment `json:"payments"`
+	Change       float64   `json:"change"`
 }
 
 type SessionSummary struct {
@@ -0,0 +1,53 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Voucher struct {
+	Id                 primitive.ObjectID `bson:"_id" json:"id"`
+	Code               string             `bson:"code" json:"code"`
+	PromotionId        primitive.ObjectID `bson:"promotionId" json:"promotionId"`
+	PromotionName      string             `bson:"promotionName" json:"promotionName"`
+	BatchId            string             `bson:"batchId" json:"batchId"`
+	MaxUses            int                `bson:"maxUses" json:"maxUses"`
+	MaxUsesPerCustomer int                `bson:"maxUsesPerCustomer" json:"maxUsesPerCustomer"`
+	UsedCount          int                `bson:"usedCount" json:"usedCount"`
+	ExpiresAt          *time.Time         `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"`
+	Status             string             `bson:"status" json:"status"`
+	CreatedBy          string             `bson:"createdBy" json:"-"`
+	CreatedDate        time.Time          `bson:"createdDate" json:"createdDate"`
+	UpdatedBy          string             `bson:"updatedBy" json:"-"`
+	UpdatedDate        time.Time          `bson:"updatedDate" json:"-"`
+}
+
+type VoucherRedemption struct {
+	Id           primitive.ObjectID  `bson:"_id" json:"id"`
+	VoucherId    primitive.ObjectID  `bson:"voucherId" json:"voucherId"`
+	Code         string              `bson:"code" json:"code"`
+	PromotionId  primitive.ObjectID  `bson:"promotionId" json:"promotionId"`
+	SessionId    primitive.ObjectID  `bson:"sessionId" json:"sessionId"`
+	MemberId     *primitive.ObjectID `bson:"memberId,omitempty" json:"memberId,omitempty"`
+	Status       string              `bson:"status" json:"status"`
+	CreatedBy    string              `bson:"createdBy" json:"createdBy"`
+	CreatedDate  time.Time           `bson:"createdDate" json:"createdDate"`
+	ReleasedDate *time.Time          `bson:"releasedDate,omitempty" json:"releasedDate,omitempty"`
+}
+
+type SessionVoucher struct {
+	VoucherId    primitive.ObjectID `bson:"voucherId" json:"voucherId"`
+	RedemptionId primitive.ObjectID `bson:"redemptionId" json:"redemptionId"`
+	Code         string             `bson:"code" json:"code"`
+	PromotionId  primitive.ObjectID `bson:"promotionId" json:"promotionId"`
+}
+
+type VoucherReport struct {
+	PromotionId   primitive.ObjectID `bson:"promotionId" json:"promotionId"`
+	PromotionName string             `bson:"promotionName" json:"promotionName"`
+	BatchId       string             `bson:"batchId" json:"batchId"`
+	Issued        int                `bson:"issued" json:"issued"`
+	Used          int                `bson:"used" json:"used"`
+	Redemptions   int                `bson:"redemptions" json:"redemptions"`
+}
@@ -0,0 +1,223 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"snook/app/data/entities"
+	"snook/db"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrShiftAlreadyOpen = errors.New("cashier already has an open shift")
+	ErrShiftClosed      = errors.New("shift is already closed")
+)
+
+type cashShiftEntity struct {
+	col         *mongo.Collection
+	paymentCol  *mongo.Collection
+	creditorCol *mongo.Collection
+	expenseCol  *mongo.Collection
+}
+
+type ICashShift interface {
+	GetCashShifts(startDate, endDate time.Time, userId, status string) ([]entities.CashShift, error)
+	GetCashShiftById(id primitive.ObjectID) (entities.CashShift, error)
+	GetOpenCashShift(userId string) (entities.CashShift, error)
+	OpenCashShift(shift entities.CashShift) (entities.CashShift, error)
+	AddCashMovement(id primitive.ObjectID, movement entities.CashMovement) (entities.CashMovement, error)
+	CloseCashShift(shift entities.CashShift) error
+	SumShiftCash(userId string, from, to time.Time, cashCodes []string) (entities.CashSummary, error)
+}
+
+func NewCashShiftEntity(resource *db.Resource) ICashShift {
+	col := resource.SnookDb.Collection("cash_shifts")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// One open shift per cashier, so every cash movement has a single drawer.
+	_, err := col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "userId", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"status": "OPEN"}),
+	})
+	if err != nil {
+		logrus.Error("failed to create cash shift index: ", err)
+	}
+	_, err = col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "openedDate", Value: -1}},
+	})
+	if err != nil {
+		logrus.Error("failed to create cash shift date index: ", err)
+	}
+	return &cashShiftEntity{
+		col:         col,
+		paymentCol:  resource.SnookDb.Collection("payments"),
+		creditorCol: resource.SnookDb.Collection("creditor_payments"),
+		expenseCol:  resource.SnookDb.Collection("expenses"),
+	}
+}
+
+func (entity *cashShiftEntity) GetCashShifts(startDate, endDate time.Time, userId, status string) ([]entities.CashShift, error) {
+	logrus.Info("GetCashShifts")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	filter := bson.M{"openedDate": bson.M{"$gte": startDate, "$lte": endDate}}
+	if userId != "" {
+		filter["userId"] = userId
+	}
+	if status != "" {
+		filter["status"] = status
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "openedDate", Value: -1}})
+	cursor, err := entity.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var shifts []entities.CashShift
+	if err = cursor.All(ctx, &shifts); err != nil {
+		return nil, err
+	}
+	return shifts, nil
+}
+
+func (entity *cashShiftEntity) GetCashShiftById(id primitive.ObjectID) (entities.CashShift, error) {
+	logrus.Info("GetCashShiftById")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var shift entities.CashShift
+	err := entity.col.FindOne(ctx, bson.M{"_id": id}).Decode(&shift)
+	return shift, err
+}
+
+func (entity *cashShiftEntity) GetOpenCashShift(userId string) (entities.CashShift, error) {
+	logrus.Info("GetOpenCashShift")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var shift entities.CashShift
+	err := entity.col.FindOne(ctx, bson.M{"userId": userId, "status": "OPEN"}).Decode(&shift)
+	return shift, err
+}
+
+func (entity *cashShiftEntity) OpenCashShift(shift entities.CashShift) (entities.CashShift, error) {
+	logrus.Info("OpenCashShift")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	shift.Id = primitive.NewObjectID()
+	shift.Status = "OPEN"
+	shift.Movements = []entities.CashMovement{}
+	shift.OpenedDate = time.Now()
+	_, err := entity.col.InsertOne(ctx, shift)
+	if mongo.IsDuplicateKeyError(err) {
+		return shift, ErrShiftAlreadyOpen
+	}
+	return shift, err
+}
+
+func (entity *cashShiftEntity) AddCashMovement(id primitive.ObjectID, movement entities.CashMovement) (entities.CashMovement, error) {
+	logrus.Info("AddCashMovement")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	movement.Id = primitive.NewObjectID()
+	movement.CreatedDate = time.Now()
+	result, err := entity.col.UpdateOne(ctx, bson.M{"_id": id, "status": "OPEN"}, bson.M{
+		"$push": bson.M{"movements": movement},
+	})
+	if err != nil {
+		return movement, err
+	}
+	if result.MatchedCount == 0 {
+		return movement, ErrShiftClosed
+	}
+	return movement, nil
+}
+
+// CloseCashShift freezes the figures of an open shift.
+func (entity *cashShiftEntity) CloseCashShift(shift entities.CashShift) error {
+	logrus.Info("CloseCashShift")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	result, err := entity.col.UpdateOne(ctx, bson.M{"_id": shift.Id, "status": "OPEN"}, bson.M{"$set": bson.M{
+		"status":       "CLOSED",
+		"cashSales":    shift.CashSales,
+		"creditorCash": shift.CreditorCash,
+		"cashExpenses": shift.CashExpenses,
+		"payIns":       shift.PayIns,
+		"payOuts":      shift.PayOuts,
+		"expectedCash": shift.ExpectedCash,
+		"countedCash":  shift.CountedCash,
+		"overShort":    shift.OverShort,
+		"note":         shift.Note,
+		"closedBy":     shift.ClosedBy,
+		"closedDate":   shift.ClosedDate,
+	}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrShiftClosed
+	}
+	return nil
+}
+
+// SumShiftCash adds up the cash the user took and paid out between from and
+// to: payments and creditor payments made with a cash method, and expenses
+// paid from the drawer. Refunds count for the cashier who paid them out,
+// not the admin who approved them.
+func (entity *cashShiftEntity) SumShiftCash(userId string, from, to time.Time, cashCodes []string) (entities.CashSummary, error) {
+	logrus.Info("SumShiftCash")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var summary entities.CashSummary
+	filter := bson.M{
+		"createdBy":   userId,
+		"createdDate": bson.M{"$gte": from, "$lte": to},
+	}
+	var err error
+	paymentFilter := bson.M{
+		"$or": bson.A{
+			bson.M{"paidOutBy": userId},
+			bson.M{"createdBy": userId, "paidOutBy": bson.M{"$exists": false}},
+		},
+		"createdDate": filter["createdDate"],
+		"type":        bson.M{"$in": cashCodes},
+	}
+	if summary.CashSales, err = sumAmount(ctx, entity.paymentCol, paymentFilter); err != nil {
+		return summary, err
+	}
+	filter["type"] = bson.M{"$in": cashCodes}
+	if summary.CreditorCash, err = sumAmount(ctx, entity.creditorCol, filter); err != nil {
+		return summary, err
+	}
+	delete(filter, "type")
+	filter["paymentType"] = bson.M{"$in": cashCodes}
+	if summary.CashExpenses, err = sumAmount(ctx, entity.expenseCol, filter); err != nil {
+		return summary, err
+	}
+	return summary, nil
+}
+
+func sumAmount(ctx context.Context, col *mongo.Collection, filter bson.M) (float64, error) {
+	cursor, err := col.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": "$amount"}}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	var results []struct {
+		Total float64 `bson:"total"`
+	}
+	if err = cursor.All(ctx, &results); err != nil {
+		return 0, err
+	}
+	if len(results) == 0 {
+		return 0, nil
+	}
+	return results[0].Total, nil
+}
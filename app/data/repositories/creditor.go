@@ -30,6 +30,7 @@ type ICreditor interface {
 func NewCreditorEntity(resource *db.Resource) ICreditor {
 	col := resource.SnookDb.Collection("creditors")
 	payCol := resource.SnookDb.Collection("creditor_payments")
+	normalizePaymentTypes(payCol, "type")
 	return &creditorEntity{col: col, payCol: payCol}
 }
 
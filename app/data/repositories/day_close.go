@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"snook/app/data/entities"
+	"snook/db"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrDayClosed = errors.New("business day is already closed")
+
+type dayCloseEntity struct {
+	col *mongo.Collection
+}
+
+// IDayClose has no update or delete: a Z report is immutable once written.
+type IDayClose interface {
+	GetDayCloses(startDate, endDate string) ([]entities.DayClose, error)
+	GetDayCloseByDate(businessDate string) (entities.DayClose, error)
+	IsDayClosed(businessDate string) (bool, error)
+	CreateDayClose(dayClose entities.DayClose) (entities.DayClose, error)
+}
+
+func NewDayCloseEntity(resource *db.Resource) IDayClose {
+	col := resource.SnookDb.Collection("day_closes")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "businessDate", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "number", Value: 1}}, Options: options.Index().SetUnique(true)},
+	})
+	if err != nil {
+		logrus.Error("failed to create day close index: ", err)
+	}
+	return &dayCloseEntity{col: col}
+}
+
+func (entity *dayCloseEntity) GetDayCloses(startDate, endDate string) ([]entities.DayClose, error) {
+	logrus.Info("GetDayCloses")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	filter := bson.M{"businessDate": bson.M{"$gte": startDate, "$lte": endDate}}
+	opts := options.Find().SetSort(bson.D{{Key: "businessDate", Value: -1}})
+	cursor, err := entity.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var dayCloses []entities.DayClose
+	if err = cursor.All(ctx, &dayCloses); err != nil {
+		return nil, err
+	}
+	return dayCloses, nil
+}
+
+func (entity *dayCloseEntity) GetDayCloseByDate(businessDate string) (entities.DayClose, error) {
+	logrus.Info("GetDayCloseByDate")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var dayClose entities.DayClose
+	err := entity.col.FindOne(ctx, bson.M{"businessDate": businessDate}).Decode(&dayClose)
+	return dayClose, err
+}
+
+func (entity *dayCloseEntity) IsDayClosed(businessDate string) (bool, error) {
+	logrus.Info("IsDayClosed")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	count, err := entity.col.CountDocuments(ctx, bson.M{"businessDate": businessDate})
+	return count > 0, err
+}
+
+// CreateDayClose stores the Z report under the next report number. The
+// unique business date makes closing a day twice fail with ErrDayClosed; a
+// number taken by another day closed at the same time is retried with the
+// next one.
+func (entity *dayCloseEntity) CreateDayClose(dayClose entities.DayClose) (entities.DayClose, error) {
+	logrus.Info("CreateDayClose")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	dayClose.Id = primitive.NewObjectID()
+	dayClose.ClosedDate = time.Now()
+	var err error
+	for attempt := 0; attempt < 5; attempt++ {
+		var last entities.DayClose
+		err = entity.col.FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.D{{Key: "number", Value: -1}})).Decode(&last)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return dayClose, err
+		}
+		dayClose.Number = last.Number + 1
+		_, err = entity.col.InsertOne(ctx, dayClose)
+		if !mongo.IsDuplicateKeyError(err) {
+			return dayClose, err
+		}
+		if closed, _ := entity.IsDayClosed(dayClose.BusinessDate); closed {
+			return dayClose, ErrDayClosed
+		}
+	}
+	return dayClose, err
+}
@@ -27,6 +27,7 @@ type IExpense interface {
 
 func NewExpenseEntity(resource *db.Resource) IExpense {
 	col := resource.SnookDb.Collection("expenses")
+	normalizePaymentTypes(col, "paymentType")
 	return &expenseEntity{col: col}
 }
 
@@ -76,6 +77,7 @@ func (entity *expenseEntity) UpdateExpenseById(id primitive.ObjectID, expense en
 		"category":    expense.Category,
 		"description": expense.Description,
 		"amount":      expense.Amount,
+		"paymentType": expense.PaymentType,
 		"date":        expense.Date,
 		"updatedBy":   expense.UpdatedBy,
 		"updatedDate": expense.UpdatedDate,
@@ -0,0 +1,239 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"math"
+	"snook/app/data/entities"
+	"snook/db"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrIngredientInUse = errors.New("ingredient is used in a recipe")
+
+type ingredientEntity struct {
+	col         *mongo.Collection
+	movementCol *mongo.Collection
+	itemCol     *mongo.Collection
+}
+
+type IIngredient interface {
+	GetIngredients() ([]entities.Ingredient, error)
+	GetIngredientById(id primitive.ObjectID) (entities.Ingredient, error)
+	CreateIngredient(ingredient entities.Ingredient) (entities.Ingredient, error)
+	UpdateIngredientById(id primitive.ObjectID, ingredient entities.Ingredient) error
+	DeleteIngredientById(id primitive.ObjectID) error
+	GetLowStockIngredients() ([]entities.Ingredient, error)
+	PostIngredientMovement(movement entities.IngredientMovement) (entities.IngredientMovement, error)
+	RecordIngredientCount(movement entities.IngredientMovement, counted float64) (entities.IngredientMovement, error)
+	GetIngredientMovements(ingredientId primitive.ObjectID) ([]entities.IngredientMovement, error)
+}
+
+func NewIngredientEntity(resource *db.Resource) IIngredient {
+	col := resource.SnookDb.Collection("ingredients")
+	movementCol := resource.SnookDb.Collection("ingredient_movements")
+	itemCol := resource.SnookDb.Collection("menu_items")
+	return &ingredientEntity{col: col, movementCol: movementCol, itemCol: itemCol}
+}
+
+func (entity *ingredientEntity) GetIngredients() ([]entities.Ingredient, error) {
+	logrus.Info("GetIngredients")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := entity.col.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var ingredients []entities.Ingredient
+	if err = cursor.All(ctx, &ingredients); err != nil {
+		return nil, err
+	}
+	return ingredients, nil
+}
+
+func (entity *ingredientEntity) GetIngredientById(id primitive.ObjectID) (entities.Ingredient, error) {
+	logrus.Info("GetIngredientById")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var ingredient entities.Ingredient
+	err := entity.col.FindOne(ctx, bson.M{"_id": id}).Decode(&ingredient)
+	return ingredient, err
+}
+
+func (entity *ingredientEntity) CreateIngredient(ingredient entities.Ingredient) (entities.Ingredient, error) {
+	logrus.Info("CreateIngredient")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ingredient.Id = primitive.NewObjectID()
+	ingredient.CreatedDate = time.Now()
+	ingredient.UpdatedDate = time.Now()
+	_, err := entity.col.InsertOne(ctx, ingredient)
+	return ingredient, err
+}
+
+func (entity *ingredientEntity) UpdateIngredientById(id primitive.ObjectID, ingredient entities.Ingredient) error {
+	logrus.Info("UpdateIngredientById")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ingredient.UpdatedDate = time.Now()
+	_, err := entity.col.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"name":          ingredient.Name,
+		"unit":          ingredient.Unit,
+		"costPrice":     ingredient.CostPrice,
+		"lowStockLevel": ingredient.LowStockLevel,
+		"status":        ingredient.Status,
+		"updatedBy":     ingredient.UpdatedBy,
+		"updatedDate":   ingredient.UpdatedDate,
+	}})
+	return err
+}
+
+func (entity *ingredientEntity) DeleteIngredientById(id primitive.ObjectID) error {
+	logrus.Info("DeleteIngredientById")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	used, err := entity.itemCol.CountDocuments(ctx, bson.M{"recipe.ingredientId": id})
+	if err != nil {
+		return err
+	}
+	if used > 0 {
+		return ErrIngredientInUse
+	}
+	_, err = entity.col.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (entity *ingredientEntity) GetLowStockIngredients() ([]entities.Ingredient, error) {
+	logrus.Info("GetLowStockIngredients")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	filter := bson.M{
+		"status": "ACTIVE",
+		"$expr":  bson.M{"$lte": bson.A{"$quantity", "$lowStockLevel"}},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "quantity", Value: 1}})
+	cursor, err := entity.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var ingredients []entities.Ingredient
+	if err = cursor.All(ctx, &ingredients); err != nil {
+		return nil, err
+	}
+	return ingredients, nil
+}
+
+// PostIngredientMovement applies a signed quantity change and records it in
+// the ledger. A purchase also moves the cost price to the weighted average.
+func (entity *ingredientEntity) PostIngredientMovement(movement entities.IngredientMovement) (entities.IngredientMovement, error) {
+	logrus.Info("PostIngredientMovement")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if movement.Type == "PURCHASE" && movement.UnitCost > 0 {
+		for attempt := 0; attempt < 3; attempt++ {
+			var ingredient entities.Ingredient
+			if err := entity.col.FindOne(ctx, bson.M{"_id": movement.IngredientId}).Decode(&ingredient); err != nil {
+				return movement, err
+			}
+			cost := weightedAverageCost(ingredient.Quantity, ingredient.CostPrice, movement.Quantity, movement.UnitCost)
+			result, err := entity.col.UpdateOne(ctx,
+				bson.M{"_id": ingredient.Id, "quantity": ingredient.Quantity, "costPrice": ingredient.CostPrice},
+				bson.M{"$inc": bson.M{"quantity": movement.Quantity}, "$set": bson.M{"costPrice": cost}})
+			if err != nil {
+				return movement, err
+			}
+			if result.MatchedCount == 0 {
+				continue
+			}
+			ingredient.Quantity += movement.Quantity
+			ingredient.CostPrice = cost
+			return insertIngredientMovement(ctx, entity.movementCol, movement, ingredient)
+		}
+		return movement, ErrStockCountConflict
+	}
+	return moveIngredient(ctx, entity.col, entity.movementCol, movement, false)
+}
+
+// RecordIngredientCount sets the ingredient to the counted quantity and
+// records the difference, unless its quantity changed since it was read.
+func (entity *ingredientEntity) RecordIngredientCount(movement entities.IngredientMovement, counted float64) (entities.IngredientMovement, error) {
+	logrus.Info("RecordIngredientCount")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for attempt := 0; attempt < 3; attempt++ {
+		var ingredient entities.Ingredient
+		if err := entity.col.FindOne(ctx, bson.M{"_id": movement.IngredientId}).Decode(&ingredient); err != nil {
+			return movement, err
+		}
+		result, err := entity.col.UpdateOne(ctx,
+			bson.M{"_id": ingredient.Id, "quantity": ingredient.Quantity},
+			bson.M{"$set": bson.M{"quantity": counted}})
+		if err != nil {
+			return movement, err
+		}
+		if result.MatchedCount == 0 {
+			continue
+		}
+		movement.Quantity = counted - ingredient.Quantity
+		ingredient.Quantity = counted
+		return insertIngredientMovement(ctx, entity.movementCol, movement, ingredient)
+	}
+	return movement, ErrStockCountConflict
+}
+
+func (entity *ingredientEntity) GetIngredientMovements(ingredientId primitive.ObjectID) ([]entities.IngredientMovement, error) {
+	logrus.Info("GetIngredientMovements")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	opts := options.Find().SetSort(bson.D{{Key: "createdDate", Value: -1}})
+	cursor, err := entity.movementCol.Find(ctx, bson.M{"ingredientId": ingredientId}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var movements []entities.IngredientMovement
+	if err = cursor.All(ctx, &movements); err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+// moveIngredient applies movement to the ingredient quantity and writes the
+// ledger entry. With requireStock a removal only succeeds while enough is on
+// hand, otherwise ErrOutOfStock is returned and nothing changes.
+func moveIngredient(ctx context.Context, col, movementCol *mongo.Collection, movement entities.IngredientMovement, requireStock bool) (entities.IngredientMovement, error) {
+	filter := bson.M{"_id": movement.IngredientId}
+	if requireStock && movement.Quantity < 0 {
+		filter["quantity"] = bson.M{"$gte": -movement.Quantity}
+	}
+	var ingredient entities.Ingredient
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := col.FindOneAndUpdate(ctx, filter, bson.M{"$inc": bson.M{"quantity": movement.Quantity}}, opts).Decode(&ingredient)
+	if errors.Is(err, mongo.ErrNoDocuments) && requireStock {
+		if n, countErr := col.CountDocuments(ctx, bson.M{"_id": movement.IngredientId}); countErr == nil && n > 0 {
+			return movement, ErrOutOfStock
+		}
+	}
+	if err != nil {
+		return movement, err
+	}
+	return insertIngredientMovement(ctx, movementCol, movement, ingredient)
+}
+
+func insertIngredientMovement(ctx context.Context, movementCol *mongo.Collection, movement entities.IngredientMovement, ingredient entities.Ingredient) (entities.IngredientMovement, error) {
+	movement.Id = primitive.NewObjectID()
+	movement.IngredientName = ingredient.Name
+	movement.BalanceAfter = math.Round(ingredient.Quantity*1e4) / 1e4
+	if movement.UnitCost == 0 {
+		movement.UnitCost = ingredient.CostPrice
+	}
+	movement.CreatedDate = time.Now()
+	_, err := movementCol.InsertOne(ctx, movement)
+	return movement, err
+}
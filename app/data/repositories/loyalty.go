@@ -0,0 +1,267 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"snook/app/data/entities"
+	"snook/db"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrInsufficientPoints = errors.New("insufficient points")
+
+type loyaltyEntity struct {
+	resource *db.Resource
+	col      *mongo.Collection
+	txnCol   *mongo.Collection
+}
+
+type ILoyalty interface {
+	GetMembers(keyword string) ([]entities.Member, error)
+	GetMemberById(id primitive.ObjectID) (entities.Member, error)
+	GetMemberByPhone(phone string) (entities.Member, error)
+	CreateMember(member entities.Member) (entities.Member, error)
+	UpdateMemberById(id primitive.ObjectID, member entities.Member) error
+	GetLoyaltyTransactions(memberId primitive.ObjectID) ([]entities.LoyaltyTransaction, error)
+	PostLoyaltyTransaction(txn entities.LoyaltyTransaction) (entities.LoyaltyTransaction, error)
+	ExpirePoints(memberId *primitive.ObjectID, asOf time.Time) (int, error)
+}
+
+func NewLoyaltyEntity(resource *db.Resource) ILoyalty {
+	col := resource.SnookDb.Collection("members")
+	txnCol := resource.SnookDb.Collection("loyalty_transactions")
+	return &loyaltyEntity{resource: resource, col: col, txnCol: txnCol}
+}
+
+func (entity *loyaltyEntity) GetMembers(keyword string) ([]entities.Member, error) {
+	logrus.Info("GetMembers")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	filter := bson.M{}
+	if keyword != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(keyword), Options: "i"}
+		filter["$or"] = []bson.M{{"name": pattern}, {"phone": pattern}}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := entity.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var members []entities.Member
+	if err = cursor.All(ctx, &members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+func (entity *loyaltyEntity) GetMemberById(id primitive.ObjectID) (entities.Member, error) {
+	logrus.Info("GetMemberById")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var member entities.Member
+	err := entity.col.FindOne(ctx, bson.M{"_id": id}).Decode(&member)
+	return member, err
+}
+
+func (entity *loyaltyEntity) GetMemberByPhone(phone string) (entities.Member, error) {
+	logrus.Info("GetMemberByPhone")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var member entities.Member
+	err := entity.col.FindOne(ctx, bson.M{"phone": phone}).Decode(&member)
+	return member, err
+}
+
+func (entity *loyaltyEntity) CreateMember(member entities.Member) (entities.Member, error) {
+	logrus.Info("CreateMember")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	member.Id = primitive.NewObjectID()
+	member.CreatedDate = time.Now()
+	member.UpdatedDate = time.Now()
+	_, err := entity.col.InsertOne(ctx, member)
+	return member, err
+}
+
+func (entity *loyaltyEntity) UpdateMemberById(id primitive.ObjectID, member entities.Member) error {
+	logrus.Info("UpdateMemberById")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	member.UpdatedDate = time.Now()
+	_, err := entity.col.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"name":        member.Name,
+		"phone":       member.Phone,
+		"status":      member.Status,
+		"note":        member.Note,
+		"updatedBy":   member.UpdatedBy,
+		"updatedDate": member.UpdatedDate,
+	}})
+	return err
+}
+
+func (entity *loyaltyEntity) GetLoyaltyTransactions(memberId primitive.ObjectID) ([]entities.LoyaltyTransaction, error) {
+	logrus.Info("GetLoyaltyTransactions")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	opts := options.Find().SetSort(bson.D{{Key: "createdDate", Value: -1}})
+	cursor, err := entity.txnCol.Find(ctx, bson.M{"memberId": memberId}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var txns []entities.LoyaltyTransaction
+	if err = cursor.All(ctx, &txns); err != nil {
+		return nil, err
+	}
+	return txns, nil
+}
+
+// PostLoyaltyTransaction moves the member balance by txn.Points. Positive
+// entries open a new lot that can later be redeemed or expired; negative
+// entries consume the oldest open lots first. The balance, the lots and the
+// ledger entry are written in one transaction.
+func (entity *loyaltyEntity) PostLoyaltyTransaction(txn entities.LoyaltyTransaction) (entities.LoyaltyTransaction, error) {
+	logrus.Info("PostLoyaltyTransaction")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var posted entities.LoyaltyTransaction
+	err := entity.resource.WithTransaction(ctx, func(sc mongo.SessionContext) error {
+		var err error
+		posted, err = postLoyalty(sc, entity.col, entity.txnCol, txn)
+		return err
+	})
+	if err != nil {
+		return txn, err
+	}
+	return posted, nil
+}
+
+// postLoyalty writes one ledger entry against the members and ledger
+// collections. It must run inside a transaction, which checkout shares to
+// post points together with the bill.
+func postLoyalty(ctx context.Context, memberCol, txnCol *mongo.Collection, txn entities.LoyaltyTransaction) (entities.LoyaltyTransaction, error) {
+	if txn.Points == 0 {
+		return txn, errors.New("points must not be zero")
+	}
+	filter := bson.M{"_id": txn.MemberId}
+	inc := bson.M{"points": txn.Points}
+	if txn.Points > 0 {
+		inc["lifetimePoints"] = txn.Points
+		txn.Remaining = txn.Points
+	} else {
+		filter["points"] = bson.M{"$gte": -txn.Points}
+		txn.Remaining = 0
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var member entities.Member
+	err := memberCol.FindOneAndUpdate(ctx, filter, bson.M{"$inc": inc}, opts).Decode(&member)
+	if errors.Is(err, mongo.ErrNoDocuments) && txn.Points < 0 {
+		return txn, ErrInsufficientPoints
+	}
+	if err != nil {
+		return txn, err
+	}
+	if txn.Points < 0 {
+		if err := consumeLots(ctx, txnCol, txn.MemberId, -txn.Points); err != nil {
+			return txn, err
+		}
+	}
+	txn.Id = primitive.NewObjectID()
+	txn.BalanceAfter = member.Points
+	txn.CreatedDate = time.Now()
+	_, err = txnCol.InsertOne(ctx, txn)
+	return txn, err
+}
+
+// consumeLots takes points from the oldest open lots. Each decrement only
+// applies while the lot still holds enough, so a lot consumed concurrently
+// fails the redemption instead of going negative.
+func consumeLots(ctx context.Context, txnCol *mongo.Collection, memberId primitive.ObjectID, points int) error {
+	opts := options.Find().SetSort(bson.D{{Key: "createdDate", Value: 1}})
+	cursor, err := txnCol.Find(ctx, bson.M{"memberId": memberId, "remaining": bson.M{"$gt": 0}}, opts)
+	if err != nil {
+		return err
+	}
+	var lots []entities.LoyaltyTransaction
+	if err = cursor.All(ctx, &lots); err != nil {
+		return err
+	}
+	for _, lot := range lots {
+		if points <= 0 {
+			break
+		}
+		used := lot.Remaining
+		if used > points {
+			used = points
+		}
+		result, err := txnCol.UpdateOne(ctx,
+			bson.M{"_id": lot.Id, "remaining": bson.M{"$gte": used}},
+			bson.M{"$inc": bson.M{"remaining": -used}})
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return ErrInsufficientPoints
+		}
+		points -= used
+	}
+	return nil
+}
+
+func (entity *loyaltyEntity) ExpirePoints(memberId *primitive.ObjectID, asOf time.Time) (int, error) {
+	logrus.Info("ExpirePoints")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	filter := bson.M{"remaining": bson.M{"$gt": 0}, "expiresAt": bson.M{"$lte": asOf}}
+	if memberId != nil {
+		filter["memberId"] = *memberId
+	}
+	cursor, err := entity.txnCol.Find(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	var lots []entities.LoyaltyTransaction
+	if err = cursor.All(ctx, &lots); err != nil {
+		return 0, err
+	}
+	expired := 0
+	for _, lot := range lots {
+		res, err := entity.txnCol.UpdateOne(ctx,
+			bson.M{"_id": lot.Id, "remaining": lot.Remaining},
+			bson.M{"$set": bson.M{"remaining": 0}})
+		if err != nil {
+			return expired, err
+		}
+		if res.ModifiedCount == 0 {
+			continue
+		}
+		var member entities.Member
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		err = entity.col.FindOneAndUpdate(ctx, bson.M{"_id": lot.MemberId},
+			bson.M{"$inc": bson.M{"points": -lot.Remaining}}, opts).Decode(&member)
+		if err != nil {
+			return expired, err
+		}
+		_, err = entity.txnCol.InsertOne(ctx, entities.LoyaltyTransaction{
+			Id:           primitive.NewObjectID(),
+			MemberId:     lot.MemberId,
+			Type:         "EXPIRE",
+			Points:       -lot.Remaining,
+			BalanceAfter: member.Points,
+			Reason:       "points expired",
+			CreatedBy:    "SYSTEM",
+			CreatedDate:  time.Now(),
+		})
+		if err != nil {
+			return expired, err
+		}
+		expired += lot.Remaining
+	}
+	return expired, nil
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"snook/app/data/entities"
 	"snook/db"
 	"time"
@@ -13,27 +14,41 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+var ErrMenuCategoryInUse = errors.New("menu category still has menu items")
+
 type menuCategoryEntity struct {
-	col *mongo.Collection
+	col          *mongo.Collection
+	itemCol      *mongo.Collection
+	promotionCol *mongo.Collection
 }
 
 type IMenuCategory interface {
 	GetMenuCategories() ([]entities.MenuCategory, error)
 	GetMenuCategoryById(id primitive.ObjectID) (entities.MenuCategory, error)
+	GetMenuCategoryByName(name string) (entities.MenuCategory, error)
 	CreateMenuCategory(cat entities.MenuCategory) (entities.MenuCategory, error)
 	UpdateMenuCategoryById(id primitive.ObjectID, cat entities.MenuCategory) error
 	DeleteMenuCategoryById(id primitive.ObjectID) error
+	MigrateMenuItemCategories(userId string) (entities.CategoryMigration, error)
 }
 
 func NewMenuCategoryEntity(resource *db.Resource) IMenuCategory {
 	col := resource.SnookDb.Collection("menu_categories")
-	return &menuCategoryEntity{col: col}
+	return &menuCategoryEntity{
+		col:          col,
+		itemCol:      resource.SnookDb.Collection("menu_items"),
+		promotionCol: resource.SnookDb.Collection("promotions"),
+	}
 }
 
 func (entity *menuCategoryEntity) GetMenuCategories() ([]entities.MenuCategory, error) {
 	logrus.Info("GetMenuCategories")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
+	return entity.findMenuCategories(ctx)
+}
+
+func (entity *menuCategoryEntity) findMenuCategories(ctx context.Context) ([]entities.MenuCategory, error) {
 	opts := options.Find().SetSort(bson.D{{Key: "sortOrder", Value: 1}})
 	cursor, err := entity.col.Find(ctx, bson.M{}, opts)
 	if err != nil {
@@ -55,10 +70,23 @@ func (entity *menuCategoryEntity) GetMenuCategoryById(id primitive.ObjectID) (en
 	return cat, err
 }
 
+func (entity *menuCategoryEntity) GetMenuCategoryByName(name string) (entities.MenuCategory, error) {
+	logrus.Info("GetMenuCategoryByName")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var cat entities.MenuCategory
+	err := entity.col.FindOne(ctx, bson.M{"name": name}).Decode(&cat)
+	return cat, err
+}
+
 func (entity *menuCategoryEntity) CreateMenuCategory(cat entities.MenuCategory) (entities.MenuCategory, error) {
 	logrus.Info("CreateMenuCategory")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
+	return entity.insertMenuCategory(ctx, cat)
+}
+
+func (entity *menuCategoryEntity) insertMenuCategory(ctx context.Context, cat entities.MenuCategory) (entities.MenuCategory, error) {
 	cat.Id = primitive.NewObjectID()
 	cat.CreatedDate = time.Now()
 	cat.UpdatedDate = time.Now()
@@ -66,24 +94,104 @@ func (entity *menuCategoryEntity) CreateMenuCategory(cat entities.MenuCategory)
 	return cat, err
 }
 
+// UpdateMenuCategoryById saves the category settings. A rename is carried
+// over to its menu items and to promotions on the category.
 func (entity *menuCategoryEntity) UpdateMenuCategoryById(id primitive.ObjectID, cat entities.MenuCategory) error {
 	logrus.Info("UpdateMenuCategoryById")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
+	return entity.updateMenuCategory(ctx, id, cat)
+}
+
+func (entity *menuCategoryEntity) updateMenuCategory(ctx context.Context, id primitive.ObjectID, cat entities.MenuCategory) error {
+	var current entities.MenuCategory
+	if err := entity.col.FindOne(ctx, bson.M{"_id": id}).Decode(&current); err != nil {
+		return err
+	}
 	cat.UpdatedDate = time.Now()
 	_, err := entity.col.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
 		"name":        cat.Name,
 		"sortOrder":   cat.SortOrder,
+		"station":     cat.Station,
+		"taxClass":    cat.TaxClass,
+		"hidden":      cat.Hidden,
 		"updatedBy":   cat.UpdatedBy,
 		"updatedDate": cat.UpdatedDate,
 	}})
+	if err != nil || current.Name == cat.Name {
+		return err
+	}
+	_, err = entity.itemCol.UpdateMany(ctx, bson.M{"categoryId": id}, bson.M{"$set": bson.M{"category": cat.Name}})
+	if err != nil {
+		return err
+	}
+	_, err = entity.promotionCol.UpdateMany(ctx, bson.M{"category": current.Name}, bson.M{"$set": bson.M{"category": cat.Name}})
 	return err
 }
 
+// DeleteMenuCategoryById returns ErrMenuCategoryInUse while menu items are
+// linked to the category, by id or by its name on items not yet migrated.
 func (entity *menuCategoryEntity) DeleteMenuCategoryById(id primitive.ObjectID) error {
 	logrus.Info("DeleteMenuCategoryById")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	_, err := entity.col.DeleteOne(ctx, bson.M{"_id": id})
+	var cat entities.MenuCategory
+	if err := entity.col.FindOne(ctx, bson.M{"_id": id}).Decode(&cat); err != nil {
+		return err
+	}
+	items, err := entity.itemCol.CountDocuments(ctx, bson.M{"$or": []bson.M{
+		{"categoryId": id},
+		{"categoryId": bson.M{"$exists": false}, "category": cat.Name},
+	}})
+	if err != nil {
+		return err
+	}
+	if items > 0 {
+		return ErrMenuCategoryInUse
+	}
+	_, err = entity.col.DeleteOne(ctx, bson.M{"_id": id})
 	return err
 }
+
+// MigrateMenuItemCategories links menu items that only carry a category name
+// to the category of that name, creating categories that do not exist yet.
+// It can be run repeatedly.
+func (entity *menuCategoryEntity) MigrateMenuItemCategories(userId string) (entities.CategoryMigration, error) {
+	logrus.Info("MigrateMenuItemCategories")
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	migration := entities.CategoryMigration{CategoriesCreated: []string{}}
+	names, err := entity.itemCol.Distinct(ctx, "category", bson.M{"categoryId": bson.M{"$exists": false}})
+	if err != nil {
+		return migration, err
+	}
+	for _, value := range names {
+		name, ok := value.(string)
+		if !ok || name == "" {
+			continue
+		}
+		var cat entities.MenuCategory
+		err := entity.col.FindOne(ctx, bson.M{"name": name}).Decode(&cat)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			now := time.Now()
+			cat = entities.MenuCategory{
+				Id: primitive.NewObjectID(), Name: name, Station: "KITCHEN",
+				CreatedBy: userId, CreatedDate: now, UpdatedBy: userId, UpdatedDate: now,
+			}
+			if _, err = entity.col.InsertOne(ctx, cat); err != nil {
+				return migration, err
+			}
+			migration.CategoriesCreated = append(migration.CategoriesCreated, name)
+		} else if err != nil {
+			return migration, err
+		}
+		result, err := entity.itemCol.UpdateMany(ctx,
+			bson.M{"category": name, "categoryId": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"categoryId": cat.Id}})
+		if err != nil {
+			return migration, err
+		}
+		migration.ItemsLinked += result.ModifiedCount
+	}
+	return migration, nil
+}
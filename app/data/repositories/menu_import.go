@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"context"
+	"snook/app/data/entities"
+	"snook/db"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type menuImportEntity struct {
+	resource *db.Resource
+	category *menuCategoryEntity
+	item     *menuItemEntity
+}
+
+type IMenuImport interface {
+	RunMenuImport(fn func(tx MenuImportTx) error) error
+}
+
+// MenuImportTx reads and writes the menu for an import. Every call runs in
+// the import's transaction.
+type MenuImportTx interface {
+	GetMenuCategories() ([]entities.MenuCategory, error)
+	CreateMenuCategory(cat entities.MenuCategory) (entities.MenuCategory, error)
+	UpdateMenuCategoryById(id primitive.ObjectID, cat entities.MenuCategory) error
+	GetMenuItems() ([]entities.MenuItem, error)
+	CreateMenuItem(item entities.MenuItem) (entities.MenuItem, error)
+	UpdateMenuItemById(id primitive.ObjectID, item entities.MenuItem) error
+	PostStockMovement(movement entities.StockMovement) (entities.StockMovement, error)
+	RecordStockCount(movement entities.StockMovement, counted int) (entities.StockMovement, error)
+}
+
+func NewMenuImportEntity(resource *db.Resource) IMenuImport {
+	return &menuImportEntity{
+		resource: resource,
+		category: &menuCategoryEntity{
+			col:          resource.SnookDb.Collection("menu_categories"),
+			itemCol:      resource.SnookDb.Collection("menu_items"),
+			promotionCol: resource.SnookDb.Collection("promotions"),
+		},
+		item: &menuItemEntity{
+			resource:    resource,
+			col:         resource.SnookDb.Collection("menu_items"),
+			movementCol: resource.SnookDb.Collection("stock_movements"),
+		},
+	}
+}
+
+// RunMenuImport calls fn in one transaction, so a row that fails part way
+// leaves the menu as it was.
+func (entity *menuImportEntity) RunMenuImport(fn func(tx MenuImportTx) error) error {
+	logrus.Info("RunMenuImport")
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	return entity.resource.WithTransaction(ctx, func(sc mongo.SessionContext) error {
+		return fn(&menuImportTx{ctx: sc, category: entity.category, item: entity.item})
+	})
+}
+
+type menuImportTx struct {
+	ctx      context.Context
+	category *menuCategoryEntity
+	item     *menuItemEntity
+}
+
+func (tx *menuImportTx) GetMenuCategories() ([]entities.MenuCategory, error) {
+	return tx.category.findMenuCategories(tx.ctx)
+}
+
+func (tx *menuImportTx) CreateMenuCategory(cat entities.MenuCategory) (entities.MenuCategory, error) {
+	return tx.category.insertMenuCategory(tx.ctx, cat)
+}
+
+func (tx *menuImportTx) UpdateMenuCategoryById(id primitive.ObjectID, cat entities.MenuCategory) error {
+	return tx.category.updateMenuCategory(tx.ctx, id, cat)
+}
+
+func (tx *menuImportTx) GetMenuItems() ([]entities.MenuItem, error) {
+	return tx.item.findMenuItems(tx.ctx, "")
+}
+
+func (tx *menuImportTx) CreateMenuItem(item entities.MenuItem) (entities.MenuItem, error) {
+	return tx.item.insertMenuItem(tx.ctx, item)
+}
+
+func (tx *menuImportTx) UpdateMenuItemById(id primitive.ObjectID, item entities.MenuItem) error {
+	return tx.item.updateMenuItem(tx.ctx, id, item)
+}
+
+func (tx *menuImportTx) PostStockMovement(movement entities.StockMovement) (entities.StockMovement, error) {
+	return moveStock(tx.ctx, tx.item.col, tx.item.movementCol, movement, false)
+}
+
+func (tx *menuImportTx) RecordStockCount(movement entities.StockMovement, counted int) (entities.StockMovement, error) {
+	return tx.item.countStock(tx.ctx, movement, counted)
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"snook/app/data/entities"
 	"snook/db"
 	"time"
@@ -13,29 +14,67 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+var (
+	ErrOutOfStock         = errors.New("insufficient stock")
+	ErrStockCountConflict = errors.New("stock changed during count, please retry")
+	ErrDuplicateItemCode  = errors.New("sku or barcode is already used by another item")
+	ErrMenuItemHasVariant = errors.New("menu item still has variants")
+)
+
 type menuItemEntity struct {
-	col *mongo.Collection
+	resource    *db.Resource
+	col         *mongo.Collection
+	movementCol *mongo.Collection
 }
 
 type IMenuItem interface {
 	GetMenuItems(category string) ([]entities.MenuItem, error)
 	GetMenuItemById(id primitive.ObjectID) (entities.MenuItem, error)
+	GetMenuItemByCode(code string) (entities.MenuItem, error)
+	GetMenuItemVariants(parentId primitive.ObjectID) ([]entities.MenuItem, error)
 	CreateMenuItem(item entities.MenuItem) (entities.MenuItem, error)
 	UpdateMenuItemById(id primitive.ObjectID, item entities.MenuItem) error
 	DeleteMenuItemById(id primitive.ObjectID) error
-	UpdateMenuItemQuantity(id primitive.ObjectID, quantity int) error
+	UpdateMenuItemRecipe(id primitive.ObjectID, recipe []entities.RecipeLine, updatedBy string) error
+	UpdateMenuItemModifiers(id primitive.ObjectID, groups []entities.ModifierGroup, updatedBy string) error
+	UpdateMenuItemAvailability(id primitive.ObjectID, windows []entities.AvailabilityWindow, updatedBy string) error
+	UpdateMenuItemImage(id primitive.ObjectID, imageUrl, thumbnailUrl, updatedBy string) error
+	CountImageReferences(url string) (int64, error)
+	PostStockMovement(movement entities.StockMovement) (entities.StockMovement, error)
+	RecordStockCount(movement entities.StockMovement, counted int) (entities.StockMovement, error)
+	GetStockMovements(menuItemId primitive.ObjectID) ([]entities.StockMovement, error)
+	GetStockReconciliation() ([]entities.StockReconciliation, error)
+	ReconcileStock() ([]entities.StockReconciliation, error)
 	GetLowStockMenuItems(threshold int) ([]entities.LowStockMenuItem, error)
 }
 
 func NewMenuItemEntity(resource *db.Resource) IMenuItem {
 	col := resource.SnookDb.Collection("menu_items")
-	return &menuItemEntity{col: col}
+	movementCol := resource.SnookDb.Collection("stock_movements")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// Items without a code are left out of the unique indexes.
+	for _, key := range []string{"sku", "barcode"} {
+		_, err := col.Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys: bson.D{{Key: key, Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{key: bson.M{"$type": "string", "$gt": ""}}),
+		})
+		if err != nil {
+			logrus.Error("failed to create menu item "+key+" index: ", err)
+		}
+	}
+	return &menuItemEntity{resource: resource, col: col, movementCol: movementCol}
 }
 
 func (entity *menuItemEntity) GetMenuItems(category string) ([]entities.MenuItem, error) {
 	logrus.Info("GetMenuItems")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
+	return entity.findMenuItems(ctx, category)
+}
+
+func (entity *menuItemEntity) findMenuItems(ctx context.Context, category string) ([]entities.MenuItem, error) {
 	filter := bson.M{}
 	if category != "" {
 		filter["category"] = category
@@ -61,14 +100,51 @@ func (entity *menuItemEntity) GetMenuItemById(id primitive.ObjectID) (entities.M
 	return item, err
 }
 
+// GetMenuItemByCode finds the item a scanned code belongs to, matching the
+// barcode first and then the SKU.
+func (entity *menuItemEntity) GetMenuItemByCode(code string) (entities.MenuItem, error) {
+	logrus.Info("GetMenuItemByCode")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var item entities.MenuItem
+	err := entity.col.FindOne(ctx, bson.M{"barcode": code}).Decode(&item)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		err = entity.col.FindOne(ctx, bson.M{"sku": code}).Decode(&item)
+	}
+	return item, err
+}
+
+func (entity *menuItemEntity) GetMenuItemVariants(parentId primitive.ObjectID) ([]entities.MenuItem, error) {
+	logrus.Info("GetMenuItemVariants")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	opts := options.Find().SetSort(bson.D{{Key: "price", Value: 1}})
+	cursor, err := entity.col.Find(ctx, bson.M{"parentId": parentId}, opts)
+	if err != nil {
+		return nil, err
+	}
+	items := []entities.MenuItem{}
+	if err = cursor.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 func (entity *menuItemEntity) CreateMenuItem(item entities.MenuItem) (entities.MenuItem, error) {
 	logrus.Info("CreateMenuItem")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
+	return entity.insertMenuItem(ctx, item)
+}
+
+func (entity *menuItemEntity) insertMenuItem(ctx context.Context, item entities.MenuItem) (entities.MenuItem, error) {
 	item.Id = primitive.NewObjectID()
 	item.CreatedDate = time.Now()
 	item.UpdatedDate = time.Now()
 	_, err := entity.col.InsertOne(ctx, item)
+	if mongo.IsDuplicateKeyError(err) {
+		err = ErrDuplicateItemCode
+	}
 	return item, err
 }
 
@@ -76,17 +152,38 @@ func (entity *menuItemEntity) UpdateMenuItemById(id primitive.ObjectID, item ent
 	logrus.Info("UpdateMenuItemById")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
+	return entity.updateMenuItem(ctx, id, item)
+}
+
+func (entity *menuItemEntity) updateMenuItem(ctx context.Context, id primitive.ObjectID, item entities.MenuItem) error {
 	item.UpdatedDate = time.Now()
 	_, err := entity.col.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"name":         item.Name,
+		"categoryId":   item.CategoryId,
+		"category":     item.Category,
+		"price":        item.Price,
+		"costPrice":    item.CostPrice,
+		"unit":         item.Unit,
+		"status":       item.Status,
+		"imageUrl":     item.ImageUrl,
+		"thumbnailUrl": item.ThumbnailUrl,
+		"variantName":  item.VariantName,
+		"sku":          item.Sku,
+		"barcode":      item.Barcode,
+		"updatedBy":    item.UpdatedBy,
+		"updatedDate":  item.UpdatedDate,
+	}})
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateItemCode
+	}
+	if err != nil {
+		return err
+	}
+	// Variants share the name and category of their parent.
+	_, err = entity.col.UpdateMany(ctx, bson.M{"parentId": id}, bson.M{"$set": bson.M{
 		"name":        item.Name,
+		"categoryId":  item.CategoryId,
 		"category":    item.Category,
-		"price":       item.Price,
-		"costPrice":   item.CostPrice,
-		"quantity":    item.Quantity,
-		"unit":        item.Unit,
-		"status":      item.Status,
-		"imageUrl":    item.ImageUrl,
-		"updatedBy":   item.UpdatedBy,
 		"updatedDate": item.UpdatedDate,
 	}})
 	return err
@@ -96,23 +193,278 @@ func (entity *menuItemEntity) DeleteMenuItemById(id primitive.ObjectID) error {
 	logrus.Info("DeleteMenuItemById")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	_, err := entity.col.DeleteOne(ctx, bson.M{"_id": id})
+	variants, err := entity.col.CountDocuments(ctx, bson.M{"parentId": id})
+	if err != nil {
+		return err
+	}
+	if variants > 0 {
+		return ErrMenuItemHasVariant
+	}
+	_, err = entity.col.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (entity *menuItemEntity) UpdateMenuItemRecipe(id primitive.ObjectID, recipe []entities.RecipeLine, updatedBy string) error {
+	logrus.Info("UpdateMenuItemRecipe")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := entity.col.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"recipe":      recipe,
+		"updatedBy":   updatedBy,
+		"updatedDate": time.Now(),
+	}})
+	return err
+}
+
+func (entity *menuItemEntity) UpdateMenuItemModifiers(id primitive.ObjectID, groups []entities.ModifierGroup, updatedBy string) error {
+	logrus.Info("UpdateMenuItemModifiers")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := entity.col.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"modifiers":   groups,
+		"updatedBy":   updatedBy,
+		"updatedDate": time.Now(),
+	}})
 	return err
 }
 
-func (entity *menuItemEntity) UpdateMenuItemQuantity(id primitive.ObjectID, quantity int) error {
-	logrus.Info("UpdateMenuItemQuantity")
+func (entity *menuItemEntity) UpdateMenuItemAvailability(id primitive.ObjectID, windows []entities.AvailabilityWindow, updatedBy string) error {
+	logrus.Info("UpdateMenuItemAvailability")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	_, err := entity.col.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$inc": bson.M{"quantity": quantity}})
+	_, err := entity.col.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"availability": windows,
+		"updatedBy":    updatedBy,
+		"updatedDate":  time.Now(),
+	}})
 	return err
 }
 
+func (entity *menuItemEntity) UpdateMenuItemImage(id primitive.ObjectID, imageUrl, thumbnailUrl, updatedBy string) error {
+	logrus.Info("UpdateMenuItemImage")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := entity.col.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"imageUrl":     imageUrl,
+		"thumbnailUrl": thumbnailUrl,
+		"updatedBy":    updatedBy,
+		"updatedDate":  time.Now(),
+	}})
+	return err
+}
+
+// CountImageReferences counts the items whose image or thumbnail is url.
+// Variants start out sharing the image of their parent.
+func (entity *menuItemEntity) CountImageReferences(url string) (int64, error) {
+	logrus.Info("CountImageReferences")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return entity.col.CountDocuments(ctx, bson.M{"$or": bson.A{
+		bson.M{"imageUrl": url},
+		bson.M{"thumbnailUrl": url},
+	}})
+}
+
+// PostStockMovement applies a signed quantity change to the item and records
+// it in the ledger with the resulting balance.
+func (entity *menuItemEntity) PostStockMovement(movement entities.StockMovement) (entities.StockMovement, error) {
+	logrus.Info("PostStockMovement")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return moveStock(ctx, entity.col, entity.movementCol, movement, false)
+}
+
+// RecordStockCount sets the item to the counted quantity and records the
+// difference. The update only applies if the quantity did not change since it
+// was read, so sales made during the count are not overwritten.
+func (entity *menuItemEntity) RecordStockCount(movement entities.StockMovement, counted int) (entities.StockMovement, error) {
+	logrus.Info("RecordStockCount")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return entity.countStock(ctx, movement, counted)
+}
+
+func (entity *menuItemEntity) countStock(ctx context.Context, movement entities.StockMovement, counted int) (entities.StockMovement, error) {
+	for attempt := 0; attempt < 3; attempt++ {
+		var item entities.MenuItem
+		if err := entity.col.FindOne(ctx, bson.M{"_id": movement.MenuItemId}).Decode(&item); err != nil {
+			return movement, err
+		}
+		result, err := entity.col.UpdateOne(ctx,
+			bson.M{"_id": item.Id, "quantity": item.Quantity},
+			bson.M{"$set": bson.M{"quantity": counted}})
+		if err != nil {
+			return movement, err
+		}
+		if result.MatchedCount == 0 {
+			continue
+		}
+		movement.Quantity = counted - item.Quantity
+		item.Quantity = counted
+		return insertStockMovement(ctx, entity.movementCol, movement, item)
+	}
+	return movement, ErrStockCountConflict
+}
+
+// moveStock applies movement to the item quantity and writes the ledger entry.
+// With requireStock a removal only succeeds while enough stock is on hand,
+// otherwise ErrOutOfStock is returned and nothing changes.
+func moveStock(ctx context.Context, itemCol, movementCol *mongo.Collection, movement entities.StockMovement, requireStock bool) (entities.StockMovement, error) {
+	filter := bson.M{"_id": movement.MenuItemId}
+	if requireStock && movement.Quantity < 0 {
+		filter["quantity"] = bson.M{"$gte": -movement.Quantity}
+	}
+	var item entities.MenuItem
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := itemCol.FindOneAndUpdate(ctx, filter, bson.M{"$inc": bson.M{"quantity": movement.Quantity}}, opts).Decode(&item)
+	if errors.Is(err, mongo.ErrNoDocuments) && requireStock {
+		if n, countErr := itemCol.CountDocuments(ctx, bson.M{"_id": movement.MenuItemId}); countErr == nil && n > 0 {
+			return movement, ErrOutOfStock
+		}
+	}
+	if err != nil {
+		return movement, err
+	}
+	return insertStockMovement(ctx, movementCol, movement, item)
+}
+
+func insertStockMovement(ctx context.Context, movementCol *mongo.Collection, movement entities.StockMovement, item entities.MenuItem) (entities.StockMovement, error) {
+	movement.Id = primitive.NewObjectID()
+	movement.MenuItemName = item.Name
+	movement.BalanceAfter = item.Quantity
+	if movement.UnitCost == 0 {
+		movement.UnitCost = item.CostPrice
+	}
+	movement.CreatedDate = time.Now()
+	_, err := movementCol.InsertOne(ctx, movement)
+	return movement, err
+}
+
+func (entity *menuItemEntity) GetStockMovements(menuItemId primitive.ObjectID) ([]entities.StockMovement, error) {
+	logrus.Info("GetStockMovements")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	opts := options.Find().SetSort(bson.D{{Key: "createdDate", Value: -1}})
+	cursor, err := entity.movementCol.Find(ctx, bson.M{"menuItemId": menuItemId}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var movements []entities.StockMovement
+	if err = cursor.All(ctx, &movements); err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+// GetStockReconciliation compares the quantity on hand of every item with the
+// sum of its ledger and returns the items that disagree.
+func (entity *menuItemEntity) GetStockReconciliation() ([]entities.StockReconciliation, error) {
+	logrus.Info("GetStockReconciliation")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":       "$menuItemId",
+			"balance":   bson.M{"$sum": "$quantity"},
+			"movements": bson.M{"$sum": 1},
+		}}},
+	}
+	cursor, err := entity.movementCol.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var ledger []struct {
+		MenuItemId primitive.ObjectID `bson:"_id"`
+		Balance    int                `bson:"balance"`
+		Movements  int                `bson:"movements"`
+	}
+	if err = cursor.All(ctx, &ledger); err != nil {
+		return nil, err
+	}
+	balances := map[primitive.ObjectID]int{}
+	counts := map[primitive.ObjectID]int{}
+	for _, l := range ledger {
+		balances[l.MenuItemId] = l.Balance
+		counts[l.MenuItemId] = l.Movements
+	}
+	cursor, err = entity.col.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var items []entities.MenuItem
+	if err = cursor.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	var rows []entities.StockReconciliation
+	for _, item := range items {
+		balance := balances[item.Id]
+		if balance == item.Quantity {
+			continue
+		}
+		rows = append(rows, entities.StockReconciliation{
+			MenuItemId:    item.Id,
+			Name:          item.Name,
+			OnHand:        item.Quantity,
+			LedgerBalance: balance,
+			Difference:    item.Quantity - balance,
+			Movements:     counts[item.Id],
+		})
+	}
+	return rows, nil
+}
+
+// ReconcileStock brings the ledger of every item in line with its quantity
+// on hand. The difference is reset and then posted as an ADJUSTMENT movement
+// in one transaction, so each correction is on record; for items without any
+// movement yet it is their opening balance. Items whose stock moved since
+// they were compared are left for the next run.
+func (entity *menuItemEntity) ReconcileStock() ([]entities.StockReconciliation, error) {
+	logrus.Info("ReconcileStock")
+	rows, err := entity.GetStockReconciliation()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	var reconciled []entities.StockReconciliation
+	for _, row := range rows {
+		reason := "stock reconciliation"
+		if row.Movements == 0 {
+			reason = "opening balance"
+		}
+		var changed bool
+		err := entity.resource.WithTransaction(ctx, func(sc mongo.SessionContext) error {
+			result, err := entity.col.UpdateOne(sc, bson.M{"_id": row.MenuItemId, "quantity": row.OnHand},
+				bson.M{"$set": bson.M{"quantity": row.LedgerBalance}})
+			if err != nil {
+				return err
+			}
+			changed = result.MatchedCount > 0
+			if !changed {
+				return nil
+			}
+			_, err = moveStock(sc, entity.col, entity.movementCol, entities.StockMovement{
+				MenuItemId: row.MenuItemId, Type: "ADJUSTMENT", Quantity: row.Difference,
+				Reason: reason, CreatedBy: "SYSTEM",
+			}, false)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			reconciled = append(reconciled, row)
+		}
+	}
+	return reconciled, nil
+}
+
 func (entity *menuItemEntity) GetLowStockMenuItems(threshold int) ([]entities.LowStockMenuItem, error) {
 	logrus.Info("GetLowStockMenuItems")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	filter := bson.M{"quantity": bson.M{"$lte": threshold}, "status": "ACTIVE"}
+	// Items with a recipe are stocked through their ingredients.
+	filter := bson.M{"quantity": bson.M{"$lte": threshold}, "status": "ACTIVE", "recipe.0": bson.M{"$exists": false}}
 	opts := options.Find().SetSort(bson.D{{Key: "quantity", Value: 1}})
 	cursor, err := entity.col.Find(ctx, filter, opts)
 	if err != nil {
@@ -0,0 +1,230 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"snook/app/data/entities"
+	"snook/db"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ticketChannel is the Redis channel ticket changes are published on, so
+// every API instance can push them to its connected station screens.
+const ticketChannel = "snook:order-tickets"
+
+var ErrTicketTransition = errors.New("ticket cannot move to this status")
+
+// ticketTransitions lists the statuses a ticket may come from for each
+// target status.
+var ticketTransitions = map[string][]string{
+	"PREPARING": {"QUEUED"},
+	"READY":     {"QUEUED", "PREPARING"},
+	"SERVED":    {"READY"},
+	"CANCELLED": {"QUEUED", "PREPARING", "READY"},
+}
+
+type orderTicketEntity struct {
+	col *mongo.Collection
+	rdb *redis.Client
+}
+
+type IOrderTicket interface {
+	GetOrderTickets(station string, statuses []string) ([]entities.OrderTicket, error)
+	GetOrderTicketById(id primitive.ObjectID) (entities.OrderTicket, error)
+	CreateOrderTicket(ticket entities.OrderTicket) (entities.OrderTicket, error)
+	UpdateOrderTicketStatus(id primitive.ObjectID, status, userId string) (entities.OrderTicket, error)
+	CancelOrderTickets(orderId primitive.ObjectID, userId string) ([]entities.OrderTicket, error)
+	GetPrepMetrics(startDate, endDate time.Time) ([]entities.StationPrepMetrics, error)
+	PublishOrderTicket(ticket entities.OrderTicket) error
+	SubscribeOrderTickets(ctx context.Context) (<-chan entities.OrderTicket, func() error)
+}
+
+func NewOrderTicketEntity(resource *db.Resource) IOrderTicket {
+	col := resource.SnookDb.Collection("order_tickets")
+	return &orderTicketEntity{col: col, rdb: resource.RdDb}
+}
+
+func (entity *orderTicketEntity) GetOrderTickets(station string, statuses []string) ([]entities.OrderTicket, error) {
+	logrus.Info("GetOrderTickets")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	filter := bson.M{}
+	if station != "" {
+		filter["station"] = station
+	}
+	if len(statuses) > 0 {
+		filter["status"] = bson.M{"$in": statuses}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "queuedDate", Value: 1}})
+	cursor, err := entity.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	tickets := []entities.OrderTicket{}
+	if err = cursor.All(ctx, &tickets); err != nil {
+		return nil, err
+	}
+	return tickets, nil
+}
+
+func (entity *orderTicketEntity) GetOrderTicketById(id primitive.ObjectID) (entities.OrderTicket, error) {
+	logrus.Info("GetOrderTicketById")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var ticket entities.OrderTicket
+	err := entity.col.FindOne(ctx, bson.M{"_id": id}).Decode(&ticket)
+	return ticket, err
+}
+
+func (entity *orderTicketEntity) CreateOrderTicket(ticket entities.OrderTicket) (entities.OrderTicket, error) {
+	logrus.Info("CreateOrderTicket")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ticket.Id = primitive.NewObjectID()
+	ticket.Status = "QUEUED"
+	ticket.QueuedDate = time.Now()
+	ticket.UpdatedBy = ticket.CreatedBy
+	ticket.UpdatedDate = ticket.QueuedDate
+	_, err := entity.col.InsertOne(ctx, ticket)
+	return ticket, err
+}
+
+// UpdateOrderTicketStatus moves the ticket to status and stamps the time it
+// reached it. It returns ErrTicketTransition when the ticket is not in a
+// status it can move from.
+func (entity *orderTicketEntity) UpdateOrderTicketStatus(id primitive.ObjectID, status, userId string) (entities.OrderTicket, error) {
+	logrus.Info("UpdateOrderTicketStatus")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	from, ok := ticketTransitions[status]
+	if !ok {
+		return entities.OrderTicket{}, ErrTicketTransition
+	}
+	now := time.Now()
+	set := bson.M{"status": status, "updatedBy": userId, "updatedDate": now}
+	switch status {
+	case "PREPARING":
+		set["startedDate"] = now
+	case "READY":
+		set["readyDate"] = now
+	case "SERVED":
+		set["servedDate"] = now
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var ticket entities.OrderTicket
+	err := entity.col.FindOneAndUpdate(ctx, bson.M{"_id": id, "status": bson.M{"$in": from}}, bson.M{"$set": set}, opts).Decode(&ticket)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if _, findErr := entity.GetOrderTicketById(id); findErr == nil {
+			return ticket, ErrTicketTransition
+		}
+	}
+	return ticket, err
+}
+
+// CancelOrderTickets cancels the open tickets of an order and returns them.
+func (entity *orderTicketEntity) CancelOrderTickets(orderId primitive.ObjectID, userId string) ([]entities.OrderTicket, error) {
+	logrus.Info("CancelOrderTickets")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	filter := bson.M{"orderId": orderId, "status": bson.M{"$in": ticketTransitions["CANCELLED"]}}
+	cursor, err := entity.col.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var tickets []entities.OrderTicket
+	if err = cursor.All(ctx, &tickets); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	_, err = entity.col.UpdateMany(ctx, filter, bson.M{"$set": bson.M{
+		"status": "CANCELLED", "updatedBy": userId, "updatedDate": now,
+	}})
+	for i := range tickets {
+		tickets[i].Status = "CANCELLED"
+		tickets[i].UpdatedBy = userId
+		tickets[i].UpdatedDate = now
+	}
+	return tickets, err
+}
+
+// GetPrepMetrics reports ticket timings per station for tickets queued in the
+// date range. Cancelled tickets are left out.
+func (entity *orderTicketEntity) GetPrepMetrics(startDate, endDate time.Time) ([]entities.StationPrepMetrics, error) {
+	logrus.Info("GetPrepMetrics")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	minutes := func(end, start interface{}) bson.M {
+		return bson.M{"$divide": bson.A{bson.M{"$subtract": bson.A{end, start}}, 60000}}
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"queuedDate": bson.M{"$gte": startDate, "$lte": endDate},
+			"status":     bson.M{"$ne": "CANCELLED"},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"station":   1,
+			"completed": bson.M{"$cond": bson.A{bson.M{"$ifNull": bson.A{"$readyDate", false}}, 1, 0}},
+			"waitMins":  minutes("$startedDate", "$queuedDate"),
+			"prepMins":  minutes("$readyDate", bson.M{"$ifNull": bson.A{"$startedDate", "$queuedDate"}}),
+			"serveMins": minutes("$servedDate", "$readyDate"),
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":          "$station",
+			"tickets":      bson.M{"$sum": 1},
+			"completed":    bson.M{"$sum": "$completed"},
+			"avgWaitMins":  bson.M{"$avg": "$waitMins"},
+			"avgPrepMins":  bson.M{"$avg": "$prepMins"},
+			"maxPrepMins":  bson.M{"$max": "$prepMins"},
+			"avgServeMins": bson.M{"$avg": "$serveMins"},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+	cursor, err := entity.col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	metrics := []entities.StationPrepMetrics{}
+	if err = cursor.All(ctx, &metrics); err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+func (entity *orderTicketEntity) PublishOrderTicket(ticket entities.OrderTicket) error {
+	payload, err := json.Marshal(ticket)
+	if err != nil {
+		return err
+	}
+	return entity.rdb.Publish(context.Background(), ticketChannel, payload).Err()
+}
+
+// SubscribeOrderTickets streams published ticket changes until ctx is done or
+// the returned close function is called.
+func (entity *orderTicketEntity) SubscribeOrderTickets(ctx context.Context) (<-chan entities.OrderTicket, func() error) {
+	pubsub := entity.rdb.Subscribe(ctx, ticketChannel)
+	tickets := make(chan entities.OrderTicket)
+	go func() {
+		defer close(tickets)
+		for msg := range pubsub.Channel() {
+			var ticket entities.OrderTicket
+			if err := json.Unmarshal([]byte(msg.Payload), &ticket); err != nil {
+				logrus.Error("invalid ticket message: ", err)
+				continue
+			}
+			select {
+			case tickets <- ticket:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return tickets, pubsub.Close
+}
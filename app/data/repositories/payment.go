@@ -20,15 +20,37 @@ type paymentEntity struct {
 type IPayment interface {
 	GetPaymentsBySessionId(sessionId primitive.ObjectID) ([]entities.Payment, error)
 	CreatePayment(payment entities.Payment) (entities.Payment, error)
-	DeletePayment(id primitive.ObjectID) error
+	GetPaymentById(id primitive.ObjectID) (entities.Payment, error)
 	GetPaymentsByDateRange(startDate, endDate time.Time) ([]entities.Payment, error)
 }
 
 func NewPaymentEntity(resource *db.Resource) IPayment {
 	col := resource.SnookDb.Collection("payments")
+	normalizePaymentTypes(col, "type")
 	return &paymentEntity{col: col}
 }
 
+// normalizePaymentTypes brings payment types stored before they were checked
+// against the payment method catalog into catalog form, so "cash" and "Cash"
+// count as CASH in reports and drawer totals. Types already upper-case are
+// not touched, so it only does work once.
+func normalizePaymentTypes(col *mongo.Collection, field string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	filter := bson.M{field: bson.M{"$type": "string", "$not": primitive.Regex{Pattern: "^[A-Z0-9_]*$"}}}
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		field: bson.M{"$toUpper": bson.M{"$trim": bson.M{"input": "$" + field}}},
+	}}}}
+	result, err := col.UpdateMany(ctx, filter, update)
+	if err != nil {
+		logrus.Error("failed to normalize "+col.Name()+" "+field+": ", err)
+		return
+	}
+	if result.ModifiedCount > 0 {
+		logrus.Info("normalized ", result.ModifiedCount, " ", col.Name(), " ", field, " values")
+	}
+}
+
 func (entity *paymentEntity) GetPaymentsBySessionId(sessionId primitive.ObjectID) ([]entities.Payment, error) {
 	logrus.Info("GetPaymentsBySessionId")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -55,12 +77,13 @@ func (entity *paymentEntity) CreatePayment(payment entities.Payment) (entities.P
 	return payment, err
 }
 
-func (entity *paymentEntity) DeletePayment(id primitive.ObjectID) error {
-	logrus.Info("DeletePayment")
+func (entity *paymentEntity) GetPaymentById(id primitive.ObjectID) (entities.Payment, error) {
+	logrus.Info("GetPaymentById")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	_, err := entity.col.DeleteOne(ctx, bson.M{"_id": id})
-	return err
+	var payment entities.Payment
+	err := entity.col.FindOne(ctx, bson.M{"_id": id}).Decode(&payment)
+	return payment, err
 }
 
 func (entity *paymentEntity) GetPaymentsByDateRange(startDate, endDate time.Time) ([]entities.Payment, error) {
@@ -0,0 +1,353 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"snook/app/data/entities"
+	"snook/db"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrPaymentIntentStatus   = errors.New("payment request is no longer pending")
+	ErrDuplicatePaymentEvent = errors.New("payment event was already received")
+)
+
+type paymentIntentEntity struct {
+	resource   *db.Resource
+	col        *mongo.Collection
+	paymentCol *mongo.Collection
+	eventCol   *mongo.Collection
+	sessionCol *mongo.Collection
+}
+
+type IPaymentIntent interface {
+	GetPaymentIntents(sessionId *primitive.ObjectID, status string) ([]entities.PaymentIntent, error)
+	GetPaymentIntentById(id primitive.ObjectID) (entities.PaymentIntent, error)
+	CreatePaymentIntent(intent entities.PaymentIntent) (entities.PaymentIntent, error)
+	ConfirmPaymentIntent(id primitive.ObjectID, userId string) (entities.Payment, error)
+	CancelPaymentIntent(id primitive.ObjectID, userId string) error
+	GetPaymentEvents(intentId primitive.ObjectID) ([]entities.PaymentEvent, error)
+	ApplyPaymentEvent(event entities.PaymentEvent) (entities.PaymentEvent, error)
+}
+
+func NewPaymentIntentEntity(resource *db.Resource) IPaymentIntent {
+	col := resource.SnookDb.Collection("payment_intents")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "sessionId", Value: 1}, {Key: "status", Value: 1}},
+	})
+	if err != nil {
+		logrus.Error("failed to create payment intent index: ", err)
+	}
+	_, err = col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "provider", Value: 1}, {Key: "providerRef", Value: 1}},
+		Options: options.Index().
+			SetPartialFilterExpression(bson.M{"providerRef": bson.M{"$type": "string"}}),
+	})
+	if err != nil {
+		logrus.Error("failed to create payment intent provider index: ", err)
+	}
+	eventCol := resource.SnookDb.Collection("payment_events")
+	_, err = eventCol.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "provider", Value: 1}, {Key: "eventId", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		logrus.Error("failed to create payment event index: ", err)
+	}
+	return &paymentIntentEntity{
+		resource:   resource,
+		col:        col,
+		paymentCol: resource.SnookDb.Collection("payments"),
+		eventCol:   eventCol,
+		sessionCol: resource.SnookDb.Collection("table_sessions"),
+	}
+}
+
+func (entity *paymentIntentEntity) GetPaymentIntents(sessionId *primitive.ObjectID, status string) ([]entities.PaymentIntent, error) {
+	logrus.Info("GetPaymentIntents")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	filter := bson.M{}
+	if sessionId != nil {
+		filter["sessionId"] = *sessionId
+	}
+	if status != "" {
+		filter["status"] = status
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "createdDate", Value: -1}})
+	cursor, err := entity.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var intents []entities.PaymentIntent
+	if err = cursor.All(ctx, &intents); err != nil {
+		return nil, err
+	}
+	return intents, nil
+}
+
+func (entity *paymentIntentEntity) GetPaymentIntentById(id primitive.ObjectID) (entities.PaymentIntent, error) {
+	logrus.Info("GetPaymentIntentById")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var intent entities.PaymentIntent
+	err := entity.col.FindOne(ctx, bson.M{"_id": id}).Decode(&intent)
+	return intent, err
+}
+
+// CreatePaymentIntent records a new request for the session. Pending
+// requests of the same method are cancelled, since the customer pays the
+// latest amount.
+func (entity *paymentIntentEntity) CreatePaymentIntent(intent entities.PaymentIntent) (entities.PaymentIntent, error) {
+	logrus.Info("CreatePaymentIntent")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	now := time.Now()
+	intent.Id = primitive.NewObjectID()
+	intent.Status = "PENDING"
+	intent.CreatedDate = now
+	intent.UpdatedBy = intent.CreatedBy
+	intent.UpdatedDate = now
+	err := entity.resource.WithTransaction(ctx, func(sc mongo.SessionContext) error {
+		_, err := entity.col.UpdateMany(sc, bson.M{
+			"sessionId": intent.SessionId, "method": intent.Method, "status": "PENDING",
+		}, bson.M{"$set": bson.M{"status": "CANCELLED", "updatedBy": intent.CreatedBy, "updatedDate": now}})
+		if err != nil {
+			return err
+		}
+		_, err = entity.col.InsertOne(sc, intent)
+		return err
+	})
+	return intent, err
+}
+
+// ConfirmPaymentIntent turns a pending request into a payment of its amount.
+func (entity *paymentIntentEntity) ConfirmPaymentIntent(id primitive.ObjectID, userId string) (entities.Payment, error) {
+	logrus.Info("ConfirmPaymentIntent")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var payment entities.Payment
+	err := entity.resource.WithTransaction(ctx, func(sc mongo.SessionContext) error {
+		var intent entities.PaymentIntent
+		if err := entity.col.FindOne(sc, bson.M{"_id": id}).Decode(&intent); err != nil {
+			return err
+		}
+		if intent.Status != "PENDING" {
+			return ErrPaymentIntentStatus
+		}
+		var err error
+		payment, err = entity.confirmIntent(sc, intent, userId)
+		return err
+	})
+	return payment, err
+}
+
+func (entity *paymentIntentEntity) CancelPaymentIntent(id primitive.ObjectID, userId string) error {
+	logrus.Info("CancelPaymentIntent")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	result, err := entity.col.UpdateOne(ctx, bson.M{"_id": id, "status": "PENDING"}, bson.M{"$set": bson.M{
+		"status":      "CANCELLED",
+		"updatedBy":   userId,
+		"updatedDate": time.Now(),
+	}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrPaymentIntentStatus
+	}
+	return nil
+}
+
+func (entity *paymentIntentEntity) GetPaymentEvents(intentId primitive.ObjectID) ([]entities.PaymentEvent, error) {
+	logrus.Info("GetPaymentEvents")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	opts := options.Find().SetSort(bson.D{{Key: "receivedDate", Value: 1}})
+	cursor, err := entity.eventCol.Find(ctx, bson.M{"intentId": intentId}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var events []entities.PaymentEvent
+	if err = cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// ApplyPaymentEvent records a gateway webhook and applies it to its intent
+// in one transaction. A delivery seen before fails with
+// ErrDuplicatePaymentEvent and changes nothing; a failed one leaves no trace,
+// so the gateway's retry is applied in full. A successful charge is booked
+// even when the intent was cancelled or failed meanwhile, since the money
+// has arrived, unless the session has been closed or paid by other means
+// since the request was made: the event is then kept, flagged for a refund,
+// so the bill is not overpaid. Booking a charge cancels the session's other
+// pending requests, as the balance they asked for is gone.
+func (entity *paymentIntentEntity) ApplyPaymentEvent(event entities.PaymentEvent) (entities.PaymentEvent, error) {
+	logrus.Info("ApplyPaymentEvent")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	result := event
+	err := entity.resource.WithTransaction(ctx, func(sc mongo.SessionContext) error {
+		result = event
+		result.Id = primitive.NewObjectID()
+		result.ReceivedDate = time.Now()
+		result.Result = "IGNORED"
+		var intent entities.PaymentIntent
+		err := entity.col.FindOne(sc, bson.M{"provider": event.Provider, "providerRef": event.ProviderRef}).Decode(&intent)
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			result.Note = "unknown charge"
+		case err != nil:
+			return err
+		default:
+			result.IntentId = &intent.Id
+			if err := entity.applyEvent(sc, intent, &result); err != nil {
+				return err
+			}
+		}
+		_, err = entity.eventCol.InsertOne(sc, result)
+		return err
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return event, ErrDuplicatePaymentEvent
+	}
+	return result, err
+}
+
+func (entity *paymentIntentEntity) applyEvent(ctx context.Context, intent entities.PaymentIntent, event *entities.PaymentEvent) error {
+	switch event.Type {
+	case "charge.succeeded":
+		if intent.Status == "CONFIRMED" {
+			event.Note = "intent already confirmed"
+			return nil
+		}
+		var session entities.TableSession
+		if err := entity.sessionCol.FindOne(ctx, bson.M{"_id": intent.SessionId}).Decode(&session); err != nil {
+			return err
+		}
+		if session.Status == "CLOSED" {
+			event.NeedsRefund = true
+			event.Note = "session already closed, the charge needs a refund"
+			return nil
+		}
+		if math.Abs(event.Amount-intent.Amount) >= 0.005 {
+			event.Note = fmt.Sprintf("amount %.2f does not match the intent amount %.2f", event.Amount, intent.Amount)
+			return nil
+		}
+		if intent.Status != "PENDING" {
+			paid, err := entity.paidSince(ctx, intent.SessionId, intent.CreatedDate)
+			if err != nil {
+				return err
+			}
+			if paid >= 0.005 {
+				event.NeedsRefund = true
+				event.Note = fmt.Sprintf("%.2f was paid after this request, the charge needs a refund", paid)
+				return nil
+			}
+		}
+		payment, err := entity.confirmIntent(ctx, intent, event.Provider)
+		if err != nil {
+			return err
+		}
+		_, err = entity.col.UpdateMany(ctx,
+			bson.M{"sessionId": intent.SessionId, "status": "PENDING", "_id": bson.M{"$ne": intent.Id}},
+			bson.M{"$set": bson.M{"status": "CANCELLED", "updatedBy": event.Provider, "updatedDate": time.Now()}})
+		if err != nil {
+			return err
+		}
+		event.PaymentId = &payment.Id
+	case "charge.failed":
+		if intent.Status != "PENDING" {
+			event.Note = "intent is " + intent.Status
+			return nil
+		}
+		_, err := entity.col.UpdateOne(ctx, bson.M{"_id": intent.Id}, bson.M{"$set": bson.M{
+			"status":      "FAILED",
+			"updatedBy":   event.Provider,
+			"updatedDate": time.Now(),
+		}})
+		if err != nil {
+			return err
+		}
+	default:
+		event.Note = "unhandled event type"
+		return nil
+	}
+	event.Result = "APPLIED"
+	return nil
+}
+
+// paidSince sums the payments booked on the session from since on. A request
+// is made for the balance at the time, so anything paid later is taken off it.
+func (entity *paymentIntentEntity) paidSince(ctx context.Context, sessionId primitive.ObjectID, since time.Time) (float64, error) {
+	cursor, err := entity.paymentCol.Find(ctx, bson.M{"sessionId": sessionId, "createdDate": bson.M{"$gte": since}})
+	if err != nil {
+		return 0, err
+	}
+	var payments []entities.Payment
+	if err = cursor.All(ctx, &payments); err != nil {
+		return 0, err
+	}
+	paid := 0.0
+	for _, p := range payments {
+		paid += p.Amount
+	}
+	return math.Round(paid*100) / 100, nil
+}
+
+// confirmIntent books the payment of an intent and marks it confirmed.
+// PromptPay requests are booked as QR payments, gateway charges under their
+// method.
+func (entity *paymentIntentEntity) confirmIntent(ctx context.Context, intent entities.PaymentIntent, userId string) (entities.Payment, error) {
+	now := time.Now()
+	paymentType := intent.Method
+	if paymentType == "PROMPTPAY" {
+		paymentType = "QR"
+	}
+	payment := entities.Payment{
+		Id:          primitive.NewObjectID(),
+		SessionId:   intent.SessionId,
+		Type:        paymentType,
+		Amount:      intent.Amount,
+		Fee:         intent.Fee,
+		Note:        intent.Note,
+		IntentId:    &intent.Id,
+		Provider:    intent.Provider,
+		Reference:   intent.ProviderRef,
+		Status:      "CONFIRMED",
+		CreatedBy:   userId,
+		CreatedDate: now,
+	}
+	if _, err := entity.paymentCol.InsertOne(ctx, payment); err != nil {
+		return payment, err
+	}
+	result, err := entity.col.UpdateOne(ctx, bson.M{"_id": intent.Id, "status": intent.Status}, bson.M{"$set": bson.M{
+		"status":        "CONFIRMED",
+		"paymentId":     payment.Id,
+		"confirmedBy":   userId,
+		"confirmedDate": now,
+		"updatedBy":     userId,
+		"updatedDate":   now,
+	}})
+	if err != nil {
+		return payment, err
+	}
+	if result.MatchedCount == 0 {
+		return payment, ErrPaymentIntentStatus
+	}
+	return payment, nil
+}
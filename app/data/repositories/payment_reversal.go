@@ -0,0 +1,229 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"math"
+	"snook/app/data/entities"
+	"snook/db"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrPaymentReversalStatus = errors.New("payment reversal is no longer pending")
+	ErrReversalExceedsAmount = errors.New("reversal exceeds the amount left on the payment")
+)
+
+type paymentReversalEntity struct {
+	resource   *db.Resource
+	col        *mongo.Collection
+	paymentCol *mongo.Collection
+}
+
+type IPaymentReversal interface {
+	GetPaymentReversals(paymentId *primitive.ObjectID, status string) ([]entities.PaymentReversal, error)
+	GetPaymentReversalById(id primitive.ObjectID) (entities.PaymentReversal, error)
+	GetReversibleAmount(payment entities.Payment) (float64, error)
+	CreatePaymentReversal(reversal entities.PaymentReversal) (entities.PaymentReversal, error)
+	ApprovePaymentReversal(id primitive.ObjectID, userId, paidOutBy, note string) (entities.Payment, error)
+	RejectPaymentReversal(id primitive.ObjectID, userId, note string) error
+}
+
+func NewPaymentReversalEntity(resource *db.Resource) IPaymentReversal {
+	col := resource.SnookDb.Collection("payment_reversals")
+	paymentCol := resource.SnookDb.Collection("payments")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "paymentId", Value: 1}, {Key: "status", Value: 1}},
+	})
+	if err != nil {
+		logrus.Error("failed to create payment reversal index: ", err)
+	}
+	_, err = paymentCol.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "reversalOf", Value: 1}},
+		Options: options.Index().
+			SetPartialFilterExpression(bson.M{"reversalOf": bson.M{"$type": "objectId"}}),
+	})
+	if err != nil {
+		logrus.Error("failed to create payment reversal entry index: ", err)
+	}
+	return &paymentReversalEntity{resource: resource, col: col, paymentCol: paymentCol}
+}
+
+func (entity *paymentReversalEntity) GetPaymentReversals(paymentId *primitive.ObjectID, status string) ([]entities.PaymentReversal, error) {
+	logrus.Info("GetPaymentReversals")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	filter := bson.M{}
+	if paymentId != nil {
+		filter["paymentId"] = *paymentId
+	}
+	if status != "" {
+		filter["status"] = status
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "createdDate", Value: -1}})
+	cursor, err := entity.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var reversals []entities.PaymentReversal
+	if err = cursor.All(ctx, &reversals); err != nil {
+		return nil, err
+	}
+	return reversals, nil
+}
+
+func (entity *paymentReversalEntity) GetPaymentReversalById(id primitive.ObjectID) (entities.PaymentReversal, error) {
+	logrus.Info("GetPaymentReversalById")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var reversal entities.PaymentReversal
+	err := entity.col.FindOne(ctx, bson.M{"_id": id}).Decode(&reversal)
+	return reversal, err
+}
+
+// GetReversibleAmount returns what is left of the payment after approved and
+// pending reversals.
+func (entity *paymentReversalEntity) GetReversibleAmount(payment entities.Payment) (float64, error) {
+	logrus.Info("GetReversibleAmount")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return entity.reversibleAmount(ctx, payment)
+}
+
+func (entity *paymentReversalEntity) CreatePaymentReversal(reversal entities.PaymentReversal) (entities.PaymentReversal, error) {
+	logrus.Info("CreatePaymentReversal")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	reversal.Id = primitive.NewObjectID()
+	reversal.Status = "PENDING"
+	reversal.CreatedDate = time.Now()
+	_, err := entity.col.InsertOne(ctx, reversal)
+	return reversal, err
+}
+
+// ApprovePaymentReversal books the negative payment of a pending reversal.
+// The amount is checked again against the original payment inside the
+// transaction, so two approvals can never reverse more than was paid. The
+// entry is counted in the drawer of paidOutBy, or of the cashier who asked
+// for the reversal when it is empty, rather than the approver's.
+func (entity *paymentReversalEntity) ApprovePaymentReversal(id primitive.ObjectID, userId, paidOutBy, note string) (entities.Payment, error) {
+	logrus.Info("ApprovePaymentReversal")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var entry entities.Payment
+	err := entity.resource.WithTransaction(ctx, func(sc mongo.SessionContext) error {
+		var reversal entities.PaymentReversal
+		if err := entity.col.FindOne(sc, bson.M{"_id": id}).Decode(&reversal); err != nil {
+			return err
+		}
+		if reversal.Status != "PENDING" {
+			return ErrPaymentReversalStatus
+		}
+		var payment entities.Payment
+		if err := entity.paymentCol.FindOne(sc, bson.M{"_id": reversal.PaymentId}).Decode(&payment); err != nil {
+			return err
+		}
+		reversed, err := entity.reversedAmount(sc, payment.Id)
+		if err != nil {
+			return err
+		}
+		if math.Round((payment.Amount-reversed-reversal.Amount)*100) < 0 {
+			return ErrReversalExceedsAmount
+		}
+		now := time.Now()
+		paymentId, reversalId := payment.Id, reversal.Id
+		cashier := paidOutBy
+		if cashier == "" {
+			cashier = reversal.CreatedBy
+		}
+		entry = entities.Payment{
+			Id: primitive.NewObjectID(), SessionId: payment.SessionId, Type: payment.Type,
+			Amount: -reversal.Amount, Note: reversal.Type + ": " + reversal.Reason,
+			Provider: payment.Provider, Reference: payment.Reference, Status: reversal.Type,
+			ReversalOf: &paymentId, ReversalId: &reversalId, PaidOutBy: cashier,
+			CreatedBy: userId, CreatedDate: now,
+		}
+		if _, err := entity.paymentCol.InsertOne(sc, entry); err != nil {
+			return err
+		}
+		result, err := entity.col.UpdateOne(sc, bson.M{"_id": id, "status": "PENDING"}, bson.M{"$set": bson.M{
+			"status":       "APPROVED",
+			"entryId":      entry.Id,
+			"paidOutBy":    cashier,
+			"reviewNote":   note,
+			"reviewedBy":   userId,
+			"reviewedDate": now,
+		}})
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return ErrPaymentReversalStatus
+		}
+		return nil
+	})
+	return entry, err
+}
+
+func (entity *paymentReversalEntity) RejectPaymentReversal(id primitive.ObjectID, userId, note string) error {
+	logrus.Info("RejectPaymentReversal")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	result, err := entity.col.UpdateOne(ctx, bson.M{"_id": id, "status": "PENDING"}, bson.M{"$set": bson.M{
+		"status":       "REJECTED",
+		"reviewNote":   note,
+		"reviewedBy":   userId,
+		"reviewedDate": time.Now(),
+	}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrPaymentReversalStatus
+	}
+	return nil
+}
+
+func (entity *paymentReversalEntity) reversibleAmount(ctx context.Context, payment entities.Payment) (float64, error) {
+	reversed, err := entity.reversedAmount(ctx, payment.Id)
+	if err != nil {
+		return 0, err
+	}
+	cursor, err := entity.col.Find(ctx, bson.M{"paymentId": payment.Id, "status": "PENDING"})
+	if err != nil {
+		return 0, err
+	}
+	var pending []entities.PaymentReversal
+	if err = cursor.All(ctx, &pending); err != nil {
+		return 0, err
+	}
+	for _, r := range pending {
+		reversed += r.Amount
+	}
+	return math.Round((payment.Amount-reversed)*100) / 100, nil
+}
+
+// reversedAmount sums the negative entries already booked against a payment.
+func (entity *paymentReversalEntity) reversedAmount(ctx context.Context, paymentId primitive.ObjectID) (float64, error) {
+	cursor, err := entity.paymentCol.Find(ctx, bson.M{"reversalOf": paymentId})
+	if err != nil {
+		return 0, err
+	}
+	var entries []entities.Payment
+	if err = cursor.All(ctx, &entries); err != nil {
+		return 0, err
+	}
+	reversed := 0.0
+	for _, e := range entries {
+		reversed -= e.Amount
+	}
+	return reversed, nil
+}
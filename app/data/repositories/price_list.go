@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"context"
+	"snook/app/data/entities"
+	"snook/db"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type priceListEntity struct {
+	col *mongo.Collection
+}
+
+type IPriceList interface {
+	GetPriceLists() ([]entities.PriceList, error)
+	GetPriceListById(id primitive.ObjectID) (entities.PriceList, error)
+	GetCurrentPriceLists(now time.Time) ([]entities.PriceList, error)
+	CreatePriceList(list entities.PriceList) (entities.PriceList, error)
+	UpdatePriceListById(id primitive.ObjectID, list entities.PriceList) error
+	DeletePriceListById(id primitive.ObjectID) error
+}
+
+func NewPriceListEntity(resource *db.Resource) IPriceList {
+	col := resource.SnookDb.Collection("price_lists")
+	return &priceListEntity{col: col}
+}
+
+func (entity *priceListEntity) GetPriceLists() ([]entities.PriceList, error) {
+	logrus.Info("GetPriceLists")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	opts := options.Find().SetSort(bson.D{{Key: "priority", Value: -1}, {Key: "createdDate", Value: -1}})
+	cursor, err := entity.col.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	lists := []entities.PriceList{}
+	if err = cursor.All(ctx, &lists); err != nil {
+		return nil, err
+	}
+	return lists, nil
+}
+
+func (entity *priceListEntity) GetPriceListById(id primitive.ObjectID) (entities.PriceList, error) {
+	logrus.Info("GetPriceListById")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var list entities.PriceList
+	err := entity.col.FindOne(ctx, bson.M{"_id": id}).Decode(&list)
+	return list, err
+}
+
+// GetCurrentPriceLists returns the active lists whose date range covers now.
+// Days of week and time windows are left to the caller.
+func (entity *priceListEntity) GetCurrentPriceLists(now time.Time) ([]entities.PriceList, error) {
+	logrus.Info("GetCurrentPriceLists")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	filter := bson.M{
+		"status": "ACTIVE",
+		"$and": []bson.M{
+			{"$or": []bson.M{{"startDate": bson.M{"$exists": false}}, {"startDate": bson.M{"$lte": now}}}},
+			{"$or": []bson.M{{"endDate": bson.M{"$exists": false}}, {"endDate": bson.M{"$gte": now}}}},
+		},
+	}
+	cursor, err := entity.col.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	lists := []entities.PriceList{}
+	if err = cursor.All(ctx, &lists); err != nil {
+		return nil, err
+	}
+	return lists, nil
+}
+
+func (entity *priceListEntity) CreatePriceList(list entities.PriceList) (entities.PriceList, error) {
+	logrus.Info("CreatePriceList")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	list.Id = primitive.NewObjectID()
+	list.CreatedDate = time.Now()
+	list.UpdatedBy = list.CreatedBy
+	list.UpdatedDate = list.CreatedDate
+	_, err := entity.col.InsertOne(ctx, list)
+	return list, err
+}
+
+func (entity *priceListEntity) UpdatePriceListById(id primitive.ObjectID, list entities.PriceList) error {
+	logrus.Info("UpdatePriceListById")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	list.UpdatedDate = time.Now()
+	set := bson.M{
+		"name":           list.Name,
+		"daysOfWeek":     list.DaysOfWeek,
+		"startTimeOfDay": list.StartTimeOfDay,
+		"endTimeOfDay":   list.EndTimeOfDay,
+		"priority":       list.Priority,
+		"items":          list.Items,
+		"status":         list.Status,
+		"updatedBy":      list.UpdatedBy,
+		"updatedDate":    list.UpdatedDate,
+	}
+	unset := bson.M{}
+	if list.StartDate != nil {
+		set["startDate"] = list.StartDate
+	} else {
+		unset["startDate"] = ""
+	}
+	if list.EndDate != nil {
+		set["endDate"] = list.EndDate
+	} else {
+		unset["endDate"] = ""
+	}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	_, err := entity.col.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+func (entity *priceListEntity) DeletePriceListById(id primitive.ObjectID) error {
+	logrus.Info("DeletePriceListById")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := entity.col.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
@@ -21,6 +21,7 @@ type IPromotion interface {
 	GetPromotions() ([]entities.Promotion, error)
 	GetPromotionById(id primitive.ObjectID) (entities.Promotion, error)
 	GetActivePromotions(tableType string) ([]entities.Promotion, error)
+	GetAutoApplyPromotions() ([]entities.Promotion, error)
 	CreatePromotion(promo entities.Promotion) (entities.Promotion, error)
 	UpdatePromotionById(id primitive.ObjectID, promo entities.Promotion) error
 	DeletePromotionById(id primitive.ObjectID) error
@@ -85,6 +86,21 @@ func (entity *promotionEntity) GetActivePromotions(tableType string) ([]entities
 	return promos, nil
 }
 
+func (entity *promotionEntity) GetAutoApplyPromotions() ([]entities.Promotion, error) {
+	logrus.Info("GetAutoApplyPromotions")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cursor, err := entity.col.Find(ctx, bson.M{"status": "ACTIVE", "autoApply": true})
+	if err != nil {
+		return nil, err
+	}
+	var promos []entities.Promotion
+	if err = cursor.All(ctx, &promos); err != nil {
+		return nil, err
+	}
+	return promos, nil
+}
+
 func (entity *promotionEntity) CreatePromotion(promo entities.Promotion) (entities.Promotion, error) {
 	logrus.Info("CreatePromotion")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -102,19 +118,35 @@ func (entity *promotionEntity) UpdatePromotionById(id primitive.ObjectID, promo
 	defer cancel()
 	promo.UpdatedDate = time.Now()
 	_, err := entity.col.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
-		"name":        promo.Name,
-		"description": promo.Description,
-		"type":        promo.Type,
-		"playHours":   promo.PlayHours,
-		"freeHours":   promo.FreeHours,
-		"discountPct": promo.DiscountPct,
-		"discountAmt": promo.DiscountAmt,
-		"tableTypes":  promo.TableTypes,
-		"startDate":   promo.StartDate,
-		"endDate":     promo.EndDate,
-		"status":      promo.Status,
-		"updatedBy":   promo.UpdatedBy,
-		"updatedDate": promo.UpdatedDate,
+		"name":               promo.Name,
+		"description":        promo.Description,
+		"type":               promo.Type,
+		"playHours":          promo.PlayHours,
+		"freeHours":          promo.FreeHours,
+		"discountPct":        promo.DiscountPct,
+		"discountAmt":        promo.DiscountAmt,
+		"tableTypes":         promo.TableTypes,
+		"startDate":          promo.StartDate,
+		"endDate":            promo.EndDate,
+		"daysOfWeek":         promo.DaysOfWeek,
+		"startTimeOfDay":     promo.StartTimeOfDay,
+		"endTimeOfDay":       promo.EndTimeOfDay,
+		"memberOnly":         promo.MemberOnly,
+		"minSpend":           promo.MinSpend,
+		"maxUses":            promo.MaxUses,
+		"maxUsesPerCustomer": promo.MaxUsesPerCustomer,
+		"priority":           promo.Priority,
+		"stackable":          promo.Stackable,
+		"autoApply":          promo.AutoApply,
+		"menuItemId":         promo.MenuItemId,
+		"buyQuantity":        promo.BuyQuantity,
+		"getQuantity":        promo.GetQuantity,
+		"category":           promo.Category,
+		"bundlePrice":        promo.BundlePrice,
+		"bundleItems":        promo.BundleItems,
+		"status":             promo.Status,
+		"updatedBy":          promo.UpdatedBy,
+		"updatedDate":        promo.UpdatedDate,
 	}})
 	return err
 }
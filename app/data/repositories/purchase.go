@@ -0,0 +1,330 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"snook/app/data/entities"
+	"snook/db"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrPurchaseOrderNotReceivable = errors.New("purchase order cannot be received")
+	ErrPurchaseOrderNotEditable   = errors.New("purchase order is no longer draft or ordered")
+)
+
+type purchaseEntity struct {
+	resource    *db.Resource
+	supplierCol *mongo.Collection
+	col         *mongo.Collection
+	itemCol     *mongo.Collection
+	movementCol *mongo.Collection
+	expenseCol  *mongo.Collection
+}
+
+type IPurchase interface {
+	GetSuppliers(status string) ([]entities.Supplier, error)
+	GetSupplierById(id primitive.ObjectID) (entities.Supplier, error)
+	CreateSupplier(supplier entities.Supplier) (entities.Supplier, error)
+	UpdateSupplierById(id primitive.ObjectID, supplier entities.Supplier) error
+	GetPurchaseOrders(status string, supplierId *primitive.ObjectID) ([]entities.PurchaseOrder, error)
+	GetPurchaseOrderById(id primitive.ObjectID) (entities.PurchaseOrder, error)
+	CreatePurchaseOrder(po entities.PurchaseOrder) (entities.PurchaseOrder, error)
+	UpdatePurchaseOrderById(id primitive.ObjectID, po entities.PurchaseOrder) error
+	ReceivePurchaseOrder(id primitive.ObjectID, items []entities.PurchaseOrderItem, userId string) (entities.GoodsReceipt, error)
+}
+
+func NewPurchaseEntity(resource *db.Resource) IPurchase {
+	col := resource.SnookDb.Collection("purchase_orders")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "poNumber", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		logrus.Error("failed to create purchase order number index: ", err)
+	}
+	return &purchaseEntity{
+		resource:    resource,
+		supplierCol: resource.SnookDb.Collection("suppliers"),
+		col:         col,
+		itemCol:     resource.SnookDb.Collection("menu_items"),
+		movementCol: resource.SnookDb.Collection("stock_movements"),
+		expenseCol:  resource.SnookDb.Collection("expenses"),
+	}
+}
+
+func (entity *purchaseEntity) GetSuppliers(status string) ([]entities.Supplier, error) {
+	logrus.Info("GetSuppliers")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := entity.supplierCol.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var suppliers []entities.Supplier
+	if err = cursor.All(ctx, &suppliers); err != nil {
+		return nil, err
+	}
+	return suppliers, nil
+}
+
+func (entity *purchaseEntity) GetSupplierById(id primitive.ObjectID) (entities.Supplier, error) {
+	logrus.Info("GetSupplierById")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var supplier entities.Supplier
+	err := entity.supplierCol.FindOne(ctx, bson.M{"_id": id}).Decode(&supplier)
+	return supplier, err
+}
+
+func (entity *purchaseEntity) CreateSupplier(supplier entities.Supplier) (entities.Supplier, error) {
+	logrus.Info("CreateSupplier")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	supplier.Id = primitive.NewObjectID()
+	supplier.CreatedDate = time.Now()
+	supplier.UpdatedDate = time.Now()
+	_, err := entity.supplierCol.InsertOne(ctx, supplier)
+	return supplier, err
+}
+
+func (entity *purchaseEntity) UpdateSupplierById(id primitive.ObjectID, supplier entities.Supplier) error {
+	logrus.Info("UpdateSupplierById")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	supplier.UpdatedDate = time.Now()
+	_, err := entity.supplierCol.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"name":        supplier.Name,
+		"contactName": supplier.ContactName,
+		"phone":       supplier.Phone,
+		"email":       supplier.Email,
+		"address":     supplier.Address,
+		"taxId":       supplier.TaxId,
+		"note":        supplier.Note,
+		"status":      supplier.Status,
+		"updatedBy":   supplier.UpdatedBy,
+		"updatedDate": supplier.UpdatedDate,
+	}})
+	return err
+}
+
+func (entity *purchaseEntity) GetPurchaseOrders(status string, supplierId *primitive.ObjectID) ([]entities.PurchaseOrder, error) {
+	logrus.Info("GetPurchaseOrders")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	if supplierId != nil {
+		filter["supplierId"] = *supplierId
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "createdDate", Value: -1}})
+	cursor, err := entity.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var orders []entities.PurchaseOrder
+	if err = cursor.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (entity *purchaseEntity) GetPurchaseOrderById(id primitive.ObjectID) (entities.PurchaseOrder, error) {
+	logrus.Info("GetPurchaseOrderById")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var po entities.PurchaseOrder
+	err := entity.col.FindOne(ctx, bson.M{"_id": id}).Decode(&po)
+	return po, err
+}
+
+// CreatePurchaseOrder numbers the order after the orders of the day. The
+// number is unique, so an order created at the same time takes the next one.
+func (entity *purchaseEntity) CreatePurchaseOrder(po entities.PurchaseOrder) (entities.PurchaseOrder, error) {
+	logrus.Info("CreatePurchaseOrder")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	po.Id = primitive.NewObjectID()
+	po.CreatedDate = now
+	po.UpdatedDate = now
+	var err error
+	for attempt := 0; attempt < 5; attempt++ {
+		var count int64
+		count, err = entity.col.CountDocuments(ctx, bson.M{"createdDate": bson.M{"$gte": start}})
+		if err != nil {
+			return po, err
+		}
+		po.PoNumber = fmt.Sprintf("PO-%s-%03d", now.Format("20060102"), count+1+int64(attempt))
+		_, err = entity.col.InsertOne(ctx, po)
+		if !mongo.IsDuplicateKeyError(err) {
+			return po, err
+		}
+	}
+	return po, err
+}
+
+// UpdatePurchaseOrderById edits or cancels an order. Only draft and ordered
+// orders can change; one received in the meantime fails with
+// ErrPurchaseOrderNotEditable.
+func (entity *purchaseEntity) UpdatePurchaseOrderById(id primitive.ObjectID, po entities.PurchaseOrder) error {
+	logrus.Info("UpdatePurchaseOrderById")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	po.UpdatedDate = time.Now()
+	filter := bson.M{"_id": id, "status": bson.M{"$in": []string{"DRAFT", "ORDERED"}}}
+	result, err := entity.col.UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+		"supplierId":   po.SupplierId,
+		"supplierName": po.SupplierName,
+		"status":       po.Status,
+		"items":        po.Items,
+		"total":        po.Total,
+		"expectedDate": po.ExpectedDate,
+		"note":         po.Note,
+		"updatedBy":    po.UpdatedBy,
+		"updatedDate":  po.UpdatedDate,
+	}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrPurchaseOrderNotEditable
+	}
+	return nil
+}
+
+// ReceivePurchaseOrder books a delivery against the order in one transaction:
+// stock goes up through PURCHASE movements, each item's cost price becomes
+// the weighted average of the stock on hand and the delivery, and the
+// delivered amount is recorded as an expense.
+func (entity *purchaseEntity) ReceivePurchaseOrder(id primitive.ObjectID, items []entities.PurchaseOrderItem, userId string) (entities.GoodsReceipt, error) {
+	logrus.Info("ReceivePurchaseOrder")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	var receipt entities.GoodsReceipt
+	err := entity.resource.WithTransaction(ctx, func(sc mongo.SessionContext) error {
+		receipt = entities.GoodsReceipt{}
+		var po entities.PurchaseOrder
+		if err := entity.col.FindOne(sc, bson.M{"_id": id}).Decode(&po); err != nil {
+			return err
+		}
+		if po.Status != "ORDERED" && po.Status != "PARTIAL" {
+			return ErrPurchaseOrderNotReceivable
+		}
+		now := time.Now()
+		amount := 0.0
+		for _, received := range items {
+			index := -1
+			for i, line := range po.Items {
+				if line.MenuItemId == received.MenuItemId {
+					index = i
+					break
+				}
+			}
+			if index < 0 {
+				return fmt.Errorf("item %s is not on this purchase order", received.MenuItemId.Hex())
+			}
+			line := &po.Items[index]
+			if received.Quantity <= 0 || line.ReceivedQuantity+received.Quantity > line.Quantity {
+				return fmt.Errorf("received quantity for %s exceeds the ordered quantity", line.Name)
+			}
+			unitCost := line.UnitCost
+			if received.UnitCost > 0 {
+				unitCost = received.UnitCost
+			}
+			movement, err := entity.receiveStock(sc, entities.StockMovement{
+				MenuItemId: line.MenuItemId, Type: "PURCHASE", Quantity: received.Quantity,
+				UnitCost: unitCost, RefType: "PURCHASE_ORDER", RefId: &po.Id,
+				Reason: po.PoNumber, CreatedBy: userId,
+			})
+			if err != nil {
+				return err
+			}
+			line.ReceivedUnitCost = weightedAverageCost(float64(line.ReceivedQuantity), line.ReceivedUnitCost, float64(received.Quantity), unitCost)
+			line.ReceivedQuantity += received.Quantity
+			amount += float64(received.Quantity) * unitCost
+			receipt.Movements = append(receipt.Movements, movement)
+		}
+		expense := entities.Expense{
+			Id:          primitive.NewObjectID(),
+			Category:    "PURCHASE",
+			Description: po.PoNumber + " " + po.SupplierName,
+			Amount:      math.Round(amount*100) / 100,
+			Date:        now,
+			CreatedBy:   userId,
+			CreatedDate: now,
+			UpdatedBy:   userId,
+			UpdatedDate: now,
+		}
+		if _, err := entity.expenseCol.InsertOne(sc, expense); err != nil {
+			return err
+		}
+		po.Status = "RECEIVED"
+		for _, line := range po.Items {
+			if line.ReceivedQuantity < line.Quantity {
+				po.Status = "PARTIAL"
+			}
+		}
+		po.ReceivedDate = &now
+		po.ExpenseIds = append(po.ExpenseIds, expense.Id)
+		po.UpdatedBy = userId
+		po.UpdatedDate = now
+		_, err := entity.col.UpdateOne(sc, bson.M{"_id": po.Id}, bson.M{"$set": bson.M{
+			"items":        po.Items,
+			"status":       po.Status,
+			"receivedDate": po.ReceivedDate,
+			"expenseIds":   po.ExpenseIds,
+			"updatedBy":    po.UpdatedBy,
+			"updatedDate":  po.UpdatedDate,
+		}})
+		receipt.PurchaseOrder = po
+		receipt.Expense = expense
+		return err
+	})
+	return receipt, err
+}
+
+func (entity *purchaseEntity) receiveStock(ctx context.Context, movement entities.StockMovement) (entities.StockMovement, error) {
+	var item entities.MenuItem
+	if err := entity.itemCol.FindOne(ctx, bson.M{"_id": movement.MenuItemId}).Decode(&item); err != nil {
+		return movement, err
+	}
+	item.CostPrice = weightedAverageCost(float64(item.Quantity), item.CostPrice, float64(movement.Quantity), movement.UnitCost)
+	item.Quantity += movement.Quantity
+	_, err := entity.itemCol.UpdateOne(ctx, bson.M{"_id": item.Id}, bson.M{
+		"$inc": bson.M{"quantity": movement.Quantity},
+		"$set": bson.M{"costPrice": item.CostPrice, "updatedDate": time.Now()},
+	})
+	if err != nil {
+		return movement, err
+	}
+	return insertStockMovement(ctx, entity.movementCol, movement, item)
+}
+
+// weightedAverageCost values the stock on hand and the received quantity
+// together. Stock at or below zero carries no value, so the new cost is the
+// received unit cost.
+func weightedAverageCost(onHand, cost, received, unitCost float64) float64 {
+	if onHand <= 0 {
+		return unitCost
+	}
+	return math.Round((onHand*cost+received*unitCost)/(onHand+received)*1e4) / 1e4
+}
@@ -20,6 +20,9 @@ type settingEntity struct {
 type ISetting interface {
 	GetSetting() (entities.Setting, error)
 	UpsertSetting(setting entities.Setting) error
+	UpdateLoyaltySetting(loyalty entities.LoyaltySetting, updatedBy string) error
+	UpdatePaymentMethods(methods []entities.PaymentMethod, updatedBy string) error
+	UpdateCutoverHour(hour int, updatedBy string) error
 }
 
 func NewSettingEntity(resource *db.Resource) ISetting {
@@ -54,3 +57,42 @@ func (entity *settingEntity) UpsertSetting(setting entities.Setting) error {
 	}, "$setOnInsert": bson.M{"_id": primitive.NewObjectID()}}, opts)
 	return err
 }
+
+func (entity *settingEntity) UpdateLoyaltySetting(loyalty entities.LoyaltySetting, updatedBy string) error {
+	logrus.Info("UpdateLoyaltySetting")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	opts := options.Update().SetUpsert(true)
+	_, err := entity.col.UpdateOne(ctx, bson.M{}, bson.M{"$set": bson.M{
+		"loyalty":     loyalty,
+		"updatedBy":   updatedBy,
+		"updatedDate": time.Now(),
+	}, "$setOnInsert": bson.M{"_id": primitive.NewObjectID()}}, opts)
+	return err
+}
+
+func (entity *settingEntity) UpdatePaymentMethods(methods []entities.PaymentMethod, updatedBy string) error {
+	logrus.Info("UpdatePaymentMethods")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	opts := options.Update().SetUpsert(true)
+	_, err := entity.col.UpdateOne(ctx, bson.M{}, bson.M{"$set": bson.M{
+		"paymentMethods": methods,
+		"updatedBy":      updatedBy,
+		"updatedDate":    time.Now(),
+	}, "$setOnInsert": bson.M{"_id": primitive.NewObjectID()}}, opts)
+	return err
+}
+
+func (entity *settingEntity) UpdateCutoverHour(hour int, updatedBy string) error {
+	logrus.Info("UpdateCutoverHour")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	opts := options.Update().SetUpsert(true)
+	_, err := entity.col.UpdateOne(ctx, bson.M{}, bson.M{"$set": bson.M{
+		"cutoverHour": hour,
+		"updatedBy":   updatedBy,
+		"updatedDate": time.Now(),
+	}, "$setOnInsert": bson.M{"_id": primitive.NewObjectID()}}, opts)
+	return err
+}
@@ -0,0 +1,257 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"snook/app/data/entities"
+	"snook/db"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrStockTakeInProgress = errors.New("another stock take is still in progress")
+	ErrStockTakeStatus     = errors.New("stock take is not in the required status")
+)
+
+type stockTakeEntity struct {
+	resource    *db.Resource
+	col         *mongo.Collection
+	itemCol     *mongo.Collection
+	movementCol *mongo.Collection
+}
+
+type IStockTake interface {
+	GetStockTakes(status string) ([]entities.StockTake, error)
+	GetStockTakeById(id primitive.ObjectID) (entities.StockTake, error)
+	StartStockTake(take entities.StockTake) (entities.StockTake, error)
+	RecordStockTakeCounts(id primitive.ObjectID, counts map[primitive.ObjectID]int, userId string) (entities.StockTake, error)
+	UpdateStockTakeStatus(id primitive.ObjectID, from, to, userId string) error
+	ApproveStockTake(id primitive.ObjectID, userId string) (entities.StockTake, error)
+}
+
+func NewStockTakeEntity(resource *db.Resource) IStockTake {
+	col := resource.SnookDb.Collection("stock_takes")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// Open and submitted counts carry inProgress, so the unique index lets
+	// only one of them exist at a time.
+	_, err := col.UpdateMany(ctx,
+		bson.M{"status": bson.M{"$in": []string{"OPEN", "SUBMITTED"}}, "inProgress": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"inProgress": true}})
+	if err != nil {
+		logrus.Error("failed to mark stock takes in progress: ", err)
+	}
+	_, err = col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "inProgress", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"inProgress": true}),
+	})
+	if err != nil {
+		logrus.Error("failed to create stock take index: ", err)
+	}
+	return &stockTakeEntity{
+		resource:    resource,
+		col:         col,
+		itemCol:     resource.SnookDb.Collection("menu_items"),
+		movementCol: resource.SnookDb.Collection("stock_movements"),
+	}
+}
+
+func (entity *stockTakeEntity) GetStockTakes(status string) ([]entities.StockTake, error) {
+	logrus.Info("GetStockTakes")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "createdDate", Value: -1}}).SetProjection(bson.M{"lines": 0})
+	cursor, err := entity.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	takes := []entities.StockTake{}
+	if err = cursor.All(ctx, &takes); err != nil {
+		return nil, err
+	}
+	return takes, nil
+}
+
+func (entity *stockTakeEntity) GetStockTakeById(id primitive.ObjectID) (entities.StockTake, error) {
+	logrus.Info("GetStockTakeById")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var take entities.StockTake
+	err := entity.col.FindOne(ctx, bson.M{"_id": id}).Decode(&take)
+	return take, err
+}
+
+// StartStockTake opens a count sheet for every stocked item, optionally of
+// one category. Items with a recipe are skipped as their stock lives in the
+// ingredients. Only one count may be in progress at a time.
+func (entity *stockTakeEntity) StartStockTake(take entities.StockTake) (entities.StockTake, error) {
+	logrus.Info("StartStockTake")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	filter := bson.M{"recipe.0": bson.M{"$exists": false}}
+	if take.Category != "" {
+		filter["category"] = take.Category
+	}
+	cursor, err := entity.itemCol.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "category", Value: 1}, {Key: "name", Value: 1}}))
+	if err != nil {
+		return take, err
+	}
+	var items []entities.MenuItem
+	if err = cursor.All(ctx, &items); err != nil {
+		return take, err
+	}
+	take.Lines = []entities.StockTakeLine{}
+	for _, item := range items {
+		take.Lines = append(take.Lines, entities.StockTakeLine{
+			MenuItemId: item.Id, Name: item.Name, Category: item.Category,
+			SystemQuantity: item.Quantity, UnitCost: item.CostPrice,
+		})
+	}
+	take.Id = primitive.NewObjectID()
+	take.Status = "OPEN"
+	take.InProgress = true
+	take.CreatedDate = time.Now()
+	take.UpdatedBy = take.CreatedBy
+	take.UpdatedDate = take.CreatedDate
+	_, err = entity.col.InsertOne(ctx, take)
+	if mongo.IsDuplicateKeyError(err) {
+		return take, ErrStockTakeInProgress
+	}
+	return take, err
+}
+
+// RecordStockTakeCounts stores counted quantities on an open stock take.
+// Each line takes the system quantity and cost price at the time of counting.
+func (entity *stockTakeEntity) RecordStockTakeCounts(id primitive.ObjectID, counts map[primitive.ObjectID]int, userId string) (entities.StockTake, error) {
+	logrus.Info("RecordStockTakeCounts")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var take entities.StockTake
+	if err := entity.col.FindOne(ctx, bson.M{"_id": id}).Decode(&take); err != nil {
+		return take, err
+	}
+	if take.Status != "OPEN" {
+		return take, ErrStockTakeStatus
+	}
+	now := time.Now()
+	found := 0
+	for i := range take.Lines {
+		line := &take.Lines[i]
+		counted, ok := counts[line.MenuItemId]
+		if !ok {
+			continue
+		}
+		found++
+		var item entities.MenuItem
+		if err := entity.itemCol.FindOne(ctx, bson.M{"_id": line.MenuItemId}).Decode(&item); err != nil {
+			return take, err
+		}
+		line.SystemQuantity = item.Quantity
+		line.UnitCost = item.CostPrice
+		line.CountedQuantity = &counted
+		line.Variance = counted - item.Quantity
+		line.CountedBy = userId
+		line.CountedDate = &now
+	}
+	if found < len(counts) {
+		return take, fmt.Errorf("%d item(s) are not on this stock take", len(counts)-found)
+	}
+	take.UpdatedBy = userId
+	take.UpdatedDate = now
+	result, err := entity.col.UpdateOne(ctx, bson.M{"_id": id, "status": "OPEN"}, bson.M{"$set": bson.M{
+		"lines":       take.Lines,
+		"updatedBy":   take.UpdatedBy,
+		"updatedDate": take.UpdatedDate,
+	}})
+	if err == nil && result.MatchedCount == 0 {
+		err = ErrStockTakeStatus
+	}
+	return take, err
+}
+
+// UpdateStockTakeStatus moves the stock take from one status to another and
+// returns ErrStockTakeStatus when it is not in the expected status.
+func (entity *stockTakeEntity) UpdateStockTakeStatus(id primitive.ObjectID, from, to, userId string) error {
+	logrus.Info("UpdateStockTakeStatus")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	now := time.Now()
+	set := bson.M{"status": to, "updatedBy": userId, "updatedDate": now}
+	if to == "SUBMITTED" {
+		set["submittedBy"] = userId
+		set["submittedDate"] = now
+	}
+	update := bson.M{"$set": set}
+	if to != "OPEN" && to != "SUBMITTED" {
+		update["$unset"] = bson.M{"inProgress": ""}
+	}
+	result, err := entity.col.UpdateOne(ctx, bson.M{"_id": id, "status": from}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrStockTakeStatus
+	}
+	return nil
+}
+
+// ApproveStockTake posts a COUNT movement for every counted line with a
+// variance and marks the stock take approved, in one transaction. The
+// variance is posted as a difference so sales after the count are kept.
+func (entity *stockTakeEntity) ApproveStockTake(id primitive.ObjectID, userId string) (entities.StockTake, error) {
+	logrus.Info("ApproveStockTake")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	var take entities.StockTake
+	err := entity.resource.WithTransaction(ctx, func(sc mongo.SessionContext) error {
+		if err := entity.col.FindOne(sc, bson.M{"_id": id}).Decode(&take); err != nil {
+			return err
+		}
+		if take.Status != "SUBMITTED" {
+			return ErrStockTakeStatus
+		}
+		for _, line := range take.Lines {
+			if line.CountedQuantity == nil || line.Variance == 0 {
+				continue
+			}
+			if _, err := moveStock(sc, entity.itemCol, entity.movementCol, entities.StockMovement{
+				MenuItemId: line.MenuItemId, Type: "COUNT", Quantity: line.Variance,
+				UnitCost: line.UnitCost, RefType: "STOCK_TAKE", RefId: &take.Id,
+				Reason: "stock take", CreatedBy: userId,
+			}, false); err != nil {
+				return err
+			}
+		}
+		now := time.Now()
+		take.Status = "APPROVED"
+		take.ApprovedBy = userId
+		take.ApprovedDate = &now
+		take.UpdatedBy = userId
+		take.UpdatedDate = now
+		take.InProgress = false
+		_, err := entity.col.UpdateOne(sc, bson.M{"_id": id}, bson.M{
+			"$set": bson.M{
+				"status":       take.Status,
+				"approvedBy":   take.ApprovedBy,
+				"approvedDate": take.ApprovedDate,
+				"updatedBy":    take.UpdatedBy,
+				"updatedDate":  take.UpdatedDate,
+			},
+			"$unset": bson.M{"inProgress": ""},
+		})
+		return err
+	})
+	return take, err
+}
@@ -2,6 +2,8 @@ package repositories
 
 import (
 	"context"
+	"errors"
+	"math"
 	"snook/app/data/entities"
 	"snook/db"
 	"time"
@@ -13,22 +15,45 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+var (
+	ErrOrderVoided            = errors.New("order has been voided")
+	ErrOrderAdjustmentPending = errors.New("order already has an adjustment waiting for approval")
+	ErrOrderAdjustmentStale   = errors.New("order changed after the adjustment was requested")
+)
+
 type tableOrderEntity struct {
-	col *mongo.Collection
+	resource              *db.Resource
+	col                   *mongo.Collection
+	itemCol               *mongo.Collection
+	movementCol           *mongo.Collection
+	ingredientCol         *mongo.Collection
+	ingredientMovementCol *mongo.Collection
 }
 
 type ITableOrder interface {
 	GetOrdersBySessionId(sessionId primitive.ObjectID) ([]entities.TableOrder, error)
 	GetTableOrderById(id primitive.ObjectID) (entities.TableOrder, error)
 	CreateTableOrder(order entities.TableOrder) (entities.TableOrder, error)
-	UpdateTableOrder(id primitive.ObjectID, order entities.TableOrder) error
+	PlaceTableOrder(order entities.TableOrder) (entities.TableOrder, error)
+	AdjustTableOrder(id primitive.ObjectID, adjustment entities.OrderAdjustment) (entities.TableOrder, error)
+	ApproveOrderAdjustment(id, adjustmentId primitive.ObjectID, approverId string) (entities.TableOrder, error)
+	RejectOrderAdjustment(id, adjustmentId primitive.ObjectID, approverId string) error
+	GetPendingOrderAdjustments() ([]entities.TableOrder, error)
+	GetVoidReport(startDate, endDate time.Time) ([]entities.VoidReport, error)
 	DeleteTableOrder(id primitive.ObjectID) error
 	GetOrdersByDateRange(startDate, endDate time.Time) ([]entities.TableOrder, error)
 }
 
 func NewTableOrderEntity(resource *db.Resource) ITableOrder {
 	col := resource.SnookDb.Collection("table_orders")
-	return &tableOrderEntity{col: col}
+	return &tableOrderEntity{
+		resource:              resource,
+		col:                   col,
+		itemCol:               resource.SnookDb.Collection("menu_items"),
+		movementCol:           resource.SnookDb.Collection("stock_movements"),
+		ingredientCol:         resource.SnookDb.Collection("ingredients"),
+		ingredientMovementCol: resource.SnookDb.Collection("ingredient_movements"),
+	}
 }
 
 func (entity *tableOrderEntity) GetOrdersBySessionId(sessionId primitive.ObjectID) ([]entities.TableOrder, error) {
@@ -66,18 +91,286 @@ func (entity *tableOrderEntity) CreateTableOrder(order entities.TableOrder) (ent
 	return order, err
 }
 
-func (entity *tableOrderEntity) UpdateTableOrder(id primitive.ObjectID, order entities.TableOrder) error {
-	logrus.Info("UpdateTableOrder")
+// PlaceTableOrder takes the ordered quantity off stock and inserts the order in
+// one transaction. Items with a recipe consume their ingredients instead and
+// are costed from them. It returns ErrOutOfStock when not enough is on hand.
+func (entity *tableOrderEntity) PlaceTableOrder(order entities.TableOrder) (entities.TableOrder, error) {
+	logrus.Info("PlaceTableOrder")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	order.Id = primitive.NewObjectID()
+	order.Status = "ACTIVE"
+	order.OriginalQuantity = order.Quantity
+	order.CreatedDate = time.Now()
+	err := entity.resource.WithTransaction(ctx, func(sc mongo.SessionContext) error {
+		order.Ingredients = nil
+		if err := entity.consumeStock(sc, &order, order.Quantity, order.CreatedBy); err != nil {
+			return err
+		}
+		_, err := entity.col.InsertOne(sc, order)
+		return err
+	})
+	return order, err
+}
+
+// consumeStock takes quantity units of the order off stock, from the menu
+// item or from the ingredients of its recipe. Ingredients used are added to
+// the order and the first consumption sets the recipe cost price.
+func (entity *tableOrderEntity) consumeStock(ctx context.Context, order *entities.TableOrder, quantity int, userId string) error {
+	var item entities.MenuItem
+	if err := entity.itemCol.FindOne(ctx, bson.M{"_id": order.MenuItemId}).Decode(&item); err != nil {
+		return err
+	}
+	if len(item.Recipe) == 0 {
+		_, err := moveStock(ctx, entity.itemCol, entity.movementCol, entities.StockMovement{
+			MenuItemId: order.MenuItemId, Type: "SALE", Quantity: -quantity,
+			UnitCost: order.CostPrice, RefType: "ORDER", RefId: &order.Id,
+			CreatedBy: userId,
+		}, true)
+		return err
+	}
+	first := len(order.Ingredients) == 0
+	cost := 0.0
+	for _, line := range item.Recipe {
+		movement, err := moveIngredient(ctx, entity.ingredientCol, entity.ingredientMovementCol, entities.IngredientMovement{
+			IngredientId: line.IngredientId, Type: "SALE", Quantity: -line.Quantity * float64(quantity),
+			RefType: "ORDER", RefId: &order.Id, CreatedBy: userId,
+		}, true)
+		if err != nil {
+			return err
+		}
+		cost += line.Quantity * movement.UnitCost
+		merged := false
+		for i := range order.Ingredients {
+			if order.Ingredients[i].IngredientId == line.IngredientId {
+				order.Ingredients[i].Quantity += -movement.Quantity
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			order.Ingredients = append(order.Ingredients, entities.OrderIngredient{
+				IngredientId: line.IngredientId, Name: movement.IngredientName,
+				Quantity: -movement.Quantity, UnitCost: movement.UnitCost,
+			})
+		}
+	}
+	if first {
+		order.CostPrice = math.Round(cost*100) / 100
+	}
+	return nil
+}
+
+// restoreStock returns quantity units of the order to stock, either to the
+// menu item or to the ingredients it consumed.
+func (entity *tableOrderEntity) restoreStock(ctx context.Context, order entities.TableOrder, quantity int, reason, userId string) error {
+	if len(order.Ingredients) == 0 {
+		_, err := moveStock(ctx, entity.itemCol, entity.movementCol, entities.StockMovement{
+			MenuItemId: order.MenuItemId, Type: "RETURN", Quantity: quantity,
+			UnitCost: order.CostPrice, RefType: "ORDER", RefId: &order.Id,
+			Reason: reason, CreatedBy: userId,
+		}, false)
+		return err
+	}
+	for _, used := range order.Ingredients {
+		_, err := moveIngredient(ctx, entity.ingredientCol, entity.ingredientMovementCol, entities.IngredientMovement{
+			IngredientId: used.IngredientId, Type: "RETURN", Quantity: used.Quantity / float64(order.Quantity) * float64(quantity),
+			UnitCost: used.UnitCost, RefType: "ORDER", RefId: &order.Id,
+			Reason: reason, CreatedBy: userId,
+		}, false)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AdjustTableOrder records a quantity change or void on the order. An
+// APPROVED adjustment is applied right away, a PENDING one waits for
+// ApproveOrderAdjustment.
+func (entity *tableOrderEntity) AdjustTableOrder(id primitive.ObjectID, adjustment entities.OrderAdjustment) (entities.TableOrder, error) {
+	logrus.Info("AdjustTableOrder")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var order entities.TableOrder
+	err := entity.resource.WithTransaction(ctx, func(sc mongo.SessionContext) error {
+		order = entities.TableOrder{}
+		if err := entity.col.FindOne(sc, bson.M{"_id": id}).Decode(&order); err != nil {
+			return err
+		}
+		if order.Status == "VOID" {
+			return ErrOrderVoided
+		}
+		for _, existing := range order.Adjustments {
+			if existing.Status == "PENDING" {
+				return ErrOrderAdjustmentPending
+			}
+		}
+		adjustment.Id = primitive.NewObjectID()
+		adjustment.FromQuantity = order.Quantity
+		adjustment.RequestedDate = time.Now()
+		order.Adjustments = append(order.Adjustments, adjustment)
+		if adjustment.Status == "APPROVED" {
+			return entity.applyAdjustment(sc, &order, len(order.Adjustments)-1, adjustment.ApprovedBy)
+		}
+		return entity.saveAdjustments(sc, order)
+	})
+	return order, err
+}
+
+// ApproveOrderAdjustment applies a pending adjustment. It is rejected with
+// ErrOrderAdjustmentStale when the quantity changed since the request.
+func (entity *tableOrderEntity) ApproveOrderAdjustment(id, adjustmentId primitive.ObjectID, approverId string) (entities.TableOrder, error) {
+	logrus.Info("ApproveOrderAdjustment")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	_, err := entity.col.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
-		"quantity": order.Quantity,
-		"discount": order.Discount,
-		"total":    order.Total,
+	var order entities.TableOrder
+	err := entity.resource.WithTransaction(ctx, func(sc mongo.SessionContext) error {
+		order = entities.TableOrder{}
+		if err := entity.col.FindOne(sc, bson.M{"_id": id}).Decode(&order); err != nil {
+			return err
+		}
+		index := pendingAdjustment(order, adjustmentId)
+		if index < 0 {
+			return mongo.ErrNoDocuments
+		}
+		if order.Status == "VOID" {
+			return ErrOrderVoided
+		}
+		if order.Adjustments[index].FromQuantity != order.Quantity {
+			return ErrOrderAdjustmentStale
+		}
+		return entity.applyAdjustment(sc, &order, index, approverId)
+	})
+	return order, err
+}
+
+func (entity *tableOrderEntity) RejectOrderAdjustment(id, adjustmentId primitive.ObjectID, approverId string) error {
+	logrus.Info("RejectOrderAdjustment")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	result, err := entity.col.UpdateOne(ctx, bson.M{"_id": id, "adjustments": bson.M{"$elemMatch": bson.M{"_id": adjustmentId, "status": "PENDING"}}},
+		bson.M{"$set": bson.M{
+			"adjustments.$.status":       "REJECTED",
+			"adjustments.$.approvedBy":   approverId,
+			"adjustments.$.approvedDate": time.Now(),
+		}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func pendingAdjustment(order entities.TableOrder, adjustmentId primitive.ObjectID) int {
+	for i, adjustment := range order.Adjustments {
+		if adjustment.Id == adjustmentId && adjustment.Status == "PENDING" {
+			return i
+		}
+	}
+	return -1
+}
+
+// applyAdjustment moves stock for the quantity change and saves the order
+// with the new quantity, discount and total. The original line is kept; a
+// void leaves it with quantity 0 and status VOID.
+func (entity *tableOrderEntity) applyAdjustment(ctx context.Context, order *entities.TableOrder, index int, approverId string) error {
+	adjustment := &order.Adjustments[index]
+	now := time.Now()
+	adjustment.Status = "APPROVED"
+	adjustment.ApprovedBy = approverId
+	adjustment.ApprovedDate = &now
+	if adjustment.ToQuantity < order.Quantity {
+		if err := entity.restoreStock(ctx, *order, order.Quantity-adjustment.ToQuantity, adjustment.Reason, approverId); err != nil {
+			return err
+		}
+		for i := range order.Ingredients {
+			order.Ingredients[i].Quantity = order.Ingredients[i].Quantity / float64(order.Quantity) * float64(adjustment.ToQuantity)
+		}
+	} else if adjustment.ToQuantity > order.Quantity {
+		if err := entity.consumeStock(ctx, order, adjustment.ToQuantity-order.Quantity, approverId); err != nil {
+			return err
+		}
+	}
+	order.Quantity = adjustment.ToQuantity
+	order.Discount = adjustment.Discount
+	order.Total = math.Max(order.Price*float64(order.Quantity)-order.Discount, 0)
+	if adjustment.Type == "VOID" {
+		order.Status = "VOID"
+		order.Discount = 0
+		order.Total = 0
+	}
+	_, err := entity.col.UpdateOne(ctx, bson.M{"_id": order.Id}, bson.M{"$set": bson.M{
+		"quantity":    order.Quantity,
+		"discount":    order.Discount,
+		"total":       order.Total,
+		"status":      order.Status,
+		"ingredients": order.Ingredients,
+		"adjustments": order.Adjustments,
 	}})
 	return err
 }
 
+func (entity *tableOrderEntity) saveAdjustments(ctx context.Context, order entities.TableOrder) error {
+	_, err := entity.col.UpdateOne(ctx, bson.M{"_id": order.Id}, bson.M{"$set": bson.M{
+		"adjustments": order.Adjustments,
+	}})
+	return err
+}
+
+func (entity *tableOrderEntity) GetPendingOrderAdjustments() ([]entities.TableOrder, error) {
+	logrus.Info("GetPendingOrderAdjustments")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	opts := options.Find().SetSort(bson.D{{Key: "createdDate", Value: 1}})
+	cursor, err := entity.col.Find(ctx, bson.M{"adjustments.status": "PENDING"}, opts)
+	if err != nil {
+		return nil, err
+	}
+	orders := []entities.TableOrder{}
+	if err = cursor.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// GetVoidReport groups approved voids and quantity reductions requested in
+// the date range by the staff member who requested them.
+func (entity *tableOrderEntity) GetVoidReport(startDate, endDate time.Time) ([]entities.VoidReport, error) {
+	logrus.Info("GetVoidReport")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	removed := bson.M{"$subtract": bson.A{"$adjustments.fromQuantity", "$adjustments.toQuantity"}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"adjustments.status": "APPROVED"}}},
+		{{Key: "$unwind", Value: "$adjustments"}},
+		{{Key: "$match", Value: bson.M{
+			"adjustments.status":        "APPROVED",
+			"adjustments.requestedDate": bson.M{"$gte": startDate, "$lte": endDate},
+			"$expr":                     bson.M{"$lt": bson.A{"$adjustments.toQuantity", "$adjustments.fromQuantity"}},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":        "$adjustments.requestedBy",
+			"voids":      bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$adjustments.type", "VOID"}}, 1, 0}}},
+			"reductions": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$adjustments.type", "VOID"}}, 0, 1}}},
+			"quantity":   bson.M{"$sum": removed},
+			"value":      bson.M{"$sum": bson.M{"$multiply": bson.A{removed, "$price"}}},
+		}}},
+		{{Key: "$sort", Value: bson.M{"value": -1}}},
+	}
+	cursor, err := entity.col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	report := []entities.VoidReport{}
+	if err = cursor.All(ctx, &report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
 func (entity *tableOrderEntity) DeleteTableOrder(id primitive.ObjectID) error {
 	logrus.Info("DeleteTableOrder")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
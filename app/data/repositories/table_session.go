@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"snook/app/data/entities"
 	"snook/db"
 	"time"
@@ -13,8 +14,17 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+var ErrSessionClosed = errors.New("session already closed")
+
 type tableSessionEntity struct {
-	col *mongo.Collection
+	resource    *db.Resource
+	col         *mongo.Collection
+	paymentCol  *mongo.Collection
+	creditorCol *mongo.Collection
+	tableCol    *mongo.Collection
+	intentCol   *mongo.Collection
+	memberCol   *mongo.Collection
+	loyaltyCol  *mongo.Collection
 }
 
 type ITableSession interface {
@@ -23,14 +33,25 @@ type ITableSession interface {
 	GetActiveSessionByTableId(tableId primitive.ObjectID) (entities.TableSession, error)
 	CreateTableSession(session entities.TableSession) (entities.TableSession, error)
 	UpdateTableSession(id primitive.ObjectID, session entities.TableSession) error
+	CheckoutTableSession(session entities.TableSession, payments []entities.Payment, creditors []entities.Creditor, loyalty []entities.LoyaltyTransaction) ([]entities.Payment, error)
 	GetSessionSummary(startDate, endDate time.Time) (entities.SessionSummary, error)
 	GetSessionDailyChart(startDate, endDate time.Time) ([]entities.SessionDailyChart, error)
 	GetSessionsByTableId(tableId primitive.ObjectID, startDate, endDate time.Time) ([]entities.TableSession, error)
+	CountPromotionUsage(promotionId primitive.ObjectID, memberId *primitive.ObjectID) (int64, error)
+	CountOpenSessions(startedBefore time.Time) (int64, error)
 }
 
 func NewTableSessionEntity(resource *db.Resource) ITableSession {
-	col := resource.SnookDb.Collection("table_sessions")
-	return &tableSessionEntity{col: col}
+	return &tableSessionEntity{
+		resource:    resource,
+		col:         resource.SnookDb.Collection("table_sessions"),
+		paymentCol:  resource.SnookDb.Collection("payments"),
+		creditorCol: resource.SnookDb.Collection("creditors"),
+		tableCol:    resource.SnookDb.Collection("tables"),
+		intentCol:   resource.SnookDb.Collection("payment_intents"),
+		memberCol:   resource.SnookDb.Collection("members"),
+		loyaltyCol:  resource.SnookDb.Collection("loyalty_transactions"),
+	}
 }
 
 func (entity *tableSessionEntity) GetTableSessions(startDate, endDate time.Time) ([]entities.TableSession, error) {
@@ -79,31 +100,107 @@ func (entity *tableSessionEntity) CreateTableSession(session entities.TableSessi
 	return session, err
 }
 
+// UpdateTableSession saves an open session. A session closed in the meantime
+// is left as checked out and fails with ErrSessionClosed.
 func (entity *tableSessionEntity) UpdateTableSession(id primitive.ObjectID, session entities.TableSession) error {
 	logrus.Info("UpdateTableSession")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	session.UpdatedDate = time.Now()
-	_, err := entity.col.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
-		"status":            session.Status,
-		"endTime":           session.EndTime,
-		"pausedAt":          session.PausedAt,
-		"totalPausedMins":   session.TotalPausedMins,
-		"durationMins":      session.DurationMins,
-		"tableCharge":       session.TableCharge,
-		"foodTotal":         session.FoodTotal,
-		"discount":          session.Discount,
-		"promotionId":       session.PromotionId,
-		"promotionName":     session.PromotionName,
-		"promotionDiscount": session.PromotionDiscount,
-		"grandTotal":        session.GrandTotal,
-		"note":              session.Note,
-		"tableId":           session.TableId,
-		"tableName":         session.TableName,
-		"updatedBy":         session.UpdatedBy,
-		"updatedDate":       session.UpdatedDate,
-	}})
-	return err
+	result, err := entity.col.UpdateOne(ctx, bson.M{"_id": id, "status": bson.M{"$ne": "CLOSED"}},
+		bson.M{"$set": sessionFields(session)})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrSessionClosed
+	}
+	return nil
+}
+
+// CheckoutTableSession closes the session together with its payments, the
+// creditors of unpaid amounts, the loyalty points redeemed and earned and the
+// table, all or nothing. Payment requests still pending are cancelled, as the
+// bill is settled. A session closed in the meantime fails with
+// ErrSessionClosed.
+func (entity *tableSessionEntity) CheckoutTableSession(session entities.TableSession, payments []entities.Payment, creditors []entities.Creditor, loyalty []entities.LoyaltyTransaction) ([]entities.Payment, error) {
+	logrus.Info("CheckoutTableSession")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	var created []entities.Payment
+	err := entity.resource.WithTransaction(ctx, func(sc mongo.SessionContext) error {
+		created = nil
+		now := time.Now()
+		session.UpdatedDate = now
+		result, err := entity.col.UpdateOne(sc, bson.M{"_id": session.Id, "status": bson.M{"$ne": "CLOSED"}},
+			bson.M{"$set": sessionFields(session)})
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return ErrSessionClosed
+		}
+		for _, payment := range payments {
+			payment.Id = primitive.NewObjectID()
+			payment.CreatedDate = now
+			if _, err := entity.paymentCol.InsertOne(sc, payment); err != nil {
+				return err
+			}
+			created = append(created, payment)
+		}
+		for _, creditor := range creditors {
+			creditor.Id = primitive.NewObjectID()
+			creditor.CreatedDate = now
+			creditor.UpdatedDate = now
+			if _, err := entity.creditorCol.InsertOne(sc, creditor); err != nil {
+				return err
+			}
+		}
+		for _, txn := range loyalty {
+			if _, err := postLoyalty(sc, entity.memberCol, entity.loyaltyCol, txn); err != nil {
+				return err
+			}
+		}
+		_, err = entity.intentCol.UpdateMany(sc, bson.M{"sessionId": session.Id, "status": "PENDING"},
+			bson.M{"$set": bson.M{"status": "CANCELLED", "updatedBy": session.UpdatedBy, "updatedDate": now}})
+		if err != nil {
+			return err
+		}
+		_, err = entity.tableCol.UpdateOne(sc, bson.M{"_id": session.TableId}, bson.M{"$set": bson.M{"status": "AVAILABLE"}})
+		return err
+	})
+	return created, err
+}
+
+func sessionFields(session entities.TableSession) bson.M {
+	return bson.M{
+		"status":              session.Status,
+		"endTime":             session.EndTime,
+		"pausedAt":            session.PausedAt,
+		"pauses":              session.Pauses,
+		"totalPausedMins":     session.TotalPausedMins,
+		"durationMins":        session.DurationMins,
+		"tableCharge":         session.TableCharge,
+		"foodTotal":           session.FoodTotal,
+		"discount":            session.Discount,
+		"promotionId":         session.PromotionId,
+		"promotionName":       session.PromotionName,
+		"promotionDiscount":   session.PromotionDiscount,
+		"promotionRejections": session.PromotionRejections,
+		"promotions":          session.Promotions,
+		"vouchers":            session.Vouchers,
+		"memberId":            session.MemberId,
+		"memberName":          session.MemberName,
+		"pointsRedeemed":      session.PointsRedeemed,
+		"pointsDiscount":      session.PointsDiscount,
+		"pointsEarned":        session.PointsEarned,
+		"grandTotal":          session.GrandTotal,
+		"note":                session.Note,
+		"tableId":             session.TableId,
+		"tableName":           session.TableName,
+		"updatedBy":           session.UpdatedBy,
+		"updatedDate":         session.UpdatedDate,
+	}
 }
 
 func (entity *tableSessionEntity) GetSessionSummary(startDate, endDate time.Time) (entities.SessionSummary, error) {
@@ -174,3 +271,29 @@ func (entity *tableSessionEntity) GetSessionsByTableId(tableId primitive.ObjectI
 	}
 	return sessions, nil
 }
+
+func (entity *tableSessionEntity) CountPromotionUsage(promotionId primitive.ObjectID, memberId *primitive.ObjectID) (int64, error) {
+	logrus.Info("CountPromotionUsage")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	filter := bson.M{"status": "CLOSED", "$or": []bson.M{
+		{"promotionId": promotionId},
+		{"promotions.promotionId": promotionId},
+	}}
+	if memberId != nil {
+		filter["memberId"] = *memberId
+	}
+	return entity.col.CountDocuments(ctx, filter)
+}
+
+// CountOpenSessions counts sessions started up to startedBefore that are
+// still running or paused.
+func (entity *tableSessionEntity) CountOpenSessions(startedBefore time.Time) (int64, error) {
+	logrus.Info("CountOpenSessions")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return entity.col.CountDocuments(ctx, bson.M{
+		"status":    bson.M{"$ne": "CLOSED"},
+		"startTime": bson.M{"$lte": startedBefore},
+	})
+}
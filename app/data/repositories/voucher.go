@@ -0,0 +1,270 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"snook/app/data/entities"
+	"snook/db"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrDuplicateVoucherCode  = errors.New("voucher code already exists")
+	ErrVoucherUnavailable    = errors.New("voucher is not active, expired or fully used")
+	ErrVoucherMemberRequired = errors.New("member required for this voucher")
+	ErrVoucherCustomerLimit  = errors.New("customer usage limit reached for this voucher")
+)
+
+type voucherEntity struct {
+	resource      *db.Resource
+	col           *mongo.Collection
+	redemptionCol *mongo.Collection
+}
+
+type IVoucher interface {
+	GetVouchers(promotionId *primitive.ObjectID, batchId string) ([]entities.Voucher, error)
+	GetVoucherById(id primitive.ObjectID) (entities.Voucher, error)
+	GetVoucherByCode(code string) (entities.Voucher, error)
+	CreateVouchers(vouchers []entities.Voucher) ([]entities.Voucher, error)
+	UpdateVoucherById(id primitive.ObjectID, voucher entities.Voucher) error
+	RedeemVoucher(redemption entities.VoucherRedemption) (entities.VoucherRedemption, error)
+	ReleaseVoucherRedemption(redemptionId primitive.ObjectID) error
+	GetVoucherRedemptions(voucherId primitive.ObjectID) ([]entities.VoucherRedemption, error)
+	GetVoucherReport(promotionId *primitive.ObjectID) ([]entities.VoucherReport, error)
+}
+
+func NewVoucherEntity(resource *db.Resource) IVoucher {
+	col := resource.SnookDb.Collection("vouchers")
+	redemptionCol := resource.SnookDb.Collection("voucher_redemptions")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "code", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		logrus.Error("failed to create voucher code index: ", err)
+	}
+	return &voucherEntity{resource: resource, col: col, redemptionCol: redemptionCol}
+}
+
+func (entity *voucherEntity) GetVouchers(promotionId *primitive.ObjectID, batchId string) ([]entities.Voucher, error) {
+	logrus.Info("GetVouchers")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	filter := bson.M{}
+	if promotionId != nil {
+		filter["promotionId"] = *promotionId
+	}
+	if batchId != "" {
+		filter["batchId"] = batchId
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "createdDate", Value: -1}})
+	cursor, err := entity.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var vouchers []entities.Voucher
+	if err = cursor.All(ctx, &vouchers); err != nil {
+		return nil, err
+	}
+	return vouchers, nil
+}
+
+func (entity *voucherEntity) GetVoucherById(id primitive.ObjectID) (entities.Voucher, error) {
+	logrus.Info("GetVoucherById")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var voucher entities.Voucher
+	err := entity.col.FindOne(ctx, bson.M{"_id": id}).Decode(&voucher)
+	return voucher, err
+}
+
+func (entity *voucherEntity) GetVoucherByCode(code string) (entities.Voucher, error) {
+	logrus.Info("GetVoucherByCode")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var voucher entities.Voucher
+	err := entity.col.FindOne(ctx, bson.M{"code": code}).Decode(&voucher)
+	return voucher, err
+}
+
+func (entity *voucherEntity) CreateVouchers(vouchers []entities.Voucher) ([]entities.Voucher, error) {
+	logrus.Info("CreateVouchers")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	now := time.Now()
+	docs := make([]interface{}, len(vouchers))
+	for i := range vouchers {
+		vouchers[i].Id = primitive.NewObjectID()
+		vouchers[i].CreatedDate = now
+		vouchers[i].UpdatedDate = now
+		docs[i] = vouchers[i]
+	}
+	_, err := entity.col.InsertMany(ctx, docs)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrDuplicateVoucherCode
+	}
+	if err != nil {
+		return nil, err
+	}
+	return vouchers, nil
+}
+
+func (entity *voucherEntity) UpdateVoucherById(id primitive.ObjectID, voucher entities.Voucher) error {
+	logrus.Info("UpdateVoucherById")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := entity.col.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"maxUses":            voucher.MaxUses,
+		"maxUsesPerCustomer": voucher.MaxUsesPerCustomer,
+		"expiresAt":          voucher.ExpiresAt,
+		"status":             voucher.Status,
+		"updatedBy":          voucher.UpdatedBy,
+		"updatedDate":        time.Now(),
+	}})
+	return err
+}
+
+// RedeemVoucher claims one use of the voucher and records the redemption in
+// one transaction. The use is only claimed while the voucher is active, not
+// expired and below its limit, and while the member is below the per-customer
+// limit, so concurrent redemptions can never exceed either.
+func (entity *voucherEntity) RedeemVoucher(redemption entities.VoucherRedemption) (entities.VoucherRedemption, error) {
+	logrus.Info("RedeemVoucher")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := entity.resource.WithTransaction(ctx, func(sc mongo.SessionContext) error {
+		// A voucher stays valid through the whole of its expiry day.
+		filter := bson.M{
+			"_id":    redemption.VoucherId,
+			"status": "ACTIVE",
+			"$and": []bson.M{
+				{"$or": []bson.M{
+					{"maxUses": 0},
+					{"$expr": bson.M{"$lt": bson.A{"$usedCount", "$maxUses"}}},
+				}},
+				{"$or": []bson.M{
+					{"expiresAt": nil},
+					{"expiresAt": bson.M{"$gt": time.Now().AddDate(0, 0, -1)}},
+				}},
+			},
+		}
+		var voucher entities.Voucher
+		err := entity.col.FindOneAndUpdate(sc, filter, bson.M{"$inc": bson.M{"usedCount": 1}}).Decode(&voucher)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrVoucherUnavailable
+		}
+		if err != nil {
+			return err
+		}
+		if voucher.MaxUsesPerCustomer > 0 {
+			if redemption.MemberId == nil {
+				return ErrVoucherMemberRequired
+			}
+			used, err := entity.redemptionCol.CountDocuments(sc, bson.M{
+				"voucherId": redemption.VoucherId,
+				"memberId":  *redemption.MemberId,
+				"status":    "REDEEMED",
+			})
+			if err != nil {
+				return err
+			}
+			if used >= int64(voucher.MaxUsesPerCustomer) {
+				return ErrVoucherCustomerLimit
+			}
+		}
+		redemption.Id = primitive.NewObjectID()
+		redemption.Status = "REDEEMED"
+		redemption.CreatedDate = time.Now()
+		_, err = entity.redemptionCol.InsertOne(sc, redemption)
+		return err
+	})
+	return redemption, err
+}
+
+// ReleaseVoucherRedemption gives the use back when the discount is taken off
+// the session. Releasing twice has no further effect.
+func (entity *voucherEntity) ReleaseVoucherRedemption(redemptionId primitive.ObjectID) error {
+	logrus.Info("ReleaseVoucherRedemption")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return entity.resource.WithTransaction(ctx, func(sc mongo.SessionContext) error {
+		var redemption entities.VoucherRedemption
+		err := entity.redemptionCol.FindOneAndUpdate(sc,
+			bson.M{"_id": redemptionId, "status": "REDEEMED"},
+			bson.M{"$set": bson.M{"status": "RELEASED", "releasedDate": time.Now()}},
+		).Decode(&redemption)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		_, err = entity.col.UpdateOne(sc, bson.M{"_id": redemption.VoucherId}, bson.M{"$inc": bson.M{"usedCount": -1}})
+		return err
+	})
+}
+
+func (entity *voucherEntity) GetVoucherRedemptions(voucherId primitive.ObjectID) ([]entities.VoucherRedemption, error) {
+	logrus.Info("GetVoucherRedemptions")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	opts := options.Find().SetSort(bson.D{{Key: "createdDate", Value: -1}})
+	cursor, err := entity.redemptionCol.Find(ctx, bson.M{"voucherId": voucherId}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var redemptions []entities.VoucherRedemption
+	if err = cursor.All(ctx, &redemptions); err != nil {
+		return nil, err
+	}
+	return redemptions, nil
+}
+
+// GetVoucherReport compares vouchers issued with vouchers redeemed per
+// promotion and batch.
+func (entity *voucherEntity) GetVoucherReport(promotionId *primitive.ObjectID) ([]entities.VoucherReport, error) {
+	logrus.Info("GetVoucherReport")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	match := bson.M{}
+	if promotionId != nil {
+		match["promotionId"] = *promotionId
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":           bson.M{"promotionId": "$promotionId", "batchId": "$batchId"},
+			"promotionName": bson.M{"$first": "$promotionName"},
+			"issued":        bson.M{"$sum": 1},
+			"used":          bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{"$usedCount", 0}}, 1, 0}}},
+			"redemptions":   bson.M{"$sum": "$usedCount"},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":           0,
+			"promotionId":   "$_id.promotionId",
+			"batchId":       "$_id.batchId",
+			"promotionName": 1,
+			"issued":        1,
+			"used":          1,
+			"redemptions":   1,
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "promotionName", Value: 1}, {Key: "batchId", Value: 1}}}},
+	}
+	cursor, err := entity.col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var report []entities.VoucherReport
+	if err = cursor.All(ctx, &report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
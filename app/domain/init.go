@@ -1,38 +1,68 @@
 package domain
 
 import (
+	"snook/app/core/gateway"
+	"snook/app/core/storage"
 	"snook/app/data/repositories"
 	"snook/db"
 )
 
 type Repository struct {
-	Session      repositories.ISession
-	Table        repositories.ITable
-	TableSession repositories.ITableSession
-	Booking      repositories.IBooking
-	MenuCategory repositories.IMenuCategory
-	MenuItem     repositories.IMenuItem
-	TableOrder   repositories.ITableOrder
-	Payment      repositories.IPayment
-	Creditor     repositories.ICreditor
-	Promotion    repositories.IPromotion
-	Expense      repositories.IExpense
-	Setting      repositories.ISetting
+	Session         repositories.ISession
+	Table           repositories.ITable
+	TableSession    repositories.ITableSession
+	Booking         repositories.IBooking
+	MenuCategory    repositories.IMenuCategory
+	MenuItem        repositories.IMenuItem
+	MenuImport      repositories.IMenuImport
+	TableOrder      repositories.ITableOrder
+	Payment         repositories.IPayment
+	Creditor        repositories.ICreditor
+	Promotion       repositories.IPromotion
+	Expense         repositories.IExpense
+	Setting         repositories.ISetting
+	Loyalty         repositories.ILoyalty
+	Voucher         repositories.IVoucher
+	Purchase        repositories.IPurchase
+	Ingredient      repositories.IIngredient
+	StockTake       repositories.IStockTake
+	OrderTicket     repositories.IOrderTicket
+	PriceList       repositories.IPriceList
+	PaymentIntent   repositories.IPaymentIntent
+	PaymentReversal repositories.IPaymentReversal
+	CashShift       repositories.ICashShift
+	DayClose        repositories.IDayClose
+	Storage         storage.Storage
+	Gateway         gateway.Provider
 }
 
 func InitRepository(resource *db.Resource) *Repository {
 	return &Repository{
-		Session:      repositories.NewSessionEntity(resource),
-		Table:        repositories.NewTableEntity(resource),
-		TableSession: repositories.NewTableSessionEntity(resource),
-		Booking:      repositories.NewBookingEntity(resource),
-		MenuCategory: repositories.NewMenuCategoryEntity(resource),
-		MenuItem:     repositories.NewMenuItemEntity(resource),
-		TableOrder:   repositories.NewTableOrderEntity(resource),
-		Payment:      repositories.NewPaymentEntity(resource),
-		Creditor:     repositories.NewCreditorEntity(resource),
-		Promotion:    repositories.NewPromotionEntity(resource),
-		Expense:      repositories.NewExpenseEntity(resource),
-		Setting:      repositories.NewSettingEntity(resource),
+		Session:         repositories.NewSessionEntity(resource),
+		Table:           repositories.NewTableEntity(resource),
+		TableSession:    repositories.NewTableSessionEntity(resource),
+		Booking:         repositories.NewBookingEntity(resource),
+		MenuCategory:    repositories.NewMenuCategoryEntity(resource),
+		MenuItem:        repositories.NewMenuItemEntity(resource),
+		MenuImport:      repositories.NewMenuImportEntity(resource),
+		TableOrder:      repositories.NewTableOrderEntity(resource),
+		Payment:         repositories.NewPaymentEntity(resource),
+		Creditor:        repositories.NewCreditorEntity(resource),
+		Promotion:       repositories.NewPromotionEntity(resource),
+		Expense:         repositories.NewExpenseEntity(resource),
+		Setting:         repositories.NewSettingEntity(resource),
+		Loyalty:         repositories.NewLoyaltyEntity(resource),
+		Voucher:         repositories.NewVoucherEntity(resource),
+		Purchase:        repositories.NewPurchaseEntity(resource),
+		Ingredient:      repositories.NewIngredientEntity(resource),
+		StockTake:       repositories.NewStockTakeEntity(resource),
+		OrderTicket:     repositories.NewOrderTicketEntity(resource),
+		PriceList:       repositories.NewPriceListEntity(resource),
+		PaymentIntent:   repositories.NewPaymentIntentEntity(resource),
+		PaymentReversal: repositories.NewPaymentReversalEntity(resource),
+		CashShift:       repositories.NewCashShiftEntity(resource),
+		DayClose:        repositories.NewDayCloseEntity(resource),
+		Storage:         storage.NewStorage(),
+		Gateway:         gateway.NewProvider(),
 	}
 }
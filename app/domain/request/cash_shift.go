@@ -0,0 +1,16 @@
+package request
+
+type OpenCashShift struct {
+	OpeningFloat float64 `json:"openingFloat" binding:"gte=0"`
+}
+
+type CashMovement struct {
+	Type   string  `json:"type" binding:"required,oneof=PAY_IN PAY_OUT"`
+	Amount float64 `json:"amount" binding:"required,gt=0"`
+	Reason string  `json:"reason" binding:"required"`
+}
+
+type CloseCashShift struct {
+	CountedCash *float64 `json:"countedCash" binding:"required,gte=0"`
+	Note        string   `json:"note"`
+}
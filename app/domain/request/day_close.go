@@ -0,0 +1,5 @@
+package request
+
+type DayClose struct {
+	BusinessDate string `json:"businessDate"`
+}
@@ -5,4 +5,5 @@ type Expense struct {
 	Description string  `json:"description"`
 	Amount      float64 `json:"amount" binding:"required"`
 	Date        string  `json:"date" binding:"required"`
+	PaymentType string  `json:"paymentType"`
 }
@@ -0,0 +1,26 @@
+package request
+
+type Ingredient struct {
+	Name          string  `json:"name" binding:"required"`
+	Unit          string  `json:"unit" binding:"required"`
+	Quantity      float64 `json:"quantity"`
+	CostPrice     float64 `json:"costPrice"`
+	LowStockLevel float64 `json:"lowStockLevel"`
+	Status        string  `json:"status"`
+}
+
+type IngredientQuantity struct {
+	Type     string   `json:"type"`
+	Quantity *float64 `json:"quantity" binding:"required"`
+	UnitCost float64  `json:"unitCost"`
+	Reason   string   `json:"reason"`
+}
+
+type Recipe struct {
+	Lines []RecipeLine `json:"lines" binding:"dive"`
+}
+
+type RecipeLine struct {
+	IngredientId string  `json:"ingredientId" binding:"required"`
+	Quantity     float64 `json:"quantity" binding:"required"`
+}
@@ -0,0 +1,13 @@
+package request
+
+type Member struct {
+	Name   string `json:"name" binding:"required"`
+	Phone  string `json:"phone" binding:"required"`
+	Status string `json:"status"`
+	Note   string `json:"note"`
+}
+
+type LoyaltyAdjustment struct {
+	Points int    `json:"points" binding:"required"`
+	Reason string `json:"reason" binding:"required"`
+}
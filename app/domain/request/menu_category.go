@@ -3,4 +3,7 @@ package request
 type MenuCategory struct {
 	Name      string `json:"name" binding:"required"`
 	SortOrder int    `json:"sortOrder"`
+	Station   string `json:"station" binding:"omitempty,oneof=KITCHEN BAR NONE"`
+	TaxClass  string `json:"taxClass"`
+	Hidden    bool   `json:"hidden"`
 }
@@ -1,16 +1,52 @@
 package request
 
 type MenuItem struct {
-	Name      string  `json:"name" binding:"required"`
-	Category  string  `json:"category" binding:"required"`
-	Price     float64 `json:"price" binding:"required"`
-	CostPrice float64 `json:"costPrice"`
-	Quantity  int     `json:"quantity"`
-	Unit      string  `json:"unit"`
-	Status    string  `json:"status"`
-	ImageUrl  string  `json:"imageUrl"`
+	Name       string  `json:"name" binding:"required"`
+	CategoryId string  `json:"categoryId" binding:"required_without=Category"`
+	Category   string  `json:"category" binding:"required_without=CategoryId"`
+	Price      float64 `json:"price" binding:"required"`
+	CostPrice  float64 `json:"costPrice"`
+	Quantity   int     `json:"quantity"`
+	Unit       string  `json:"unit"`
+	Status     string  `json:"status"`
+	ImageUrl   string  `json:"imageUrl"`
+	Sku        string  `json:"sku"`
+	Barcode    string  `json:"barcode"`
+}
+
+type MenuItemVariant struct {
+	VariantName string  `json:"variantName" binding:"required"`
+	Price       float64 `json:"price" binding:"required"`
+	CostPrice   float64 `json:"costPrice"`
+	Quantity    int     `json:"quantity"`
+	Unit        string  `json:"unit"`
+	Status      string  `json:"status"`
+	Sku         string  `json:"sku"`
+	Barcode     string  `json:"barcode"`
 }
 
 type MenuItemQuantity struct {
-	Quantity int `json:"quantity" binding:"required"`
+	Type     string `json:"type"`
+	Quantity *int   `json:"quantity" binding:"required"`
+	Reason   string `json:"reason"`
+}
+
+type ModifierGroups struct {
+	Groups []ModifierGroup `json:"groups" binding:"dive"`
+}
+
+type ModifierGroup struct {
+	Id          string           `json:"id"`
+	Name        string           `json:"name" binding:"required"`
+	Required    bool             `json:"required"`
+	MultiSelect bool             `json:"multiSelect"`
+	MaxSelect   int              `json:"maxSelect"`
+	Options     []ModifierOption `json:"options" binding:"required,min=1,dive"`
+}
+
+type ModifierOption struct {
+	Id         string  `json:"id"`
+	Name       string  `json:"name" binding:"required"`
+	PriceDelta float64 `json:"priceDelta"`
+	Status     string  `json:"status"`
 }
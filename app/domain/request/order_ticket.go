@@ -0,0 +1,5 @@
+package request
+
+type OrderTicketStatus struct {
+	Status string `json:"status" binding:"required,oneof=PREPARING READY SERVED CANCELLED"`
+}
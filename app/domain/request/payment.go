@@ -3,18 +3,43 @@ package request
 type Payment struct {
 	SessionId string  `json:"sessionId" binding:"required"`
 	Type      string  `json:"type" binding:"required"`
-	Amount    float64 `json:"amount" binding:"required"`
+	Amount    float64 `json:"amount" binding:"required,gt=0"`
 	Note      string  `json:"note"`
 }
 
 type Checkout struct {
-	Payments []PaymentItem `json:"payments" binding:"required"`
-	Discount float64       `json:"discount"`
-	Note     string        `json:"note"`
+	Payments     []PaymentItem `json:"payments" binding:"required,dive"`
+	TableCharge  *float64      `json:"tableCharge"`
+	Discount     float64       `json:"discount"`
+	Note         string        `json:"note"`
+	MemberId     string        `json:"memberId"`
+	RedeemPoints int           `json:"redeemPoints"`
 }
 
 type PaymentItem struct {
 	Type   string  `json:"type" binding:"required"`
-	Amount float64 `json:"amount" binding:"required"`
+	Amount float64 `json:"amount" binding:"required,gt=0"`
 	Note   string  `json:"note"`
 }
+
+type GatewayCharge struct {
+	Method string `json:"method" binding:"required"`
+}
+
+type PaymentEventSimulation struct {
+	Type   string   `json:"type" binding:"required,oneof=charge.succeeded charge.failed"`
+	Amount *float64 `json:"amount"`
+}
+
+type PaymentReversal struct {
+	Type   string  `json:"type" binding:"required,oneof=REFUND VOID"`
+	Amount float64 `json:"amount" binding:"omitempty,gt=0"`
+	Reason string  `json:"reason" binding:"required"`
+}
+
+type PaymentReversalReview struct {
+	Note string `json:"note"`
+	// PaidOutBy is the cashier handing the money back. It defaults to the
+	// cashier who asked for the reversal.
+	PaidOutBy string `json:"paidOutBy"`
+}
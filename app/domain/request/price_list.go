@@ -0,0 +1,28 @@
+package request
+
+type PriceList struct {
+	Name           string          `json:"name" binding:"required"`
+	StartDate      string          `json:"startDate"`
+	EndDate        string          `json:"endDate"`
+	DaysOfWeek     []int           `json:"daysOfWeek"`
+	StartTimeOfDay string          `json:"startTimeOfDay"`
+	EndTimeOfDay   string          `json:"endTimeOfDay"`
+	Priority       int             `json:"priority"`
+	Items          []PriceListItem `json:"items" binding:"required,min=1,dive"`
+	Status         string          `json:"status"`
+}
+
+type PriceListItem struct {
+	MenuItemId string  `json:"menuItemId" binding:"required"`
+	Price      float64 `json:"price" binding:"min=0"`
+}
+
+type MenuItemAvailability struct {
+	Windows []AvailabilityWindow `json:"windows" binding:"dive"`
+}
+
+type AvailabilityWindow struct {
+	DaysOfWeek     []int  `json:"daysOfWeek"`
+	StartTimeOfDay string `json:"startTimeOfDay"`
+	EndTimeOfDay   string `json:"endTimeOfDay"`
+}
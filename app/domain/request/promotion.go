@@ -1,15 +1,36 @@
 package request
 
 type Promotion struct {
-	Name        string   `json:"name" binding:"required"`
-	Description string   `json:"description"`
-	Type        string   `json:"type" binding:"required"`
-	PlayHours   float64  `json:"playHours"`
-	FreeHours   float64  `json:"freeHours"`
-	DiscountPct float64  `json:"discountPct"`
-	DiscountAmt float64  `json:"discountAmt"`
-	TableTypes  []string `json:"tableTypes"`
-	StartDate   string   `json:"startDate" binding:"required"`
-	EndDate     string   `json:"endDate" binding:"required"`
-	Status      string   `json:"status"`
+	Name               string       `json:"name" binding:"required"`
+	Description        string       `json:"description"`
+	Type               string       `json:"type" binding:"required"`
+	PlayHours          float64      `json:"playHours"`
+	FreeHours          float64      `json:"freeHours"`
+	DiscountPct        float64      `json:"discountPct"`
+	DiscountAmt        float64      `json:"discountAmt"`
+	TableTypes         []string     `json:"tableTypes"`
+	StartDate          string       `json:"startDate" binding:"required"`
+	EndDate            string       `json:"endDate" binding:"required"`
+	DaysOfWeek         []int        `json:"daysOfWeek"`
+	StartTimeOfDay     string       `json:"startTimeOfDay"`
+	EndTimeOfDay       string       `json:"endTimeOfDay"`
+	MemberOnly         bool         `json:"memberOnly"`
+	MinSpend           float64      `json:"minSpend"`
+	MaxUses            int          `json:"maxUses"`
+	MaxUsesPerCustomer int          `json:"maxUsesPerCustomer"`
+	Priority           int          `json:"priority"`
+	Stackable          bool         `json:"stackable"`
+	AutoApply          bool         `json:"autoApply"`
+	MenuItemId         string       `json:"menuItemId"`
+	BuyQuantity        int          `json:"buyQuantity"`
+	GetQuantity        int          `json:"getQuantity"`
+	Category           string       `json:"category"`
+	BundlePrice        float64      `json:"bundlePrice"`
+	BundleItems        []BundleItem `json:"bundleItems"`
+	Status             string       `json:"status"`
+}
+
+type BundleItem struct {
+	MenuItemId string `json:"menuItemId" binding:"required"`
+	Quantity   int    `json:"quantity" binding:"required"`
 }
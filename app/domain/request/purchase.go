@@ -0,0 +1,36 @@
+package request
+
+type Supplier struct {
+	Name        string `json:"name" binding:"required"`
+	ContactName string `json:"contactName"`
+	Phone       string `json:"phone"`
+	Email       string `json:"email"`
+	Address     string `json:"address"`
+	TaxId       string `json:"taxId"`
+	Note        string `json:"note"`
+	Status      string `json:"status"`
+}
+
+type PurchaseOrder struct {
+	SupplierId   string              `json:"supplierId" binding:"required"`
+	Items        []PurchaseOrderItem `json:"items" binding:"required,min=1,dive"`
+	ExpectedDate string              `json:"expectedDate"`
+	Note         string              `json:"note"`
+	Status       string              `json:"status"`
+}
+
+type PurchaseOrderItem struct {
+	MenuItemId string  `json:"menuItemId" binding:"required"`
+	Quantity   int     `json:"quantity" binding:"required"`
+	UnitCost   float64 `json:"unitCost" binding:"required"`
+}
+
+type ReceivePurchaseOrder struct {
+	Items []ReceiveItem `json:"items" binding:"dive"`
+}
+
+type ReceiveItem struct {
+	MenuItemId string  `json:"menuItemId" binding:"required"`
+	Quantity   int     `json:"quantity" binding:"required"`
+	UnitCost   float64 `json:"unitCost"`
+}
@@ -8,3 +8,26 @@ type Setting struct {
 	ReceiptFooter  string `json:"receiptFooter"`
 	PromptPayId    string `json:"promptPayId"`
 }
+
+type LoyaltySetting struct {
+	TablePointsPerBaht float64 `json:"tablePointsPerBaht"`
+	FoodPointsPerBaht  float64 `json:"foodPointsPerBaht"`
+	PointValue         float64 `json:"pointValue"`
+	ExpiryDays         int     `json:"expiryDays"`
+}
+
+type PaymentMethods struct {
+	Methods []PaymentMethod `json:"methods" binding:"required,dive"`
+}
+
+type PaymentMethod struct {
+	Code         string  `json:"code" binding:"required"`
+	Label        string  `json:"label"`
+	Active       bool    `json:"active"`
+	CashInDrawer bool    `json:"cashInDrawer"`
+	FeePct       float64 `json:"feePct"`
+}
+
+type BusinessDay struct {
+	CutoverHour *int `json:"cutoverHour" binding:"required,gte=0,lte=23"`
+}
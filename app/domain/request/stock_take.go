@@ -0,0 +1,15 @@
+package request
+
+type StockTake struct {
+	Category string `json:"category"`
+	Note     string `json:"note"`
+}
+
+type StockTakeCounts struct {
+	Counts []StockTakeCount `json:"counts" binding:"required,min=1,dive"`
+}
+
+type StockTakeCount struct {
+	MenuItemId string `json:"menuItemId" binding:"required"`
+	Quantity   *int   `json:"quantity" binding:"required"`
+}
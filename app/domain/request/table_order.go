@@ -2,12 +2,20 @@ package request
 
 type TableOrder struct {
 	SessionId  string  `json:"sessionId" binding:"required"`
-	MenuItemId string  `json:"menuItemId" binding:"required"`
-	Quantity   int     `json:"quantity" binding:"required"`
+	MenuItemId string  `json:"menuItemId" binding:"required_without=Barcode"`
+	Barcode    string  `json:"barcode"`
+	Quantity   int     `json:"quantity" binding:"required,min=1"`
 	Discount   float64 `json:"discount"`
+	// Modifiers lists the chosen modifier option ids.
+	Modifiers []string `json:"modifiers"`
 }
 
 type UpdateTableOrder struct {
-	Quantity int     `json:"quantity" binding:"required"`
+	Quantity int     `json:"quantity" binding:"required,min=1"`
 	Discount float64 `json:"discount"`
+	Reason   string  `json:"reason"`
+}
+
+type VoidTableOrder struct {
+	Reason string `json:"reason" binding:"required"`
 }
@@ -5,11 +5,14 @@ type OpenTable struct {
 }
 
 type CloseTable struct {
-	TableCharge *float64 `json:"tableCharge"`
-	Discount    float64  `json:"discount"`
-	Note        string   `json:"note"`
-	PaymentType string   `json:"paymentType"`
-	PaymentNote string   `json:"paymentNote"`
+	TableCharge  *float64 `json:"tableCharge"`
+	Discount     float64  `json:"discount"`
+	Note         string   `json:"note"`
+	PaymentType  string   `json:"paymentType"`
+	PaymentNote  string   `json:"paymentNote"`
+	MemberId     string   `json:"memberId"`
+	RedeemPoints int      `json:"redeemPoints"`
+	RedeemAs     string   `json:"redeemAs" binding:"omitempty,oneof=DISCOUNT PAYMENT"`
 }
 
 type TransferTable struct {
@@ -19,3 +22,11 @@ type TransferTable struct {
 type ApplyPromotion struct {
 	PromotionId string `json:"promotionId" binding:"required"`
 }
+
+type RedeemVoucher struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type AssignMember struct {
+	MemberId string `json:"memberId" binding:"required"`
+}
@@ -0,0 +1,19 @@
+package request
+
+type Voucher struct {
+	PromotionId        string `json:"promotionId" binding:"required"`
+	Code               string `json:"code"`
+	MaxUses            int    `json:"maxUses"`
+	MaxUsesPerCustomer int    `json:"maxUsesPerCustomer"`
+	ExpiresAt          string `json:"expiresAt"`
+	Status             string `json:"status"`
+}
+
+type VoucherBatch struct {
+	PromotionId        string `json:"promotionId" binding:"required"`
+	Quantity           int    `json:"quantity" binding:"required"`
+	Prefix             string `json:"prefix"`
+	MaxUses            int    `json:"maxUses"`
+	MaxUsesPerCustomer int    `json:"maxUsesPerCustomer"`
+	ExpiresAt          string `json:"expiresAt"`
+}
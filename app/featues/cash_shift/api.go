@@ -0,0 +1,269 @@
+package cash_shift
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"snook/app/core/billing"
+	"snook/app/core/constant"
+	"snook/app/core/errcode"
+	"snook/app/data/entities"
+	"snook/app/data/repositories"
+	"snook/app/domain"
+	"snook/app/domain/request"
+	"snook/middlewares"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func ApplyCashShiftAPI(route *gin.RouterGroup, repository *domain.Repository) {
+	r := route.Group("cash-shifts")
+
+	r.POST("/open", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		var req request.OpenCashShift
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CS_BAD_REQUEST_001, err.Error())
+			return
+		}
+		shift, err := repository.CashShift.OpenCashShift(entities.CashShift{
+			UserId: ctx.GetString("UserId"), OpeningFloat: math.Round(req.OpeningFloat*100) / 100,
+		})
+		if errors.Is(err, repositories.ErrShiftAlreadyOpen) {
+			errcode.Abort(ctx, http.StatusConflict, errcode.CS_CONFLICT_001, err.Error())
+			return
+		}
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CS_BAD_REQUEST_002, err.Error())
+			return
+		}
+		shift.ExpectedCash = shift.OpeningFloat
+		ctx.JSON(http.StatusCreated, shift)
+	})
+
+	r.GET("/current", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		shift, err := repository.CashShift.GetOpenCashShift(ctx.GetString("UserId"))
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			ctx.JSON(http.StatusOK, nil)
+			return
+		}
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.CS_INTERNAL_001, err.Error())
+			return
+		}
+		if !summarizeShift(ctx, repository, &shift, time.Now()) {
+			return
+		}
+		ctx.JSON(http.StatusOK, shift)
+	})
+
+	r.GET("", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			start, end, ok := dateRange(ctx)
+			if !ok {
+				return
+			}
+			shifts, err := repository.CashShift.GetCashShifts(start, end, ctx.Query("userId"), ctx.Query("status"))
+			if err != nil {
+				errcode.Abort(ctx, http.StatusInternalServerError, errcode.CS_INTERNAL_001, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusOK, shifts)
+		})
+
+	// The over/short report only counts closed shifts, whose figures are final.
+	r.GET("/report", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			start, end, ok := dateRange(ctx)
+			if !ok {
+				return
+			}
+			shifts, err := repository.CashShift.GetCashShifts(start, end, ctx.Query("userId"), "CLOSED")
+			if err != nil {
+				errcode.Abort(ctx, http.StatusInternalServerError, errcode.CS_INTERNAL_001, err.Error())
+				return
+			}
+			report := entities.CashShiftReport{
+				StartDate: start.Format("2006-01-02"), EndDate: end.Format("2006-01-02"),
+				Cashiers: []entities.CashierOverShort{}, Shifts: []entities.CashShift{},
+			}
+			cashiers := map[string]*entities.CashierOverShort{}
+			for _, s := range shifts {
+				c, found := cashiers[s.UserId]
+				if !found {
+					c = &entities.CashierOverShort{UserId: s.UserId}
+					cashiers[s.UserId] = c
+				}
+				c.Shifts++
+				c.ExpectedCash += s.ExpectedCash
+				if s.CountedCash != nil {
+					c.CountedCash += *s.CountedCash
+				}
+				if s.OverShort != nil {
+					c.OverShort += *s.OverShort
+					report.TotalOverShort += *s.OverShort
+				}
+				report.Shifts = append(report.Shifts, s)
+			}
+			for _, c := range cashiers {
+				c.ExpectedCash = math.Round(c.ExpectedCash*100) / 100
+				c.CountedCash = math.Round(c.CountedCash*100) / 100
+				c.OverShort = math.Round(c.OverShort*100) / 100
+				report.Cashiers = append(report.Cashiers, *c)
+			}
+			sort.Slice(report.Cashiers, func(i, j int) bool { return report.Cashiers[i].OverShort < report.Cashiers[j].OverShort })
+			report.TotalOverShort = math.Round(report.TotalOverShort*100) / 100
+			ctx.JSON(http.StatusOK, report)
+		})
+
+	r.GET("/:shiftId", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		shift, ok := getOwnShift(ctx, repository)
+		if !ok {
+			return
+		}
+		if shift.Status == "OPEN" && !summarizeShift(ctx, repository, &shift, time.Now()) {
+			return
+		}
+		ctx.JSON(http.StatusOK, shift)
+	})
+
+	r.POST("/:shiftId/movements", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		var req request.CashMovement
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CS_BAD_REQUEST_001, err.Error())
+			return
+		}
+		if strings.TrimSpace(req.Reason) == "" {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CS_BAD_REQUEST_001, "reason is required")
+			return
+		}
+		shift, ok := getOwnShift(ctx, repository)
+		if !ok {
+			return
+		}
+		movement, err := repository.CashShift.AddCashMovement(shift.Id, entities.CashMovement{
+			Type: req.Type, Amount: math.Round(req.Amount*100) / 100,
+			Reason: strings.TrimSpace(req.Reason), CreatedBy: ctx.GetString("UserId"),
+		})
+		if errors.Is(err, repositories.ErrShiftClosed) {
+			errcode.Abort(ctx, http.StatusConflict, errcode.CS_CONFLICT_002, err.Error())
+			return
+		}
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CS_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusCreated, movement)
+	})
+
+	r.POST("/:shiftId/close", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		var req request.CloseCashShift
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CS_BAD_REQUEST_001, err.Error())
+			return
+		}
+		shift, ok := getOwnShift(ctx, repository)
+		if !ok {
+			return
+		}
+		if shift.Status != "OPEN" {
+			errcode.Abort(ctx, http.StatusConflict, errcode.CS_CONFLICT_002, repositories.ErrShiftClosed.Error())
+			return
+		}
+		now := time.Now()
+		if !summarizeShift(ctx, repository, &shift, now) {
+			return
+		}
+		counted := math.Round(*req.CountedCash*100) / 100
+		overShort := math.Round((counted-shift.ExpectedCash)*100) / 100
+		shift.Status = "CLOSED"
+		shift.CountedCash = &counted
+		shift.OverShort = &overShort
+		shift.Note = req.Note
+		shift.ClosedBy = ctx.GetString("UserId")
+		shift.ClosedDate = &now
+		err := repository.CashShift.CloseCashShift(shift)
+		if errors.Is(err, repositories.ErrShiftClosed) {
+			errcode.Abort(ctx, http.StatusConflict, errcode.CS_CONFLICT_002, err.Error())
+			return
+		}
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CS_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, shift)
+	})
+}
+
+// getOwnShift loads the shift in the path. Cashiers may only reach their own
+// shifts; admins may reach any.
+func getOwnShift(ctx *gin.Context, repository *domain.Repository) (entities.CashShift, bool) {
+	id, err := primitive.ObjectIDFromHex(ctx.Param("shiftId"))
+	if err != nil {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.CS_BAD_REQUEST_001, "invalid shiftId")
+		return entities.CashShift{}, false
+	}
+	shift, err := repository.CashShift.GetCashShiftById(id)
+	if err != nil {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.CS_BAD_REQUEST_002, "shift not found")
+		return shift, false
+	}
+	role := ctx.GetString("Role")
+	if shift.UserId != ctx.GetString("UserId") && role != constant.SUPER && role != constant.ADMIN {
+		errcode.Abort(ctx, http.StatusForbidden, errcode.SY_FORBIDDEN_002, "shift belongs to another cashier")
+		return shift, false
+	}
+	return shift, true
+}
+
+// summarizeShift works out the cash expected in the drawer from the opening
+// float, the cashier's cash payments and cash expenses up to now, and the
+// pay-ins and pay-outs.
+func summarizeShift(ctx *gin.Context, repository *domain.Repository, shift *entities.CashShift, now time.Time) bool {
+	// Without saved settings the default payment methods apply.
+	setting, err := repository.Setting.GetSetting()
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		errcode.Abort(ctx, http.StatusInternalServerError, errcode.CS_INTERNAL_001, err.Error())
+		return false
+	}
+	cashCodes := billing.CashMethodCodes(billing.PaymentMethods(setting))
+	summary, err := repository.CashShift.SumShiftCash(shift.UserId, shift.OpenedDate, now, cashCodes)
+	if err != nil {
+		errcode.Abort(ctx, http.StatusInternalServerError, errcode.CS_INTERNAL_001, err.Error())
+		return false
+	}
+	for _, m := range shift.Movements {
+		if m.Type == "PAY_IN" {
+			summary.PayIns += m.Amount
+		} else {
+			summary.PayOuts += m.Amount
+		}
+	}
+	summary.CashSales = math.Round(summary.CashSales*100) / 100
+	summary.CreditorCash = math.Round(summary.CreditorCash*100) / 100
+	summary.CashExpenses = math.Round(summary.CashExpenses*100) / 100
+	summary.PayIns = math.Round(summary.PayIns*100) / 100
+	summary.PayOuts = math.Round(summary.PayOuts*100) / 100
+	summary.ExpectedCash = math.Round((shift.OpeningFloat+summary.CashSales+summary.CreditorCash+
+		summary.PayIns-summary.PayOuts-summary.CashExpenses)*100) / 100
+	shift.CashSummary = summary
+	return true
+}
+
+func dateRange(ctx *gin.Context) (time.Time, time.Time, bool) {
+	start, err := time.Parse("2006-01-02", ctx.DefaultQuery("startDate", time.Now().Format("2006-01-02")))
+	if err != nil {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.CS_BAD_REQUEST_001, "invalid startDate format")
+		return start, start, false
+	}
+	end, err := time.Parse("2006-01-02", ctx.DefaultQuery("endDate", start.Format("2006-01-02")))
+	if err != nil {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.CS_BAD_REQUEST_001, "invalid endDate format")
+		return start, end, false
+	}
+	return start, end.Add(24*time.Hour - time.Nanosecond), true
+}
@@ -2,6 +2,7 @@ package creditor
 
 import (
 	"net/http"
+	"snook/app/core/billing"
 	"snook/app/core/errcode"
 	"snook/app/data/entities"
 	"snook/app/domain"
@@ -53,7 +54,7 @@ func ApplyCreditorAPI(route *gin.RouterGroup, repository *domain.Repository) {
 		ctx.JSON(http.StatusOK, payments)
 	})
 
-	r.POST("/:creditorId/pay", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+	r.POST("/:creditorId/pay", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), middlewares.RequireOpenBusinessDay(repository.Setting, repository.DayClose), func(ctx *gin.Context) {
 		id, err := primitive.ObjectIDFromHex(ctx.Param("creditorId"))
 		if err != nil {
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.CR_BAD_REQUEST_001, "invalid creditorId")
@@ -77,8 +78,18 @@ func ApplyCreditorAPI(route *gin.RouterGroup, repository *domain.Repository) {
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.CR_BAD_REQUEST_001, "amount exceeds remaining balance")
 			return
 		}
+		setting, _ := repository.Setting.GetSetting()
+		method, err := billing.ResolvePaymentMethod(billing.PaymentMethods(setting), req.Type)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CR_BAD_REQUEST_001, err.Error())
+			return
+		}
+		if method.Code == "OUTSTANDING" {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CR_BAD_REQUEST_001, "a debt cannot be paid as outstanding")
+			return
+		}
 		payment := entities.CreditorPayment{
-			CreditorId: id, Amount: req.Amount, Type: req.Type,
+			CreditorId: id, Amount: req.Amount, Type: method.Code, Fee: billing.PaymentFee(method, req.Amount),
 			Note: req.Note, CreatedBy: ctx.GetString("UserId"),
 		}
 		_, err = repository.Creditor.CreateCreditorPayment(payment)
@@ -0,0 +1,222 @@
+package day_close
+
+import (
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"snook/app/core/businessday"
+	"snook/app/core/constant"
+	"snook/app/core/errcode"
+	"snook/app/data/entities"
+	"snook/app/data/repositories"
+	"snook/app/domain"
+	"snook/app/domain/request"
+	"snook/middlewares"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func ApplyDayCloseAPI(route *gin.RouterGroup, repository *domain.Repository) {
+	r := route.Group("day-closes")
+
+	r.GET("", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		setting, _ := repository.Setting.GetSetting()
+		today := businessday.Date(time.Now(), setting.CutoverHour)
+		dayCloses, err := repository.DayClose.GetDayCloses(ctx.DefaultQuery("startDate", today), ctx.DefaultQuery("endDate", today))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.DC_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, dayCloses)
+	})
+
+	// preview works out the figures of a day without closing it.
+	r.GET("/preview", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		setting, _ := repository.Setting.GetSetting()
+		date := ctx.DefaultQuery("businessDate", businessday.Date(time.Now(), setting.CutoverHour))
+		dayClose, err := buildDayClose(repository, date, setting.CutoverHour)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.DC_BAD_REQUEST_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, dayClose)
+	})
+
+	r.GET("/:businessDate", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		dayClose, err := repository.DayClose.GetDayCloseByDate(ctx.Param("businessDate"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.DC_BAD_REQUEST_002, "business day is not closed")
+			return
+		}
+		ctx.JSON(http.StatusOK, dayClose)
+	})
+
+	r.POST("", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			// The body is optional; an empty one closes the current day.
+			var req request.DayClose
+			if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.DC_BAD_REQUEST_001, err.Error())
+				return
+			}
+			setting, err := repository.Setting.GetSetting()
+			if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+				errcode.Abort(ctx, http.StatusInternalServerError, errcode.DC_INTERNAL_001, err.Error())
+				return
+			}
+			now := time.Now()
+			if req.BusinessDate == "" {
+				req.BusinessDate = businessday.Date(now, setting.CutoverHour)
+			}
+			start, end, err := businessday.Bounds(req.BusinessDate, setting.CutoverHour)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.DC_BAD_REQUEST_001, err.Error())
+				return
+			}
+			if start.After(now) {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.DC_BAD_REQUEST_001, "business day has not started yet")
+				return
+			}
+			closed, err := repository.DayClose.IsDayClosed(req.BusinessDate)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusInternalServerError, errcode.DC_INTERNAL_001, err.Error())
+				return
+			}
+			if closed {
+				errcode.Abort(ctx, http.StatusConflict, errcode.DC_CONFLICT_001, repositories.ErrDayClosed.Error())
+				return
+			}
+			open, err := repository.TableSession.CountOpenSessions(end)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusInternalServerError, errcode.DC_INTERNAL_001, err.Error())
+				return
+			}
+			if open > 0 {
+				errcode.Abort(ctx, http.StatusConflict, errcode.DC_CONFLICT_002, strconv.FormatInt(open, 10)+" sessions of the day are still open")
+				return
+			}
+			dayClose, err := buildDayClose(repository, req.BusinessDate, setting.CutoverHour)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.DC_BAD_REQUEST_002, err.Error())
+				return
+			}
+			dayClose.ClosedBy = ctx.GetString("UserId")
+			result, err := repository.DayClose.CreateDayClose(dayClose)
+			if errors.Is(err, repositories.ErrDayClosed) {
+				errcode.Abort(ctx, http.StatusConflict, errcode.DC_CONFLICT_001, err.Error())
+				return
+			}
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.DC_BAD_REQUEST_002, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusCreated, result)
+		})
+}
+
+// buildDayClose totals a business day: the sessions started in it, the
+// payments, refunds and voids booked in it and the expenses dated on it.
+func buildDayClose(repository *domain.Repository, date string, cutoverHour int) (entities.DayClose, error) {
+	start, end, err := businessday.Bounds(date, cutoverHour)
+	if err != nil {
+		return entities.DayClose{}, err
+	}
+	dayClose := entities.DayClose{
+		BusinessDate: date, StartTime: start, EndTime: end,
+		Payments: []entities.DayPaymentTotal{},
+	}
+
+	sessions, err := repository.TableSession.GetTableSessions(start, end)
+	if err != nil {
+		return dayClose, err
+	}
+	for _, s := range sessions {
+		if s.Status != "CLOSED" {
+			continue
+		}
+		dayClose.Sessions++
+		dayClose.TableCharge += s.TableCharge
+		dayClose.FoodTotal += s.FoodTotal
+		dayClose.Discount += s.Discount
+		dayClose.PromotionDiscount += s.PromotionDiscount
+		dayClose.PointsDiscount += s.PointsDiscount
+		dayClose.GrandTotal += s.GrandTotal
+	}
+
+	payments, err := repository.Payment.GetPaymentsByDateRange(start, end)
+	if err != nil {
+		return dayClose, err
+	}
+	byType := map[string]*entities.DayPaymentTotal{}
+	for _, p := range payments {
+		total, found := byType[p.Type]
+		if !found {
+			total = &entities.DayPaymentTotal{Type: p.Type}
+			byType[p.Type] = total
+		}
+		total.Count++
+		total.Amount += p.Amount
+		total.Fee += p.Fee
+		dayClose.PaymentTotal += p.Amount
+		dayClose.PaymentFees += p.Fee
+		if p.ReversalOf == nil {
+			continue
+		}
+		if p.Status == "VOID" {
+			dayClose.PaymentVoids.Count++
+			dayClose.PaymentVoids.Amount += p.Amount
+		} else {
+			dayClose.Refunds.Count++
+			dayClose.Refunds.Amount += p.Amount
+		}
+	}
+	for _, total := range byType {
+		total.Amount = round2(total.Amount)
+		total.Fee = round2(total.Fee)
+		dayClose.Payments = append(dayClose.Payments, *total)
+	}
+	sort.Slice(dayClose.Payments, func(i, j int) bool { return dayClose.Payments[i].Type < dayClose.Payments[j].Type })
+
+	voids, err := repository.TableOrder.GetVoidReport(start, end)
+	if err != nil {
+		return dayClose, err
+	}
+	for _, v := range voids {
+		dayClose.OrderVoids.Count += v.Voids + v.Reductions
+		dayClose.OrderVoids.Amount += v.Value
+	}
+
+	// Expenses carry a calendar date, which is their business date.
+	day, _ := time.Parse(businessday.Layout, date)
+	expenses, err := repository.Expense.GetExpenses(day, day.Add(24*time.Hour-time.Nanosecond))
+	if err != nil {
+		return dayClose, err
+	}
+	for _, e := range expenses {
+		dayClose.Expenses.Count++
+		dayClose.Expenses.Amount += e.Amount
+	}
+
+	dayClose.TableCharge = round2(dayClose.TableCharge)
+	dayClose.FoodTotal = round2(dayClose.FoodTotal)
+	dayClose.Discount = round2(dayClose.Discount)
+	dayClose.PromotionDiscount = round2(dayClose.PromotionDiscount)
+	dayClose.PointsDiscount = round2(dayClose.PointsDiscount)
+	dayClose.GrandTotal = round2(dayClose.GrandTotal)
+	dayClose.PaymentTotal = round2(dayClose.PaymentTotal)
+	dayClose.PaymentFees = round2(dayClose.PaymentFees)
+	dayClose.Refunds.Amount = round2(dayClose.Refunds.Amount)
+	dayClose.PaymentVoids.Amount = round2(dayClose.PaymentVoids.Amount)
+	dayClose.OrderVoids.Amount = round2(dayClose.OrderVoids.Amount)
+	dayClose.Expenses.Amount = round2(dayClose.Expenses.Amount)
+	return dayClose, nil
+}
+
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -2,6 +2,8 @@ package expense
 
 import (
 	"net/http"
+	"snook/app/core/billing"
+	"snook/app/core/businessday"
 	"snook/app/core/constant"
 	"snook/app/core/errcode"
 	"snook/app/data/entities"
@@ -37,10 +39,17 @@ func ApplyExpenseAPI(route *gin.RouterGroup, repository *domain.Repository) {
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.EX_BAD_REQUEST_001, err.Error())
 			return
 		}
+		paymentType, ok := expensePaymentType(ctx, repository, req.PaymentType)
+		if !ok {
+			return
+		}
 		date, _ := time.Parse("2006-01-02", req.Date)
+		if !expenseDayOpen(ctx, repository, date) {
+			return
+		}
 		expense := entities.Expense{
 			Category: req.Category, Description: req.Description,
-			Amount: req.Amount, Date: date, CreatedBy: ctx.GetString("UserId"),
+			Amount: req.Amount, PaymentType: paymentType, Date: date, CreatedBy: ctx.GetString("UserId"),
 		}
 		result, err := repository.Expense.CreateExpense(expense)
 		if err != nil {
@@ -61,10 +70,22 @@ func ApplyExpenseAPI(route *gin.RouterGroup, repository *domain.Repository) {
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.EX_BAD_REQUEST_001, err.Error())
 			return
 		}
+		paymentType, ok := expensePaymentType(ctx, repository, req.PaymentType)
+		if !ok {
+			return
+		}
 		date, _ := time.Parse("2006-01-02", req.Date)
+		current, err := repository.Expense.GetExpenseById(id)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.EX_BAD_REQUEST_002, "expense not found")
+			return
+		}
+		if !expenseDayOpen(ctx, repository, current.Date) || !expenseDayOpen(ctx, repository, date) {
+			return
+		}
 		expense := entities.Expense{
 			Category: req.Category, Description: req.Description,
-			Amount: req.Amount, Date: date, UpdatedBy: ctx.GetString("UserId"),
+			Amount: req.Amount, PaymentType: paymentType, Date: date, UpdatedBy: ctx.GetString("UserId"),
 		}
 		if err := repository.Expense.UpdateExpenseById(id, expense); err != nil {
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.EX_BAD_REQUEST_002, err.Error())
@@ -80,6 +101,14 @@ func ApplyExpenseAPI(route *gin.RouterGroup, repository *domain.Repository) {
 				errcode.Abort(ctx, http.StatusBadRequest, errcode.EX_BAD_REQUEST_001, "invalid expenseId")
 				return
 			}
+			current, err := repository.Expense.GetExpenseById(id)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.EX_BAD_REQUEST_002, "expense not found")
+				return
+			}
+			if !expenseDayOpen(ctx, repository, current.Date) {
+				return
+			}
 			if err := repository.Expense.DeleteExpenseById(id); err != nil {
 				errcode.Abort(ctx, http.StatusBadRequest, errcode.EX_BAD_REQUEST_002, err.Error())
 				return
@@ -87,3 +116,35 @@ func ApplyExpenseAPI(route *gin.RouterGroup, repository *domain.Repository) {
 			ctx.JSON(http.StatusOK, gin.H{"message": "success"})
 		})
 }
+
+// expensePaymentType checks how an expense was paid against the payment
+// methods catalog. Expenses paid with a cash method come out of the payer's
+// drawer.
+func expensePaymentType(ctx *gin.Context, repository *domain.Repository, code string) (string, bool) {
+	if code == "" {
+		return "", true
+	}
+	setting, _ := repository.Setting.GetSetting()
+	method, err := billing.ResolvePaymentMethod(billing.PaymentMethods(setting), code)
+	if err != nil {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.EX_BAD_REQUEST_001, err.Error())
+		return "", false
+	}
+	return method.Code, true
+}
+
+// expenseDayOpen refuses changes to expenses of a business day that has
+// been closed. An expense's date is its business date.
+func expenseDayOpen(ctx *gin.Context, repository *domain.Repository, date time.Time) bool {
+	businessDate := date.Format(businessday.Layout)
+	closed, err := repository.DayClose.IsDayClosed(businessDate)
+	if err != nil {
+		errcode.Abort(ctx, http.StatusInternalServerError, errcode.EX_INTERNAL_001, err.Error())
+		return false
+	}
+	if closed {
+		errcode.Abort(ctx, http.StatusConflict, errcode.DC_CONFLICT_001, "business day "+businessDate+" is closed")
+		return false
+	}
+	return true
+}
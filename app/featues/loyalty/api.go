@@ -0,0 +1,168 @@
+package loyalty
+
+import (
+	"errors"
+	"net/http"
+	"snook/app/core/constant"
+	"snook/app/core/errcode"
+	"snook/app/data/entities"
+	"snook/app/data/repositories"
+	"snook/app/domain"
+	"snook/app/domain/request"
+	"snook/middlewares"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func ApplyLoyaltyAPI(route *gin.RouterGroup, repository *domain.Repository) {
+	r := route.Group("loyalty")
+
+	r.GET("/members", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		members, err := repository.Loyalty.GetMembers(ctx.Query("keyword"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.LY_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, members)
+	})
+
+	r.GET("/members/phone/:phone", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		member, err := repository.Loyalty.GetMemberByPhone(ctx.Param("phone"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.LY_BAD_REQUEST_002, "member not found")
+			return
+		}
+		ctx.JSON(http.StatusOK, member)
+	})
+
+	r.GET("/members/:memberId", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		id, err := primitive.ObjectIDFromHex(ctx.Param("memberId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.LY_BAD_REQUEST_001, "invalid memberId")
+			return
+		}
+		member, err := repository.Loyalty.GetMemberById(id)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.LY_BAD_REQUEST_002, "member not found")
+			return
+		}
+		ctx.JSON(http.StatusOK, member)
+	})
+
+	r.POST("/members", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		var req request.Member
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.LY_BAD_REQUEST_001, err.Error())
+			return
+		}
+		if _, err := repository.Loyalty.GetMemberByPhone(req.Phone); err == nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.LY_BAD_REQUEST_002, "phone already registered")
+			return
+		}
+		status := req.Status
+		if status == "" {
+			status = "ACTIVE"
+		}
+		member := entities.Member{
+			Name: req.Name, Phone: req.Phone, Status: status,
+			Note: req.Note, CreatedBy: ctx.GetString("UserId"),
+		}
+		result, err := repository.Loyalty.CreateMember(member)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.LY_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusCreated, result)
+	})
+
+	r.PUT("/members/:memberId", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		id, err := primitive.ObjectIDFromHex(ctx.Param("memberId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.LY_BAD_REQUEST_001, "invalid memberId")
+			return
+		}
+		var req request.Member
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.LY_BAD_REQUEST_001, err.Error())
+			return
+		}
+		if existing, err := repository.Loyalty.GetMemberByPhone(req.Phone); err == nil && existing.Id != id {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.LY_BAD_REQUEST_002, "phone already registered")
+			return
+		}
+		member := entities.Member{
+			Name: req.Name, Phone: req.Phone, Status: req.Status,
+			Note: req.Note, UpdatedBy: ctx.GetString("UserId"),
+		}
+		if err := repository.Loyalty.UpdateMemberById(id, member); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.LY_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"message": "success"})
+	})
+
+	r.GET("/members/:memberId/transactions", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		id, err := primitive.ObjectIDFromHex(ctx.Param("memberId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.LY_BAD_REQUEST_001, "invalid memberId")
+			return
+		}
+		txns, err := repository.Loyalty.GetLoyaltyTransactions(id)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.LY_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, txns)
+	})
+
+	r.POST("/members/:memberId/adjust", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			id, err := primitive.ObjectIDFromHex(ctx.Param("memberId"))
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.LY_BAD_REQUEST_001, "invalid memberId")
+				return
+			}
+			var req request.LoyaltyAdjustment
+			if err := ctx.ShouldBindJSON(&req); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.LY_BAD_REQUEST_001, err.Error())
+				return
+			}
+			if _, err := repository.Loyalty.GetMemberById(id); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.LY_BAD_REQUEST_002, "member not found")
+				return
+			}
+			txn := entities.LoyaltyTransaction{
+				MemberId: id, Type: "ADJUST", Points: req.Points,
+				Reason: req.Reason, CreatedBy: ctx.GetString("UserId"),
+			}
+			if req.Points > 0 {
+				setting, _ := repository.Setting.GetSetting()
+				if setting.Loyalty.ExpiryDays > 0 {
+					expiresAt := time.Now().AddDate(0, 0, setting.Loyalty.ExpiryDays)
+					txn.ExpiresAt = &expiresAt
+				}
+			}
+			result, err := repository.Loyalty.PostLoyaltyTransaction(txn)
+			if errors.Is(err, repositories.ErrInsufficientPoints) {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.LY_BAD_REQUEST_002, "adjustment exceeds member balance")
+				return
+			}
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.LY_BAD_REQUEST_002, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusCreated, result)
+		})
+
+	r.POST("/expire", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			expired, err := repository.Loyalty.ExpirePoints(nil, time.Now())
+			if err != nil {
+				errcode.Abort(ctx, http.StatusInternalServerError, errcode.LY_INTERNAL_001, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusOK, gin.H{"expiredPoints": expired})
+		})
+}
@@ -1,14 +1,20 @@
 package menu
 
 import (
+	"errors"
 	"net/http"
+	"snook/app/core/billing"
 	"snook/app/core/constant"
 	"snook/app/core/errcode"
+	"snook/app/core/media"
 	"snook/app/data/entities"
+	"snook/app/data/repositories"
 	"snook/app/domain"
 	"snook/app/domain/request"
 	"snook/middlewares"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -34,7 +40,10 @@ func ApplyMenuAPI(route *gin.RouterGroup, repository *domain.Repository) {
 				errcode.Abort(ctx, http.StatusBadRequest, errcode.MC_BAD_REQUEST_001, err.Error())
 				return
 			}
-			cat := entities.MenuCategory{Name: req.Name, SortOrder: req.SortOrder, CreatedBy: ctx.GetString("UserId")}
+			cat := entities.MenuCategory{
+				Name: req.Name, SortOrder: req.SortOrder, Station: stationOf(req.Station),
+				TaxClass: req.TaxClass, Hidden: req.Hidden, CreatedBy: ctx.GetString("UserId"),
+			}
 			result, err := repository.MenuCategory.CreateMenuCategory(cat)
 			if err != nil {
 				errcode.Abort(ctx, http.StatusBadRequest, errcode.MC_BAD_REQUEST_002, err.Error())
@@ -55,7 +64,10 @@ func ApplyMenuAPI(route *gin.RouterGroup, repository *domain.Repository) {
 				errcode.Abort(ctx, http.StatusBadRequest, errcode.MC_BAD_REQUEST_001, err.Error())
 				return
 			}
-			cat := entities.MenuCategory{Name: req.Name, SortOrder: req.SortOrder, UpdatedBy: ctx.GetString("UserId")}
+			cat := entities.MenuCategory{
+				Name: req.Name, SortOrder: req.SortOrder, Station: stationOf(req.Station),
+				TaxClass: req.TaxClass, Hidden: req.Hidden, UpdatedBy: ctx.GetString("UserId"),
+			}
 			if err := repository.MenuCategory.UpdateMenuCategoryById(id, cat); err != nil {
 				errcode.Abort(ctx, http.StatusBadRequest, errcode.MC_BAD_REQUEST_002, err.Error())
 				return
@@ -70,24 +82,63 @@ func ApplyMenuAPI(route *gin.RouterGroup, repository *domain.Repository) {
 				errcode.Abort(ctx, http.StatusBadRequest, errcode.MC_BAD_REQUEST_001, "invalid categoryId")
 				return
 			}
-			if err := repository.MenuCategory.DeleteMenuCategoryById(id); err != nil {
+			err = repository.MenuCategory.DeleteMenuCategoryById(id)
+			if errors.Is(err, repositories.ErrMenuCategoryInUse) {
+				errcode.Abort(ctx, http.StatusConflict, errcode.MC_CONFLICT_001, err.Error())
+				return
+			}
+			if err != nil {
 				errcode.Abort(ctx, http.StatusBadRequest, errcode.MC_BAD_REQUEST_002, err.Error())
 				return
 			}
 			ctx.JSON(http.StatusOK, gin.H{"message": "success"})
 		})
 
+	catRoute.POST("/migrate", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			result, err := repository.MenuCategory.MigrateMenuItemCategories(ctx.GetString("UserId"))
+			if err != nil {
+				errcode.Abort(ctx, http.StatusInternalServerError, errcode.MC_INTERNAL_001, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusOK, result)
+		})
+
+	// ─── Ingredients ────────────────────────────────
+	applyIngredientAPI(route, repository)
+
+	// ─── Stock Takes ────────────────────────────────
+	applyStockTakeAPI(route, repository)
+
+	// ─── Import / Export ────────────────────────────
+	applyMenuTransferAPI(route, repository)
+
 	// ─── Menu Items ─────────────────────────────────
 	itemRoute := route.Group("menu-items")
 
 	itemRoute.GET("", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
 		category := ctx.Query("category")
+		if categoryId := ctx.Query("categoryId"); categoryId != "" {
+			cat, err := resolveCategory(repository, categoryId, "")
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, err.Error())
+				return
+			}
+			category = cat.Name
+		}
 		items, err := repository.MenuItem.GetMenuItems(category)
 		if err != nil {
 			errcode.Abort(ctx, http.StatusInternalServerError, errcode.MI_INTERNAL_001, err.Error())
 			return
 		}
-		ctx.JSON(http.StatusOK, items)
+		if ctx.Query("includeHidden") != "true" {
+			items = visibleMenuItems(repository, items)
+		}
+		items = withMenuPricing(repository, items)
+		if ctx.Query("includeUnavailable") != "true" {
+			items = availableMenuItems(items)
+		}
+		ctx.JSON(http.StatusOK, withRecipeStock(repository.Ingredient, items))
 	})
 
 	itemRoute.GET("/:itemId", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
@@ -101,7 +152,7 @@ func ApplyMenuAPI(route *gin.RouterGroup, repository *domain.Repository) {
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_002, err.Error())
 			return
 		}
-		ctx.JSON(http.StatusOK, item)
+		ctx.JSON(http.StatusOK, withRecipeStock(repository.Ingredient, withMenuPricing(repository, []entities.MenuItem{item}))[0])
 	})
 
 	itemRoute.POST("", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
@@ -115,17 +166,18 @@ func ApplyMenuAPI(route *gin.RouterGroup, repository *domain.Repository) {
 			if status == "" {
 				status = "ACTIVE"
 			}
-			item := entities.MenuItem{
-				Name: req.Name, Category: req.Category, Price: req.Price,
-				CostPrice: req.CostPrice, Quantity: req.Quantity, Unit: req.Unit,
-				Status: status, ImageUrl: req.ImageUrl, CreatedBy: ctx.GetString("UserId"),
-			}
-			result, err := repository.MenuItem.CreateMenuItem(item)
+			cat, err := resolveCategory(repository, req.CategoryId, req.Category)
 			if err != nil {
-				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_002, err.Error())
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, err.Error())
 				return
 			}
-			ctx.JSON(http.StatusCreated, result)
+			item := entities.MenuItem{
+				Name: req.Name, CategoryId: &cat.Id, Category: cat.Name, Price: req.Price,
+				CostPrice: req.CostPrice, Unit: req.Unit,
+				Sku: strings.TrimSpace(req.Sku), Barcode: strings.TrimSpace(req.Barcode),
+				Status: status, ImageUrl: req.ImageUrl, CreatedBy: ctx.GetString("UserId"),
+			}
+			createMenuItem(ctx, repository, item, req.Quantity)
 		})
 
 	itemRoute.PUT("/:itemId", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
@@ -140,15 +192,51 @@ func ApplyMenuAPI(route *gin.RouterGroup, repository *domain.Repository) {
 				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, err.Error())
 				return
 			}
+			current, err := repository.MenuItem.GetMenuItemById(id)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_002, "menu item not found")
+				return
+			}
+			cat, err := resolveCategory(repository, req.CategoryId, req.Category)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, err.Error())
+				return
+			}
 			item := entities.MenuItem{
-				Name: req.Name, Category: req.Category, Price: req.Price,
-				CostPrice: req.CostPrice, Quantity: req.Quantity, Unit: req.Unit,
+				Name: req.Name, CategoryId: &cat.Id, Category: cat.Name, Price: req.Price,
+				CostPrice: req.CostPrice, Unit: req.Unit, VariantName: current.VariantName,
+				Sku: strings.TrimSpace(req.Sku), Barcode: strings.TrimSpace(req.Barcode),
 				Status: req.Status, ImageUrl: req.ImageUrl, UpdatedBy: ctx.GetString("UserId"),
 			}
-			if err := repository.MenuItem.UpdateMenuItemById(id, item); err != nil {
+			// The uploaded thumbnail only belongs to the image it was made from.
+			if item.ImageUrl == current.ImageUrl {
+				item.ThumbnailUrl = current.ThumbnailUrl
+			}
+			// Variants keep the name and category of their parent.
+			if current.ParentId != nil {
+				item.Name = current.Name
+				item.CategoryId = current.CategoryId
+				item.Category = current.Category
+			}
+			err = repository.MenuItem.UpdateMenuItemById(id, item)
+			if errors.Is(err, repositories.ErrDuplicateItemCode) {
+				errcode.Abort(ctx, http.StatusConflict, errcode.MI_CONFLICT_001, err.Error())
+				return
+			}
+			if err != nil {
 				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_002, err.Error())
 				return
 			}
+			// Quantity edits from the item form are recorded as a stock count.
+			if req.Quantity != current.Quantity {
+				_, err := repository.MenuItem.RecordStockCount(entities.StockMovement{
+					MenuItemId: id, Type: "COUNT", Reason: "menu item update", CreatedBy: item.UpdatedBy,
+				}, req.Quantity)
+				if err != nil {
+					errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_002, err.Error())
+					return
+				}
+			}
 			ctx.JSON(http.StatusOK, gin.H{"message": "success"})
 		})
 
@@ -159,10 +247,21 @@ func ApplyMenuAPI(route *gin.RouterGroup, repository *domain.Repository) {
 				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, "invalid itemId")
 				return
 			}
-			if err := repository.MenuItem.DeleteMenuItemById(id); err != nil {
+			current, err := repository.MenuItem.GetMenuItemById(id)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_002, "menu item not found")
+				return
+			}
+			err = repository.MenuItem.DeleteMenuItemById(id)
+			if errors.Is(err, repositories.ErrMenuItemHasVariant) {
+				errcode.Abort(ctx, http.StatusConflict, errcode.MI_CONFLICT_001, err.Error())
+				return
+			}
+			if err != nil {
 				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_002, err.Error())
 				return
 			}
+			removeMenuImage(ctx, repository.Storage, repository.MenuItem, current)
 			ctx.JSON(http.StatusOK, gin.H{"message": "success"})
 		})
 
@@ -178,13 +277,267 @@ func ApplyMenuAPI(route *gin.RouterGroup, repository *domain.Repository) {
 				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, err.Error())
 				return
 			}
-			if err := repository.MenuItem.UpdateMenuItemQuantity(id, req.Quantity); err != nil {
+			movement := entities.StockMovement{
+				MenuItemId: id, Type: req.Type, Quantity: *req.Quantity,
+				Reason: req.Reason, CreatedBy: ctx.GetString("UserId"),
+			}
+			if movement.Type == "" {
+				movement.Type = "ADJUSTMENT"
+			}
+			if err := validateStockMovement(&movement); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, err.Error())
+				return
+			}
+			var result entities.StockMovement
+			if movement.Type == "COUNT" {
+				result, err = repository.MenuItem.RecordStockCount(movement, *req.Quantity)
+			} else {
+				result, err = repository.MenuItem.PostStockMovement(movement)
+			}
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_002, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusOK, result)
+		})
+
+	itemRoute.GET("/lookup", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		code := strings.TrimSpace(ctx.Query("code"))
+		if code == "" {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, "code is required")
+			return
+		}
+		item, err := repository.MenuItem.GetMenuItemByCode(code)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_002, "no menu item with this code")
+			return
+		}
+		ctx.JSON(http.StatusOK, withRecipeStock(repository.Ingredient, withMenuPricing(repository, []entities.MenuItem{item}))[0])
+	})
+
+	itemRoute.GET("/:itemId/variants", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		id, err := primitive.ObjectIDFromHex(ctx.Param("itemId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, "invalid itemId")
+			return
+		}
+		variants, err := repository.MenuItem.GetMenuItemVariants(id)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.MI_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, withRecipeStock(repository.Ingredient, withMenuPricing(repository, variants)))
+	})
+
+	itemRoute.POST("/:itemId/variants", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			id, err := primitive.ObjectIDFromHex(ctx.Param("itemId"))
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, "invalid itemId")
+				return
+			}
+			var req request.MenuItemVariant
+			if err := ctx.ShouldBindJSON(&req); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, err.Error())
+				return
+			}
+			parent, err := repository.MenuItem.GetMenuItemById(id)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_002, "menu item not found")
+				return
+			}
+			if parent.ParentId != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, "a variant cannot have variants")
+				return
+			}
+			status := req.Status
+			if status == "" {
+				status = "ACTIVE"
+			}
+			unit := req.Unit
+			if unit == "" {
+				unit = parent.Unit
+			}
+			item := entities.MenuItem{
+				Name: parent.Name, CategoryId: parent.CategoryId, Category: parent.Category, ParentId: &parent.Id, VariantName: req.VariantName,
+				Price: req.Price, CostPrice: req.CostPrice, Unit: unit,
+				Sku: strings.TrimSpace(req.Sku), Barcode: strings.TrimSpace(req.Barcode),
+				Status: status, ImageUrl: parent.ImageUrl, CreatedBy: ctx.GetString("UserId"),
+			}
+			createMenuItem(ctx, repository, item, req.Quantity)
+		})
+
+	itemRoute.PUT("/:itemId/availability", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			id, err := primitive.ObjectIDFromHex(ctx.Param("itemId"))
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, "invalid itemId")
+				return
+			}
+			var req request.MenuItemAvailability
+			if err := ctx.ShouldBindJSON(&req); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, err.Error())
+				return
+			}
+			windows := []entities.AvailabilityWindow{}
+			for _, w := range req.Windows {
+				if err := billing.ValidateDaysOfWeek(w.DaysOfWeek); err != nil {
+					errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, err.Error())
+					return
+				}
+				if err := billing.ValidateTimeWindow(w.StartTimeOfDay, w.EndTimeOfDay); err != nil {
+					errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, err.Error())
+					return
+				}
+				windows = append(windows, entities.AvailabilityWindow{
+					DaysOfWeek: w.DaysOfWeek, StartTimeOfDay: w.StartTimeOfDay, EndTimeOfDay: w.EndTimeOfDay,
+				})
+			}
+			if err := repository.MenuItem.UpdateMenuItemAvailability(id, windows, ctx.GetString("UserId")); err != nil {
 				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_002, err.Error())
 				return
 			}
 			ctx.JSON(http.StatusOK, gin.H{"message": "success"})
 		})
 
+	itemRoute.PUT("/:itemId/recipe", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			id, err := primitive.ObjectIDFromHex(ctx.Param("itemId"))
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, "invalid itemId")
+				return
+			}
+			var req request.Recipe
+			if err := ctx.ShouldBindJSON(&req); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, err.Error())
+				return
+			}
+			recipe, err := toRecipe(repository.Ingredient, req)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, err.Error())
+				return
+			}
+			if err := repository.MenuItem.UpdateMenuItemRecipe(id, recipe, ctx.GetString("UserId")); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_002, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusOK, gin.H{"message": "success"})
+		})
+
+	itemRoute.PUT("/:itemId/modifiers", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			id, err := primitive.ObjectIDFromHex(ctx.Param("itemId"))
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, "invalid itemId")
+				return
+			}
+			var req request.ModifierGroups
+			if err := ctx.ShouldBindJSON(&req); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, err.Error())
+				return
+			}
+			groups, err := toModifierGroups(req)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, err.Error())
+				return
+			}
+			if err := repository.MenuItem.UpdateMenuItemModifiers(id, groups, ctx.GetString("UserId")); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_002, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusOK, gin.H{"message": "success"})
+		})
+
+	itemRoute.POST("/:itemId/image", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			id, err := primitive.ObjectIDFromHex(ctx.Param("itemId"))
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, "invalid itemId")
+				return
+			}
+			current, err := repository.MenuItem.GetMenuItemById(id)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_002, "menu item not found")
+				return
+			}
+			data, err := readImageUpload(ctx)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, err.Error())
+				return
+			}
+			img, err := media.ProcessImage(data)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, err.Error())
+				return
+			}
+			imageUrl, thumbnailUrl, err := storeMenuImage(ctx, repository.Storage, id, img)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusInternalServerError, errcode.MI_INTERNAL_001, err.Error())
+				return
+			}
+			if err := repository.MenuItem.UpdateMenuItemImage(id, imageUrl, thumbnailUrl, ctx.GetString("UserId")); err != nil {
+				removeMenuImage(ctx, repository.Storage, repository.MenuItem, entities.MenuItem{ImageUrl: imageUrl, ThumbnailUrl: thumbnailUrl})
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_002, err.Error())
+				return
+			}
+			removeMenuImage(ctx, repository.Storage, repository.MenuItem, current)
+			ctx.JSON(http.StatusOK, gin.H{"imageUrl": imageUrl, "thumbnailUrl": thumbnailUrl})
+		})
+
+	itemRoute.DELETE("/:itemId/image", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			id, err := primitive.ObjectIDFromHex(ctx.Param("itemId"))
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, "invalid itemId")
+				return
+			}
+			current, err := repository.MenuItem.GetMenuItemById(id)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_002, "menu item not found")
+				return
+			}
+			if err := repository.MenuItem.UpdateMenuItemImage(id, "", "", ctx.GetString("UserId")); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_002, err.Error())
+				return
+			}
+			removeMenuImage(ctx, repository.Storage, repository.MenuItem, current)
+			ctx.JSON(http.StatusOK, gin.H{"message": "success"})
+		})
+
+	itemRoute.GET("/:itemId/movements", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		id, err := primitive.ObjectIDFromHex(ctx.Param("itemId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, "invalid itemId")
+			return
+		}
+		movements, err := repository.MenuItem.GetStockMovements(id)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.MI_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, movements)
+	})
+
+	itemRoute.GET("/stock/reconcile", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			rows, err := repository.MenuItem.GetStockReconciliation()
+			if err != nil {
+				errcode.Abort(ctx, http.StatusInternalServerError, errcode.MI_INTERNAL_001, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusOK, rows)
+		})
+
+	itemRoute.POST("/stock/reconcile", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			rows, err := repository.MenuItem.ReconcileStock()
+			if err != nil {
+				errcode.Abort(ctx, http.StatusInternalServerError, errcode.MI_INTERNAL_001, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusOK, rows)
+		})
+
 	itemRoute.GET("/low-stock", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
 		threshold := 10
 		if t := ctx.Query("threshold"); t != "" {
@@ -200,3 +553,144 @@ func ApplyMenuAPI(route *gin.RouterGroup, repository *domain.Repository) {
 		ctx.JSON(http.StatusOK, items)
 	})
 }
+
+// createMenuItem creates the item and books its opening quantity in the
+// stock ledger.
+func createMenuItem(ctx *gin.Context, repository *domain.Repository, item entities.MenuItem, quantity int) {
+	result, err := repository.MenuItem.CreateMenuItem(item)
+	if errors.Is(err, repositories.ErrDuplicateItemCode) {
+		errcode.Abort(ctx, http.StatusConflict, errcode.MI_CONFLICT_001, err.Error())
+		return
+	}
+	if err != nil {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_002, err.Error())
+		return
+	}
+	if quantity != 0 {
+		movement, err := repository.MenuItem.PostStockMovement(entities.StockMovement{
+			MenuItemId: result.Id, Type: "ADJUSTMENT", Quantity: quantity,
+			Reason: "opening stock", CreatedBy: result.CreatedBy,
+		})
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_002, err.Error())
+			return
+		}
+		result.Quantity = movement.BalanceAfter
+	}
+	ctx.JSON(http.StatusCreated, result)
+}
+
+// resolveCategory finds the category of a menu item by id, or by name for
+// clients that still send the category name.
+func resolveCategory(repository *domain.Repository, categoryId, name string) (entities.MenuCategory, error) {
+	if categoryId != "" {
+		id, err := primitive.ObjectIDFromHex(categoryId)
+		if err != nil {
+			return entities.MenuCategory{}, errors.New("invalid categoryId")
+		}
+		cat, err := repository.MenuCategory.GetMenuCategoryById(id)
+		if err != nil {
+			return cat, errors.New("menu category not found")
+		}
+		return cat, nil
+	}
+	cat, err := repository.MenuCategory.GetMenuCategoryByName(name)
+	if err != nil {
+		return cat, errors.New("menu category not found")
+	}
+	return cat, nil
+}
+
+// visibleMenuItems leaves out items of categories hidden from the menu.
+func visibleMenuItems(repository *domain.Repository, items []entities.MenuItem) []entities.MenuItem {
+	cats, err := repository.MenuCategory.GetMenuCategories()
+	if err != nil {
+		return items
+	}
+	hiddenIds := map[primitive.ObjectID]bool{}
+	hiddenNames := map[string]bool{}
+	for _, cat := range cats {
+		if cat.Hidden {
+			hiddenIds[cat.Id] = true
+			hiddenNames[cat.Name] = true
+		}
+	}
+	if len(hiddenIds) == 0 {
+		return items
+	}
+	visible := []entities.MenuItem{}
+	for _, item := range items {
+		if item.CategoryId != nil && hiddenIds[*item.CategoryId] || item.CategoryId == nil && hiddenNames[item.Category] {
+			continue
+		}
+		visible = append(visible, item)
+	}
+	return visible
+}
+
+// withMenuPricing marks whether each item is on sale now and replaces its
+// price with the one from the price list in effect.
+func withMenuPricing(repository *domain.Repository, items []entities.MenuItem) []entities.MenuItem {
+	now := time.Now()
+	lists, _ := repository.PriceList.GetCurrentPriceLists(now)
+	for i := range items {
+		items[i].Available = billing.AvailableAt(items[i].Availability, now)
+		price, priceListId := billing.MenuItemPrice(items[i], lists, now)
+		if priceListId != nil {
+			items[i].RegularPrice = items[i].Price
+			items[i].Price = price
+			items[i].PriceListId = priceListId
+		}
+	}
+	return items
+}
+
+func availableMenuItems(items []entities.MenuItem) []entities.MenuItem {
+	available := []entities.MenuItem{}
+	for _, item := range items {
+		if item.Available {
+			available = append(available, item)
+		}
+	}
+	return available
+}
+
+// stationOf defaults categories without a station to the kitchen.
+func stationOf(station string) string {
+	if station == "" {
+		return "KITCHEN"
+	}
+	return station
+}
+
+// validateStockMovement checks a manual stock change. Sales and returns are
+// only written by orders. Waste is always taken off stock.
+func validateStockMovement(movement *entities.StockMovement) error {
+	switch movement.Type {
+	case "ADJUSTMENT":
+		if movement.Quantity == 0 {
+			return errors.New("quantity must not be 0")
+		}
+		if movement.Reason == "" {
+			return errors.New("reason is required for adjustments")
+		}
+	case "PURCHASE":
+		if movement.Quantity <= 0 {
+			return errors.New("purchase quantity must be greater than 0")
+		}
+	case "WASTE":
+		if movement.Quantity == 0 {
+			return errors.New("quantity must not be 0")
+		}
+		if movement.Quantity > 0 {
+			movement.Quantity = -movement.Quantity
+		}
+	case "COUNT":
+		if movement.Quantity < 0 {
+			return errors.New("counted quantity must not be negative")
+		}
+	default:
+		return errors.New("type must be ADJUSTMENT, PURCHASE, WASTE or COUNT")
+	}
+	return nil
+}
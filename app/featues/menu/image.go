@@ -0,0 +1,76 @@
+package menu
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"snook/app/core/media"
+	"snook/app/core/storage"
+	"snook/app/data/entities"
+	"snook/app/data/repositories"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// readImageUpload reads the "image" file of a multipart request, refusing
+// bodies larger than the image limit before they are buffered.
+func readImageUpload(ctx *gin.Context) ([]byte, error) {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, media.MaxImageBytes+1<<20)
+	header, err := ctx.FormFile("image")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, fmt.Errorf("image must not be larger than %d MB", media.MaxImageBytes>>20)
+		}
+		return nil, errors.New("image file is required")
+	}
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(io.LimitReader(file, media.MaxImageBytes+1))
+}
+
+// storeMenuImage saves the image and its thumbnail under a fresh key so
+// cached copies of a previous image are never served for the new one.
+func storeMenuImage(ctx context.Context, store storage.Storage, itemId primitive.ObjectID, img media.Image) (string, string, error) {
+	base := fmt.Sprintf("menu-items/%s/%s", itemId.Hex(), primitive.NewObjectID().Hex())
+	imageUrl, err := store.Save(ctx, base+img.Ext, img.ContentType, bytes.NewReader(img.Data))
+	if err != nil {
+		return "", "", err
+	}
+	thumbnailUrl, err := store.Save(ctx, base+"_thumb"+img.ThumbnailExt, img.ThumbnailContentType, bytes.NewReader(img.Thumbnail))
+	if err != nil {
+		_ = removeStoredFile(ctx, store, imageUrl)
+		return "", "", err
+	}
+	return imageUrl, thumbnailUrl, nil
+}
+
+// removeMenuImage deletes the uploaded files of an item once it no longer
+// uses them. Files another item still points to, such as a parent image its
+// variants share, and images hosted elsewhere are left alone.
+func removeMenuImage(ctx context.Context, store storage.Storage, menuItemEntity repositories.IMenuItem, item entities.MenuItem) {
+	for _, url := range []string{item.ImageUrl, item.ThumbnailUrl} {
+		if url == "" {
+			continue
+		}
+		if refs, err := menuItemEntity.CountImageReferences(url); err != nil || refs > 0 {
+			continue
+		}
+		_ = removeStoredFile(ctx, store, url)
+	}
+}
+
+func removeStoredFile(ctx context.Context, store storage.Storage, url string) error {
+	key, ok := store.KeyOf(url)
+	if !ok {
+		return nil
+	}
+	return store.Delete(ctx, key)
+}
@@ -0,0 +1,264 @@
+package menu
+
+import (
+	"errors"
+	"net/http"
+	"snook/app/core/constant"
+	"snook/app/core/errcode"
+	"snook/app/core/inventory"
+	"snook/app/data/entities"
+	"snook/app/data/repositories"
+	"snook/app/domain"
+	"snook/app/domain/request"
+	"snook/middlewares"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func applyIngredientAPI(route *gin.RouterGroup, repository *domain.Repository) {
+	r := route.Group("ingredients")
+
+	r.GET("", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		ingredients, err := repository.Ingredient.GetIngredients()
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.IG_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, ingredients)
+	})
+
+	r.GET("/low-stock", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		ingredients, err := repository.Ingredient.GetLowStockIngredients()
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.IG_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, ingredients)
+	})
+
+	r.GET("/:ingredientId", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		id, err := primitive.ObjectIDFromHex(ctx.Param("ingredientId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.IG_BAD_REQUEST_001, "invalid ingredientId")
+			return
+		}
+		ingredient, err := repository.Ingredient.GetIngredientById(id)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.IG_BAD_REQUEST_002, "ingredient not found")
+			return
+		}
+		ctx.JSON(http.StatusOK, ingredient)
+	})
+
+	r.POST("", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			var req request.Ingredient
+			if err := ctx.ShouldBindJSON(&req); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.IG_BAD_REQUEST_001, err.Error())
+				return
+			}
+			status := req.Status
+			if status == "" {
+				status = "ACTIVE"
+			}
+			ingredient := entities.Ingredient{
+				Name: req.Name, Unit: req.Unit, CostPrice: req.CostPrice,
+				LowStockLevel: req.LowStockLevel, Status: status, CreatedBy: ctx.GetString("UserId"),
+			}
+			result, err := repository.Ingredient.CreateIngredient(ingredient)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.IG_BAD_REQUEST_002, err.Error())
+				return
+			}
+			if req.Quantity != 0 {
+				movement, err := repository.Ingredient.PostIngredientMovement(entities.IngredientMovement{
+					IngredientId: result.Id, Type: "ADJUSTMENT", Quantity: req.Quantity,
+					Reason: "opening stock", CreatedBy: result.CreatedBy,
+				})
+				if err != nil {
+					errcode.Abort(ctx, http.StatusBadRequest, errcode.IG_BAD_REQUEST_002, err.Error())
+					return
+				}
+				result.Quantity = movement.BalanceAfter
+			}
+			ctx.JSON(http.StatusCreated, result)
+		})
+
+	r.PUT("/:ingredientId", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			id, err := primitive.ObjectIDFromHex(ctx.Param("ingredientId"))
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.IG_BAD_REQUEST_001, "invalid ingredientId")
+				return
+			}
+			var req request.Ingredient
+			if err := ctx.ShouldBindJSON(&req); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.IG_BAD_REQUEST_001, err.Error())
+				return
+			}
+			ingredient := entities.Ingredient{
+				Name: req.Name, Unit: req.Unit, CostPrice: req.CostPrice,
+				LowStockLevel: req.LowStockLevel, Status: req.Status, UpdatedBy: ctx.GetString("UserId"),
+			}
+			if err := repository.Ingredient.UpdateIngredientById(id, ingredient); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.IG_BAD_REQUEST_002, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusOK, gin.H{"message": "success"})
+		})
+
+	r.DELETE("/:ingredientId", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			id, err := primitive.ObjectIDFromHex(ctx.Param("ingredientId"))
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.IG_BAD_REQUEST_001, "invalid ingredientId")
+				return
+			}
+			if err := repository.Ingredient.DeleteIngredientById(id); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.IG_BAD_REQUEST_002, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusOK, gin.H{"message": "success"})
+		})
+
+	r.PATCH("/:ingredientId/quantity", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			id, err := primitive.ObjectIDFromHex(ctx.Param("ingredientId"))
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.IG_BAD_REQUEST_001, "invalid ingredientId")
+				return
+			}
+			var req request.IngredientQuantity
+			if err := ctx.ShouldBindJSON(&req); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.IG_BAD_REQUEST_001, err.Error())
+				return
+			}
+			movement := entities.IngredientMovement{
+				IngredientId: id, Type: req.Type, Quantity: *req.Quantity, UnitCost: req.UnitCost,
+				Reason: req.Reason, CreatedBy: ctx.GetString("UserId"),
+			}
+			if movement.Type == "" {
+				movement.Type = "ADJUSTMENT"
+			}
+			if err := validateIngredientMovement(&movement); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.IG_BAD_REQUEST_001, err.Error())
+				return
+			}
+			var result entities.IngredientMovement
+			if movement.Type == "COUNT" {
+				result, err = repository.Ingredient.RecordIngredientCount(movement, *req.Quantity)
+			} else {
+				result, err = repository.Ingredient.PostIngredientMovement(movement)
+			}
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.IG_BAD_REQUEST_002, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusOK, result)
+		})
+
+	r.GET("/:ingredientId/movements", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		id, err := primitive.ObjectIDFromHex(ctx.Param("ingredientId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.IG_BAD_REQUEST_001, "invalid ingredientId")
+			return
+		}
+		movements, err := repository.Ingredient.GetIngredientMovements(id)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.IG_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, movements)
+	})
+}
+
+// validateIngredientMovement applies the same rules as menu item stock changes.
+func validateIngredientMovement(movement *entities.IngredientMovement) error {
+	switch movement.Type {
+	case "ADJUSTMENT":
+		if movement.Quantity == 0 {
+			return errors.New("quantity must not be 0")
+		}
+		if movement.Reason == "" {
+			return errors.New("reason is required for adjustments")
+		}
+	case "PURCHASE":
+		if movement.Quantity <= 0 || movement.UnitCost < 0 {
+			return errors.New("purchase quantity must be greater than 0")
+		}
+	case "WASTE":
+		if movement.Quantity == 0 {
+			return errors.New("quantity must not be 0")
+		}
+		if movement.Quantity > 0 {
+			movement.Quantity = -movement.Quantity
+		}
+	case "COUNT":
+		if movement.Quantity < 0 {
+			return errors.New("counted quantity must not be negative")
+		}
+	default:
+		return errors.New("type must be ADJUSTMENT, PURCHASE, WASTE or COUNT")
+	}
+	return nil
+}
+
+// toRecipe resolves the recipe lines against the ingredient catalogue.
+func toRecipe(ingredientEntity repositories.IIngredient, req request.Recipe) ([]entities.RecipeLine, error) {
+	recipe := []entities.RecipeLine{}
+	seen := map[primitive.ObjectID]bool{}
+	for _, line := range req.Lines {
+		id, err := primitive.ObjectIDFromHex(line.IngredientId)
+		if err != nil {
+			return nil, errors.New("invalid ingredientId")
+		}
+		if seen[id] {
+			return nil, errors.New("each ingredient can only appear once")
+		}
+		seen[id] = true
+		if line.Quantity <= 0 {
+			return nil, errors.New("quantity must be greater than 0")
+		}
+		ingredient, err := ingredientEntity.GetIngredientById(id)
+		if err != nil {
+			return nil, errors.New("ingredient not found")
+		}
+		recipe = append(recipe, entities.RecipeLine{
+			IngredientId: id, Name: ingredient.Name, Quantity: line.Quantity, Unit: ingredient.Unit,
+		})
+	}
+	return recipe, nil
+}
+
+// withRecipeStock fills in quantity and cost price of items with a recipe
+// from the current ingredient stock and costs.
+func withRecipeStock(ingredientEntity repositories.IIngredient, items []entities.MenuItem) []entities.MenuItem {
+	composed := false
+	for _, item := range items {
+		if len(item.Recipe) > 0 {
+			composed = true
+			break
+		}
+	}
+	if !composed {
+		return items
+	}
+	ingredients, err := ingredientEntity.GetIngredients()
+	if err != nil {
+		return items
+	}
+	stock := map[primitive.ObjectID]float64{}
+	costs := map[primitive.ObjectID]float64{}
+	for _, ingredient := range ingredients {
+		stock[ingredient.Id] = ingredient.Quantity
+		costs[ingredient.Id] = ingredient.CostPrice
+	}
+	for i := range items {
+		if len(items[i].Recipe) > 0 {
+			items[i].Quantity = inventory.Availability(items[i].Recipe, stock)
+			items[i].CostPrice = inventory.RecipeCost(items[i].Recipe, costs)
+		}
+	}
+	return items
+}
@@ -0,0 +1,51 @@
+package menu
+
+import (
+	"errors"
+	"snook/app/data/entities"
+	"snook/app/domain/request"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// toModifierGroups validates the modifier groups of a menu item. Existing
+// group and option ids are kept so past order lines still match them.
+func toModifierGroups(req request.ModifierGroups) ([]entities.ModifierGroup, error) {
+	groups := []entities.ModifierGroup{}
+	seen := map[primitive.ObjectID]bool{}
+	for _, g := range req.Groups {
+		group := entities.ModifierGroup{
+			Id: modifierId(g.Id), Name: g.Name, Required: g.Required,
+			MultiSelect: g.MultiSelect, MaxSelect: g.MaxSelect,
+		}
+		if group.MaxSelect < 0 {
+			return nil, errors.New("maxSelect must not be negative")
+		}
+		if !group.MultiSelect {
+			group.MaxSelect = 1
+		}
+		for _, o := range g.Options {
+			status := o.Status
+			if status == "" {
+				status = "ACTIVE"
+			}
+			option := entities.ModifierOption{
+				Id: modifierId(o.Id), Name: o.Name, PriceDelta: o.PriceDelta, Status: status,
+			}
+			if seen[option.Id] {
+				return nil, errors.New("modifier option ids must be unique")
+			}
+			seen[option.Id] = true
+			group.Options = append(group.Options, option)
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+func modifierId(hex string) primitive.ObjectID {
+	if id, err := primitive.ObjectIDFromHex(hex); err == nil {
+		return id
+	}
+	return primitive.NewObjectID()
+}
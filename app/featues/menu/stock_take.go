@@ -0,0 +1,213 @@
+package menu
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"snook/app/core/constant"
+	"snook/app/core/errcode"
+	"snook/app/data/entities"
+	"snook/app/data/repositories"
+	"snook/app/domain"
+	"snook/app/domain/request"
+	"snook/middlewares"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func applyStockTakeAPI(route *gin.RouterGroup, repository *domain.Repository) {
+	r := route.Group("stock-takes")
+
+	r.GET("", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		takes, err := repository.StockTake.GetStockTakes(ctx.Query("status"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.ST_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, takes)
+	})
+
+	r.GET("/:stockTakeId", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		take, ok := getStockTake(ctx, repository.StockTake)
+		if !ok {
+			return
+		}
+		ctx.JSON(http.StatusOK, take)
+	})
+
+	r.GET("/:stockTakeId/variance", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		take, ok := getStockTake(ctx, repository.StockTake)
+		if !ok {
+			return
+		}
+		ctx.JSON(http.StatusOK, varianceReport(take))
+	})
+
+	r.POST("", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		var req request.StockTake
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.ST_BAD_REQUEST_001, err.Error())
+			return
+		}
+		take, err := repository.StockTake.StartStockTake(entities.StockTake{
+			Category: req.Category, Note: req.Note, CreatedBy: ctx.GetString("UserId"),
+		})
+		if errors.Is(err, repositories.ErrStockTakeInProgress) {
+			errcode.Abort(ctx, http.StatusConflict, errcode.ST_CONFLICT_001, err.Error())
+			return
+		}
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.ST_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusCreated, take)
+	})
+
+	r.PUT("/:stockTakeId/counts", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		id, err := primitive.ObjectIDFromHex(ctx.Param("stockTakeId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.ST_BAD_REQUEST_001, "invalid stockTakeId")
+			return
+		}
+		var req request.StockTakeCounts
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.ST_BAD_REQUEST_001, err.Error())
+			return
+		}
+		counts := map[primitive.ObjectID]int{}
+		for _, count := range req.Counts {
+			menuItemId, err := primitive.ObjectIDFromHex(count.MenuItemId)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.ST_BAD_REQUEST_001, "invalid menuItemId")
+				return
+			}
+			if *count.Quantity < 0 {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.ST_BAD_REQUEST_001, "counted quantity must not be negative")
+				return
+			}
+			counts[menuItemId] = *count.Quantity
+		}
+		take, err := repository.StockTake.RecordStockTakeCounts(id, counts, ctx.GetString("UserId"))
+		if errors.Is(err, repositories.ErrStockTakeStatus) {
+			errcode.Abort(ctx, http.StatusConflict, errcode.ST_CONFLICT_001, "stock take is no longer open for counting")
+			return
+		}
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.ST_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, take)
+	})
+
+	r.POST("/:stockTakeId/submit", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		updateStockTakeStatus(repository.StockTake, "OPEN", "SUBMITTED"))
+
+	r.POST("/:stockTakeId/reopen", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), updateStockTakeStatus(repository.StockTake, "SUBMITTED", "OPEN"))
+
+	r.POST("/:stockTakeId/cancel", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			take, ok := getStockTake(ctx, repository.StockTake)
+			if !ok {
+				return
+			}
+			if take.Status != "OPEN" && take.Status != "SUBMITTED" {
+				errcode.Abort(ctx, http.StatusConflict, errcode.ST_CONFLICT_001, "stock take can no longer be cancelled")
+				return
+			}
+			if err := repository.StockTake.UpdateStockTakeStatus(take.Id, take.Status, "CANCELLED", ctx.GetString("UserId")); err != nil {
+				errcode.Abort(ctx, http.StatusConflict, errcode.ST_CONFLICT_001, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusOK, gin.H{"message": "success"})
+		})
+
+	r.POST("/:stockTakeId/approve", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			id, err := primitive.ObjectIDFromHex(ctx.Param("stockTakeId"))
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.ST_BAD_REQUEST_001, "invalid stockTakeId")
+				return
+			}
+			take, err := repository.StockTake.ApproveStockTake(id, ctx.GetString("UserId"))
+			if errors.Is(err, repositories.ErrStockTakeStatus) {
+				errcode.Abort(ctx, http.StatusConflict, errcode.ST_CONFLICT_001, "only submitted stock takes can be approved")
+				return
+			}
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.ST_BAD_REQUEST_002, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusOK, varianceReport(take))
+		})
+}
+
+func getStockTake(ctx *gin.Context, stockTakeEntity repositories.IStockTake) (entities.StockTake, bool) {
+	id, err := primitive.ObjectIDFromHex(ctx.Param("stockTakeId"))
+	if err != nil {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.ST_BAD_REQUEST_001, "invalid stockTakeId")
+		return entities.StockTake{}, false
+	}
+	take, err := stockTakeEntity.GetStockTakeById(id)
+	if err != nil {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.ST_BAD_REQUEST_002, "stock take not found")
+		return entities.StockTake{}, false
+	}
+	return take, true
+}
+
+func updateStockTakeStatus(stockTakeEntity repositories.IStockTake, from, to string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := primitive.ObjectIDFromHex(ctx.Param("stockTakeId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.ST_BAD_REQUEST_001, "invalid stockTakeId")
+			return
+		}
+		err = stockTakeEntity.UpdateStockTakeStatus(id, from, to, ctx.GetString("UserId"))
+		if errors.Is(err, repositories.ErrStockTakeStatus) {
+			errcode.Abort(ctx, http.StatusConflict, errcode.ST_CONFLICT_001, "stock take must be "+from)
+			return
+		}
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.ST_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"message": "success"})
+	}
+}
+
+// varianceReport values the counted differences at the cost price recorded
+// with each count. Uncounted lines are only reported in the totals.
+func varianceReport(take entities.StockTake) entities.StockVarianceReport {
+	report := entities.StockVarianceReport{
+		StockTakeId: take.Id,
+		Status:      take.Status,
+		Lines:       []entities.StockVariance{},
+	}
+	for _, line := range take.Lines {
+		if line.CountedQuantity == nil {
+			report.ItemsUncounted++
+			continue
+		}
+		report.ItemsCounted++
+		value := math.Round(float64(line.Variance)*line.UnitCost*100) / 100
+		if line.Variance != 0 {
+			report.ItemsWithVariance++
+		}
+		if value < 0 {
+			report.ShortageValue += -value
+		} else {
+			report.SurplusValue += value
+		}
+		report.Lines = append(report.Lines, entities.StockVariance{
+			MenuItemId: line.MenuItemId, Name: line.Name, Category: line.Category,
+			SystemQuantity: line.SystemQuantity, CountedQuantity: *line.CountedQuantity,
+			Variance: line.Variance, UnitCost: line.UnitCost, VarianceValue: value,
+		})
+	}
+	report.ShortageValue = math.Round(report.ShortageValue*100) / 100
+	report.SurplusValue = math.Round(report.SurplusValue*100) / 100
+	report.NetVarianceValue = math.Round((report.SurplusValue-report.ShortageValue)*100) / 100
+	return report
+}
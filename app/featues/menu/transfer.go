@@ -0,0 +1,748 @@
+package menu
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"snook/app/core/constant"
+	"snook/app/core/errcode"
+	"snook/app/core/spreadsheet"
+	"snook/app/data/entities"
+	"snook/app/data/repositories"
+	"snook/app/domain"
+	"snook/middlewares"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	sheetCategories = "Categories"
+	sheetItems      = "Items"
+
+	maxImportBytes = 10 << 20
+	maxImportRows  = 5000
+)
+
+var (
+	categoryColumns = []string{"name", "sortOrder", "station", "taxClass", "hidden"}
+	itemColumns     = []string{"sku", "barcode", "name", "variantName", "category", "price", "costPrice", "quantity", "unit", "status", "imageUrl"}
+)
+
+func applyMenuTransferAPI(route *gin.RouterGroup, repository *domain.Repository) {
+	r := route.Group("menu-data")
+
+	r.GET("/export", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			cats, err := repository.MenuCategory.GetMenuCategories()
+			if err != nil {
+				errcode.Abort(ctx, http.StatusInternalServerError, errcode.MI_INTERNAL_001, err.Error())
+				return
+			}
+			items, err := repository.MenuItem.GetMenuItems("")
+			if err != nil {
+				errcode.Abort(ctx, http.StatusInternalServerError, errcode.MI_INTERNAL_001, err.Error())
+				return
+			}
+			sheets := []spreadsheet.Sheet{categorySheet(cats), itemSheet(items)}
+			name := "menu-" + time.Now().Format("20060102")
+			var buf bytes.Buffer
+			switch ctx.DefaultQuery("format", "xlsx") {
+			case "xlsx":
+				if err := spreadsheet.WriteXLSX(&buf, sheets); err != nil {
+					errcode.Abort(ctx, http.StatusInternalServerError, errcode.MI_INTERNAL_001, err.Error())
+					return
+				}
+				ctx.Header("Content-Disposition", `attachment; filename="`+name+`.xlsx"`)
+				ctx.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", buf.Bytes())
+			case "csv":
+				kind, err := sheetKind(ctx.Query("sheet"))
+				if err != nil {
+					errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, err.Error())
+					return
+				}
+				sheet := sheets[1]
+				if kind == sheetCategories {
+					sheet = sheets[0]
+				}
+				if err := spreadsheet.WriteCSV(&buf, sheet.Rows); err != nil {
+					errcode.Abort(ctx, http.StatusInternalServerError, errcode.MI_INTERNAL_001, err.Error())
+					return
+				}
+				ctx.Header("Content-Disposition", `attachment; filename="`+name+"-"+strings.ToLower(kind)+`.csv"`)
+				ctx.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+			default:
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, "format must be xlsx or csv")
+			}
+		})
+
+	r.POST("/import", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportBytes+1<<20)
+			header, err := ctx.FormFile("file")
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, "file is required and must not be larger than 10 MB")
+				return
+			}
+			file, err := header.Open()
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, err.Error())
+				return
+			}
+			data, err := io.ReadAll(io.LimitReader(file, maxImportBytes))
+			_ = file.Close()
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, err.Error())
+				return
+			}
+			sheets, err := readMenuSheets(header.Filename, data, ctx.Query("sheet"))
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, err.Error())
+				return
+			}
+			cats, err := repository.MenuCategory.GetMenuCategories()
+			if err != nil {
+				errcode.Abort(ctx, http.StatusInternalServerError, errcode.MI_INTERNAL_001, err.Error())
+				return
+			}
+			items, err := repository.MenuItem.GetMenuItems("")
+			if err != nil {
+				errcode.Abort(ctx, http.StatusInternalServerError, errcode.MI_INTERNAL_001, err.Error())
+				return
+			}
+			plan, err := planMenuImport(sheets, cats, items)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_001, err.Error())
+				return
+			}
+			plan.report.DryRun = ctx.Query("dryRun") == "true"
+			if plan.report.DryRun {
+				ctx.JSON(http.StatusOK, plan.report)
+				return
+			}
+			if plan.report.Summary.Errors > 0 {
+				errcode.AbortWithDetails(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_003, "import has row errors", plan.errorDetails())
+				return
+			}
+			err = repository.MenuImport.RunMenuImport(func(tx repositories.MenuImportTx) error {
+				return plan.apply(tx, ctx.GetString("UserId"))
+			})
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.MI_BAD_REQUEST_002, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusOK, plan.report)
+		})
+}
+
+func categorySheet(cats []entities.MenuCategory) spreadsheet.Sheet {
+	rows := [][]string{categoryColumns}
+	for _, cat := range cats {
+		rows = append(rows, []string{
+			cat.Name, strconv.Itoa(cat.SortOrder), stationOf(cat.Station), cat.TaxClass, strconv.FormatBool(cat.Hidden),
+		})
+	}
+	return spreadsheet.Sheet{Name: sheetCategories, Rows: rows, Numeric: map[int]bool{1: true}}
+}
+
+// itemSheet lists each parent item before its variants. Quantities of recipe
+// items are left out since they follow the ingredient stock.
+func itemSheet(items []entities.MenuItem) spreadsheet.Sheet {
+	items = slices.Clone(items)
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Name != items[j].Name {
+			return items[i].Name < items[j].Name
+		}
+		return items[i].VariantName < items[j].VariantName
+	})
+	rows := [][]string{itemColumns}
+	for _, item := range items {
+		quantity := strconv.Itoa(item.Quantity)
+		if len(item.Recipe) > 0 {
+			quantity = ""
+		}
+		rows = append(rows, []string{
+			item.Sku, item.Barcode, item.Name, item.VariantName, item.Category,
+			formatAmount(item.Price), formatAmount(item.CostPrice), quantity,
+			item.Unit, item.Status, item.ImageUrl,
+		})
+	}
+	return spreadsheet.Sheet{Name: sheetItems, Rows: rows, Numeric: map[int]bool{5: true, 6: true, 7: true}}
+}
+
+func formatAmount(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func sheetKind(name string) (string, error) {
+	switch strings.ToLower(name) {
+	case "", "items":
+		return sheetItems, nil
+	case "categories":
+		return sheetCategories, nil
+	}
+	return "", errors.New("sheet must be items or categories")
+}
+
+// readMenuSheets returns the rows of the Categories and Items sheets. A CSV
+// file, or a workbook with a single unnamed sheet, holds the sheet given by
+// the sheet parameter.
+func readMenuSheets(filename string, data []byte, sheet string) (map[string][][]string, error) {
+	kind, err := sheetKind(sheet)
+	if err != nil {
+		return nil, err
+	}
+	sheets := map[string][][]string{}
+	if strings.EqualFold(filepath.Ext(filename), ".xlsx") || bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		workbook, err := spreadsheet.ReadXLSX(data, maxImportRows+1)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range workbook {
+			for _, name := range []string{sheetCategories, sheetItems} {
+				if strings.EqualFold(strings.TrimSpace(s.Name), name) {
+					sheets[name] = s.Rows
+				}
+			}
+		}
+		if len(sheets) == 0 && len(workbook) == 1 {
+			sheets[kind] = workbook[0].Rows
+		}
+		if len(sheets) == 0 {
+			return nil, errors.New("workbook has no Categories or Items sheet")
+		}
+	} else {
+		rows, err := spreadsheet.ReadCSV(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("file could not be read as csv: %w", err)
+		}
+		sheets[kind] = rows
+	}
+	for name, rows := range sheets {
+		if len(rows) > maxImportRows+1 {
+			return nil, fmt.Errorf("%s sheet has more than %d rows", name, maxImportRows)
+		}
+	}
+	return sheets, nil
+}
+
+// menuImport is the outcome of matching the file against the current menu.
+// Nothing is written until apply.
+type menuImport struct {
+	report     entities.MenuImportReport
+	categories []categoryImport
+	items      []itemImport
+}
+
+type categoryImport struct {
+	report int
+	action string
+	cat    entities.MenuCategory
+}
+
+type itemImport struct {
+	report   int
+	action   string
+	item     entities.MenuItem
+	current  *entities.MenuItem
+	quantity *int
+}
+
+// importRow gives access to the cells of a data row by column name.
+type importRow struct {
+	cells   []string
+	columns map[string]int
+}
+
+func (r importRow) get(column string) string {
+	i, ok := r.columns[strings.ToLower(column)]
+	if !ok || i >= len(r.cells) {
+		return ""
+	}
+	return strings.TrimSpace(r.cells[i])
+}
+
+func (r importRow) blank() bool {
+	for _, c := range r.cells {
+		if strings.TrimSpace(c) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func importColumns(sheet string, header []string, known []string) (map[string]int, error) {
+	columns := map[string]int{}
+	for i, h := range header {
+		name := strings.ToLower(strings.TrimSpace(h))
+		if name == "" {
+			continue
+		}
+		if !slices.ContainsFunc(known, func(k string) bool { return strings.ToLower(k) == name }) {
+			return nil, fmt.Errorf("%s sheet has unknown column %q", sheet, strings.TrimSpace(h))
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, fmt.Errorf("%s sheet needs a name column", sheet)
+	}
+	return columns, nil
+}
+
+func importKey(name, variantName string) string {
+	return strings.ToLower(name) + "\x00" + strings.ToLower(variantName)
+}
+
+func planMenuImport(sheets map[string][][]string, cats []entities.MenuCategory, items []entities.MenuItem) (*menuImport, error) {
+	plan := &menuImport{report: entities.MenuImportReport{Rows: []entities.MenuImportRow{}}}
+	knownCats := map[string]string{}
+	for _, cat := range cats {
+		knownCats[strings.ToLower(cat.Name)] = cat.Name
+	}
+	if rows := sheets[sheetCategories]; len(rows) > 0 {
+		columns, err := importColumns(sheetCategories, rows[0], categoryColumns)
+		if err != nil {
+			return nil, err
+		}
+		plan.planCategories(rows[1:], columns, cats, knownCats)
+	}
+	if rows := sheets[sheetItems]; len(rows) > 0 {
+		columns, err := importColumns(sheetItems, rows[0], itemColumns)
+		if err != nil {
+			return nil, err
+		}
+		plan.planItems(rows[1:], columns, items, knownCats)
+	}
+	for _, row := range plan.report.Rows {
+		switch row.Action {
+		case "ERROR":
+			plan.report.Summary.Errors++
+		case "UNCHANGED":
+			plan.report.Summary.Unchanged++
+		case "CREATE":
+			if row.Sheet == sheetCategories {
+				plan.report.Summary.CategoriesCreated++
+			} else {
+				plan.report.Summary.ItemsCreated++
+			}
+		case "UPDATE":
+			if row.Sheet == sheetCategories {
+				plan.report.Summary.CategoriesUpdated++
+			} else {
+				plan.report.Summary.ItemsUpdated++
+			}
+		}
+	}
+	return plan, nil
+}
+
+// planCategories matches category rows by name. Blank cells keep the current
+// value of an existing category.
+func (plan *menuImport) planCategories(rows [][]string, columns map[string]int, cats []entities.MenuCategory, knownCats map[string]string) {
+	byName := map[string]entities.MenuCategory{}
+	for _, cat := range cats {
+		byName[strings.ToLower(cat.Name)] = cat
+	}
+	seen := map[string]int{}
+	for i, cells := range rows {
+		row := importRow{cells: cells, columns: columns}
+		if row.blank() {
+			continue
+		}
+		number := i + 2
+		name := row.get("name")
+		var errs []string
+		if name == "" {
+			errs = append(errs, "name is required")
+		} else if prev, ok := seen[strings.ToLower(name)]; ok {
+			errs = append(errs, fmt.Sprintf("category is already on row %d", prev))
+		}
+		seen[strings.ToLower(name)] = number
+		current, exists := byName[strings.ToLower(name)]
+		cat := entities.MenuCategory{Name: name, Station: stationOf("")}
+		if exists {
+			cat = current
+		}
+		if v := row.get("sortOrder"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, "sortOrder must be a whole number")
+			}
+			cat.SortOrder = n
+		}
+		if v := strings.ToUpper(row.get("station")); v != "" {
+			if v != "KITCHEN" && v != "BAR" && v != "NONE" {
+				errs = append(errs, "station must be KITCHEN, BAR or NONE")
+			}
+			cat.Station = v
+		}
+		if v := row.get("taxClass"); v != "" {
+			cat.TaxClass = v
+		}
+		if v := row.get("hidden"); v != "" {
+			hidden, err := parseImportBool(v)
+			if err != nil {
+				errs = append(errs, err.Error())
+			}
+			cat.Hidden = hidden
+		}
+		action := "UNCHANGED"
+		switch {
+		case len(errs) > 0:
+			action = "ERROR"
+		case !exists:
+			action = "CREATE"
+			knownCats[strings.ToLower(name)] = name
+		case cat.SortOrder != current.SortOrder || stationOf(cat.Station) != stationOf(current.Station) ||
+			cat.TaxClass != current.TaxClass || cat.Hidden != current.Hidden:
+			action = "UPDATE"
+		}
+		result := entities.MenuImportRow{Sheet: sheetCategories, Row: number, Key: name, Action: action, Errors: errs}
+		if exists {
+			result.Id = &current.Id
+		}
+		plan.report.Rows = append(plan.report.Rows, result)
+		if action == "CREATE" || action == "UPDATE" {
+			plan.categories = append(plan.categories, categoryImport{report: len(plan.report.Rows) - 1, action: action, cat: cat})
+		}
+	}
+}
+
+// planItems matches item rows by SKU, then by name and variant name. Blank
+// cells keep the current value of an existing item. Variant rows carry the
+// name of their parent, which must exist or be a row of the same file.
+func (plan *menuImport) planItems(rows [][]string, columns map[string]int, items []entities.MenuItem, knownCats map[string]string) {
+	bySku := map[string]*entities.MenuItem{}
+	byBarcode := map[string]*entities.MenuItem{}
+	byKey := map[string][]*entities.MenuItem{}
+	for i := range items {
+		item := &items[i]
+		if item.Sku != "" {
+			bySku[item.Sku] = item
+		}
+		if item.Barcode != "" {
+			byBarcode[item.Barcode] = item
+		}
+		key := importKey(item.Name, item.VariantName)
+		byKey[key] = append(byKey[key], item)
+	}
+	parents := map[string]bool{}
+	for _, item := range items {
+		if item.ParentId == nil {
+			parents[strings.ToLower(item.Name)] = true
+		}
+	}
+	type parsed struct {
+		number int
+		row    importRow
+	}
+	var pending []parsed
+	for i, cells := range rows {
+		row := importRow{cells: cells, columns: columns}
+		if !row.blank() {
+			pending = append(pending, parsed{number: i + 2, row: row})
+		}
+	}
+	// Rows are reported in file order, but parents are planned first so
+	// variant rows can refer to new parents.
+	slots := map[int]int{}
+	for _, p := range pending {
+		slots[p.number] = len(plan.report.Rows)
+		plan.report.Rows = append(plan.report.Rows, entities.MenuImportRow{})
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].row.get("variantName") == "" && pending[j].row.get("variantName") != ""
+	})
+	seenSku := map[string]int{}
+	seenBarcode := map[string]int{}
+	seenKey := map[string]int{}
+	matched := map[primitive.ObjectID]int{}
+	for _, p := range pending {
+		row := p.row
+		sku, barcode := row.get("sku"), row.get("barcode")
+		name, variantName := row.get("name"), row.get("variantName")
+		var errs []string
+		if name == "" {
+			errs = append(errs, "name is required")
+		}
+		var current *entities.MenuItem
+		if sku != "" {
+			current = bySku[sku]
+		}
+		if current == nil && name != "" {
+			switch matches := byKey[importKey(name, variantName)]; len(matches) {
+			case 0:
+			case 1:
+				current = matches[0]
+			default:
+				errs = append(errs, "name matches more than one item, add its sku")
+			}
+		}
+		if current != nil {
+			if prev, ok := matched[current.Id]; ok {
+				errs = append(errs, fmt.Sprintf("matches the same item as row %d", prev))
+			}
+			matched[current.Id] = p.number
+			if current.ParentId == nil && variantName != "" {
+				errs = append(errs, "an item cannot be turned into a variant")
+			}
+			if current.ParentId != nil && variantName == "" {
+				errs = append(errs, "variantName is required for a variant")
+			}
+		}
+		if sku != "" {
+			if prev, ok := seenSku[sku]; ok {
+				errs = append(errs, fmt.Sprintf("sku is already on row %d", prev))
+			} else if owner := bySku[sku]; owner != nil && owner != current {
+				errs = append(errs, "sku is used by "+owner.Name)
+			}
+			seenSku[sku] = p.number
+		}
+		if barcode != "" {
+			if prev, ok := seenBarcode[barcode]; ok {
+				errs = append(errs, fmt.Sprintf("barcode is already on row %d", prev))
+			} else if owner := byBarcode[barcode]; owner != nil && owner != current {
+				errs = append(errs, "barcode is used by "+owner.Name)
+			}
+			seenBarcode[barcode] = p.number
+		}
+		if name != "" {
+			key := importKey(name, variantName)
+			if prev, ok := seenKey[key]; ok {
+				errs = append(errs, fmt.Sprintf("item is already on row %d", prev))
+			}
+			seenKey[key] = p.number
+		}
+
+		item := entities.MenuItem{Status: "ACTIVE"}
+		if current != nil {
+			item = *current
+		}
+		item.Name, item.VariantName = name, variantName
+		if sku != "" {
+			item.Sku = sku
+		}
+		if barcode != "" {
+			item.Barcode = barcode
+		}
+		if v := row.get("price"); v != "" {
+			price, err := strconv.ParseFloat(v, 64)
+			if err != nil || price < 0 {
+				errs = append(errs, "price must be a number of zero or more")
+			}
+			item.Price = price
+		} else if current == nil {
+			errs = append(errs, "price is required")
+		}
+		if v := row.get("costPrice"); v != "" {
+			cost, err := strconv.ParseFloat(v, 64)
+			if err != nil || cost < 0 {
+				errs = append(errs, "costPrice must be a number of zero or more")
+			}
+			item.CostPrice = cost
+		}
+		var quantity *int
+		if v := row.get("quantity"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, "quantity must be a whole number")
+			}
+			if len(item.Recipe) == 0 {
+				quantity = &n
+			}
+		}
+		if v := row.get("unit"); v != "" {
+			item.Unit = v
+		}
+		if v := strings.ToUpper(row.get("status")); v != "" {
+			if v != "ACTIVE" && v != "INACTIVE" {
+				errs = append(errs, "status must be ACTIVE or INACTIVE")
+			}
+			item.Status = v
+		}
+		if v := row.get("imageUrl"); v != "" {
+			item.ImageUrl = v
+		}
+		if variantName == "" {
+			if v := row.get("category"); v != "" {
+				if known, ok := knownCats[strings.ToLower(v)]; ok {
+					item.Category = known
+				} else {
+					errs = append(errs, "category "+v+" not found")
+				}
+			} else if current == nil {
+				errs = append(errs, "category is required")
+			}
+		} else if current != nil {
+			// Variants follow the name and category of their parent.
+			item.Name, item.Category = current.Name, current.Category
+		} else if !parents[strings.ToLower(name)] {
+			errs = append(errs, "parent item "+name+" not found")
+		}
+
+		action := "UNCHANGED"
+		switch {
+		case len(errs) > 0:
+			action = "ERROR"
+		case current == nil:
+			action = "CREATE"
+		case item.Name != current.Name || item.Category != current.Category || item.Sku != current.Sku ||
+			item.Barcode != current.Barcode || item.Price != current.Price || item.CostPrice != current.CostPrice ||
+			item.Unit != current.Unit || item.Status != current.Status || item.ImageUrl != current.ImageUrl ||
+			item.VariantName != current.VariantName || (quantity != nil && *quantity != current.Quantity):
+			action = "UPDATE"
+		}
+		if action == "CREATE" && variantName == "" {
+			parents[strings.ToLower(name)] = true
+		}
+		key := name
+		if variantName != "" {
+			key += " - " + variantName
+		}
+		result := entities.MenuImportRow{Sheet: sheetItems, Row: p.number, Key: key, Action: action, Errors: errs}
+		if current != nil {
+			result.Id = &current.Id
+		}
+		plan.report.Rows[slots[p.number]] = result
+		if action == "CREATE" || action == "UPDATE" {
+			plan.items = append(plan.items, itemImport{
+				report: slots[p.number], action: action, item: item, current: current, quantity: quantity,
+			})
+		}
+	}
+}
+
+func parseImportBool(v string) (bool, error) {
+	switch strings.ToLower(v) {
+	case "true", "yes", "y", "1":
+		return true, nil
+	case "false", "no", "n", "0":
+		return false, nil
+	}
+	return false, errors.New("hidden must be true or false")
+}
+
+func (plan *menuImport) errorDetails() []string {
+	var details []string
+	for _, row := range plan.report.Rows {
+		for _, err := range row.Errors {
+			details = append(details, fmt.Sprintf("%s row %d: %s", row.Sheet, row.Row, err))
+		}
+	}
+	return details
+}
+
+// apply writes the planned changes: categories first, then parent items and
+// then variants. Stock changes go through opening stock and count movements
+// like the item form. It stops at the first failing row, and as it runs in
+// one transaction nothing of the import is kept.
+func (plan *menuImport) apply(tx repositories.MenuImportTx, userId string) error {
+	fail := func(index int, err error) error {
+		row := plan.report.Rows[index]
+		return fmt.Errorf("%s row %d: %w", row.Sheet, row.Row, err)
+	}
+	for _, c := range plan.categories {
+		cat := c.cat
+		if c.action == "CREATE" {
+			cat.CreatedBy = userId
+			result, err := tx.CreateMenuCategory(cat)
+			if err != nil {
+				return fail(c.report, err)
+			}
+			plan.report.Rows[c.report].Id = &result.Id
+			continue
+		}
+		cat.UpdatedBy = userId
+		if err := tx.UpdateMenuCategoryById(cat.Id, cat); err != nil {
+			return fail(c.report, err)
+		}
+	}
+	cats, err := tx.GetMenuCategories()
+	if err != nil {
+		return err
+	}
+	catByName := map[string]entities.MenuCategory{}
+	for _, cat := range cats {
+		catByName[strings.ToLower(cat.Name)] = cat
+	}
+	items, err := tx.GetMenuItems()
+	if err != nil {
+		return err
+	}
+	parents := map[string]entities.MenuItem{}
+	for _, item := range items {
+		if item.ParentId == nil {
+			parents[strings.ToLower(item.Name)] = item
+		}
+	}
+	for _, it := range plan.items {
+		item := it.item
+		switch {
+		case item.VariantName == "":
+			cat := catByName[strings.ToLower(item.Category)]
+			item.CategoryId, item.Category = &cat.Id, cat.Name
+		case it.current == nil:
+			parent, ok := parents[strings.ToLower(item.Name)]
+			if !ok {
+				return fail(it.report, errors.New("parent item "+item.Name+" not found"))
+			}
+			item.Name, item.ParentId = parent.Name, &parent.Id
+			item.CategoryId, item.Category = parent.CategoryId, parent.Category
+			if item.Unit == "" {
+				item.Unit = parent.Unit
+			}
+			if item.ImageUrl == "" {
+				item.ImageUrl = parent.ImageUrl
+			}
+		}
+		if it.current == nil {
+			item.CreatedBy = userId
+			result, err := tx.CreateMenuItem(item)
+			if err != nil {
+				return fail(it.report, err)
+			}
+			if it.quantity != nil && *it.quantity != 0 {
+				_, err := tx.PostStockMovement(entities.StockMovement{
+					MenuItemId: result.Id, Type: "ADJUSTMENT", Quantity: *it.quantity,
+					Reason: "opening stock", CreatedBy: userId,
+				})
+				if err != nil {
+					return fail(it.report, err)
+				}
+			}
+			plan.report.Rows[it.report].Id = &result.Id
+			if result.ParentId == nil {
+				parents[strings.ToLower(result.Name)] = result
+			}
+			continue
+		}
+		if item.ImageUrl != it.current.ImageUrl {
+			item.ThumbnailUrl = ""
+		}
+		item.UpdatedBy = userId
+		if err := tx.UpdateMenuItemById(item.Id, item); err != nil {
+			return fail(it.report, err)
+		}
+		if it.quantity != nil && *it.quantity != it.current.Quantity {
+			_, err := tx.RecordStockCount(entities.StockMovement{
+				MenuItemId: item.Id, Type: "COUNT", Reason: "menu import", CreatedBy: userId,
+			}, *it.quantity)
+			if err != nil {
+				return fail(it.report, err)
+			}
+		}
+		if item.ParentId == nil {
+			parents[strings.ToLower(item.Name)] = item
+		}
+	}
+	plan.report.Applied = true
+	return nil
+}
@@ -0,0 +1,144 @@
+package order_ticket
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"snook/app/core/businessday"
+	"snook/app/core/constant"
+	"snook/app/core/errcode"
+	"snook/app/data/repositories"
+	"snook/app/domain"
+	"snook/app/domain/request"
+	"snook/middlewares"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// keepAliveInterval keeps idle streams open through proxies.
+const keepAliveInterval = 25 * time.Second
+
+var activeStatuses = []string{"QUEUED", "PREPARING", "READY"}
+
+func ApplyOrderTicketAPI(route *gin.RouterGroup, repository *domain.Repository) {
+	r := route.Group("order-tickets")
+
+	r.GET("", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		statuses := activeStatuses
+		if status := ctx.Query("status"); status != "" {
+			statuses = strings.Split(status, ",")
+		}
+		tickets, err := repository.OrderTicket.GetOrderTickets(ctx.Query("station"), statuses)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.OT_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, tickets)
+	})
+
+	r.GET("/stations/:station", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		tickets, err := repository.OrderTicket.GetOrderTickets(strings.ToUpper(ctx.Param("station")), activeStatuses)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.OT_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, tickets)
+	})
+
+	r.GET("/stream", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		station := strings.ToUpper(ctx.Query("station"))
+		tickets, closeStream := repository.OrderTicket.SubscribeOrderTickets(ctx.Request.Context())
+		defer func() { _ = closeStream() }()
+		keepAlive := time.NewTicker(keepAliveInterval)
+		defer keepAlive.Stop()
+		ctx.Header("Cache-Control", "no-cache")
+		ctx.Header("X-Accel-Buffering", "no")
+		ctx.Stream(func(w io.Writer) bool {
+			select {
+			case ticket, ok := <-tickets:
+				if !ok {
+					return false
+				}
+				if station == "" || ticket.Station == station {
+					ctx.SSEvent("ticket", ticket)
+				}
+				return true
+			case <-keepAlive.C:
+				ctx.SSEvent("ping", time.Now().Unix())
+				return true
+			case <-ctx.Request.Context().Done():
+				return false
+			}
+		})
+	})
+
+	r.GET("/metrics", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			startDate := ctx.Query("startDate")
+			endDate := ctx.Query("endDate")
+			if startDate == "" || endDate == "" {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.OT_BAD_REQUEST_001, "startDate and endDate required")
+				return
+			}
+			// Tickets after midnight count towards the business day they
+			// were ordered on.
+			setting, err := repository.Setting.GetSetting()
+			if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+				errcode.Abort(ctx, http.StatusInternalServerError, errcode.OT_INTERNAL_001, err.Error())
+				return
+			}
+			start, end, err := businessday.Span(startDate, endDate, setting.CutoverHour)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.OT_BAD_REQUEST_001, err.Error())
+				return
+			}
+			metrics, err := repository.OrderTicket.GetPrepMetrics(start, end)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusInternalServerError, errcode.OT_INTERNAL_001, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusOK, metrics)
+		})
+
+	r.GET("/:ticketId", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		id, err := primitive.ObjectIDFromHex(ctx.Param("ticketId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.OT_BAD_REQUEST_001, "invalid ticketId")
+			return
+		}
+		ticket, err := repository.OrderTicket.GetOrderTicketById(id)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.OT_BAD_REQUEST_002, "ticket not found")
+			return
+		}
+		ctx.JSON(http.StatusOK, ticket)
+	})
+
+	r.PATCH("/:ticketId/status", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		id, err := primitive.ObjectIDFromHex(ctx.Param("ticketId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.OT_BAD_REQUEST_001, "invalid ticketId")
+			return
+		}
+		var req request.OrderTicketStatus
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.OT_BAD_REQUEST_001, err.Error())
+			return
+		}
+		ticket, err := repository.OrderTicket.UpdateOrderTicketStatus(id, req.Status, ctx.GetString("UserId"))
+		if errors.Is(err, repositories.ErrTicketTransition) {
+			errcode.Abort(ctx, http.StatusConflict, errcode.OT_CONFLICT_001, "ticket cannot move to "+req.Status)
+			return
+		}
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.OT_BAD_REQUEST_002, "ticket not found")
+			return
+		}
+		_ = repository.OrderTicket.PublishOrderTicket(ticket)
+		ctx.JSON(http.StatusOK, ticket)
+	})
+}
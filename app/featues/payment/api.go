@@ -1,15 +1,25 @@
 package payment
 
 import (
+	"errors"
+	"io"
+	"math"
 	"net/http"
+	"snook/app/core/billing"
+	"snook/app/core/constant"
 	"snook/app/core/errcode"
+	"snook/app/core/gateway"
 	"snook/app/data/entities"
+	"snook/app/data/repositories"
 	"snook/app/domain"
 	"snook/app/domain/request"
 	"snook/middlewares"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/skip2/go-qrcode"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -44,19 +54,25 @@ func ApplyPaymentAPI(route *gin.RouterGroup, repository *domain.Repository) {
 		ctx.JSON(http.StatusOK, payments)
 	})
 
-	r.POST("", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+	r.POST("", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), middlewares.RequireOpenBusinessDay(repository.Setting, repository.DayClose), func(ctx *gin.Context) {
 		var req request.Payment
 		if err := ctx.ShouldBindJSON(&req); err != nil {
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.PY_BAD_REQUEST_001, err.Error())
 			return
 		}
+		setting, _ := repository.Setting.GetSetting()
+		method, err := billing.ResolvePaymentMethod(billing.PaymentMethods(setting), req.Type)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PY_BAD_REQUEST_001, err.Error())
+			return
+		}
 		sessionId, _ := primitive.ObjectIDFromHex(req.SessionId)
 		payment := entities.Payment{
-			SessionId: sessionId, Type: req.Type, Amount: req.Amount,
-			Note: req.Note, CreatedBy: ctx.GetString("UserId"),
+			SessionId: sessionId, Type: method.Code, Amount: req.Amount,
+			Fee: billing.PaymentFee(method, req.Amount), Note: req.Note, CreatedBy: ctx.GetString("UserId"),
 		}
 		// If type is OUTSTANDING, create a creditor record
-		if req.Type == "OUTSTANDING" {
+		if method.Code == "OUTSTANDING" {
 			session, err := repository.TableSession.GetTableSessionById(sessionId)
 			if err == nil {
 				creditor := entities.Creditor{
@@ -76,16 +92,332 @@ func ApplyPaymentAPI(route *gin.RouterGroup, repository *domain.Repository) {
 		ctx.JSON(http.StatusCreated, result)
 	})
 
-	r.DELETE("/:paymentId", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+	// ─── Payment Intents ────────────────────────────
+	r.GET("/intents", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		var sessionId *primitive.ObjectID
+		if v := ctx.Query("sessionId"); v != "" {
+			id, err := primitive.ObjectIDFromHex(v)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PY_BAD_REQUEST_001, "invalid sessionId")
+				return
+			}
+			sessionId = &id
+		}
+		intents, err := repository.PaymentIntent.GetPaymentIntents(sessionId, ctx.Query("status"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.PY_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, intents)
+	})
+
+	r.GET("/intents/:intentId", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		intent, ok := getPaymentIntent(ctx, repository)
+		if !ok {
+			return
+		}
+		ctx.JSON(http.StatusOK, intent)
+	})
+
+	r.GET("/intents/:intentId/qr", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		intent, ok := getPaymentIntent(ctx, repository)
+		if !ok {
+			return
+		}
+		if intent.Payload == "" {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PY_BAD_REQUEST_001, "payment request has no QR code")
+			return
+		}
+		size := 320
+		if v, err := strconv.Atoi(ctx.Query("size")); err == nil {
+			size = min(max(v, 128), 1024)
+		}
+		png, err := qrcode.Encode(intent.Payload, qrcode.Medium, size)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.PY_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.Data(http.StatusOK, "image/png", png)
+	})
+
+	r.POST("/intents/:intentId/confirm", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), middlewares.RequireOpenBusinessDay(repository.Setting, repository.DayClose), func(ctx *gin.Context) {
+		intent, ok := getPaymentIntent(ctx, repository)
+		if !ok {
+			return
+		}
+		session, err := repository.TableSession.GetTableSessionById(intent.SessionId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PY_BAD_REQUEST_002, "session not found")
+			return
+		}
+		if session.Status == "CLOSED" {
+			errcode.Abort(ctx, http.StatusConflict, errcode.PY_CONFLICT_001, "session already closed")
+			return
+		}
+		payment, err := repository.PaymentIntent.ConfirmPaymentIntent(intent.Id, ctx.GetString("UserId"))
+		if errors.Is(err, repositories.ErrPaymentIntentStatus) {
+			errcode.Abort(ctx, http.StatusConflict, errcode.PY_CONFLICT_001, err.Error())
+			return
+		}
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PY_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusCreated, payment)
+	})
+
+	r.POST("/intents/:intentId/cancel", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		intent, ok := getPaymentIntent(ctx, repository)
+		if !ok {
+			return
+		}
+		err := repository.PaymentIntent.CancelPaymentIntent(intent.Id, ctx.GetString("UserId"))
+		if errors.Is(err, repositories.ErrPaymentIntentStatus) {
+			errcode.Abort(ctx, http.StatusConflict, errcode.PY_CONFLICT_001, err.Error())
+			return
+		}
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PY_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"message": "success"})
+	})
+
+	r.GET("/intents/:intentId/events", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		intent, ok := getPaymentIntent(ctx, repository)
+		if !ok {
+			return
+		}
+		events, err := repository.PaymentIntent.GetPaymentEvents(intent.Id)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.PY_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, events)
+	})
+
+	// simulate lets staff drive a charge on the fake provider through the
+	// same signed webhook path a real gateway would use.
+	r.POST("/intents/:intentId/simulate", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), middlewares.RequireOpenBusinessDay(repository.Setting, repository.DayClose), func(ctx *gin.Context) {
+		fake, isFake := repository.Gateway.(*gateway.FakeProvider)
+		if !isFake {
+			errcode.Abort(ctx, http.StatusNotFound, errcode.PY_NOT_FOUND_001, "simulation needs the fake payment provider")
+			return
+		}
+		var req request.PaymentEventSimulation
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PY_BAD_REQUEST_001, err.Error())
+			return
+		}
+		intent, ok := getPaymentIntent(ctx, repository)
+		if !ok {
+			return
+		}
+		if intent.Provider != fake.Name() || intent.ProviderRef == "" {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PY_BAD_REQUEST_001, "payment request was not created by the payment gateway")
+			return
+		}
+		amount := intent.Amount
+		if req.Amount != nil {
+			amount = *req.Amount
+		}
+		body, signature, err := fake.NewEvent(req.Type, intent.ProviderRef, amount)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.PY_INTERNAL_001, err.Error())
+			return
+		}
+		header := http.Header{}
+		header.Set(gateway.FakeSignatureHeader, signature)
+		receiveWebhook(ctx, repository, header, body)
+	})
+
+	// ─── Gateway Webhooks ───────────────────────────
+	// A closed day answers 409, so the provider retries after the cutover.
+	r.POST("/webhooks/:provider", middlewares.RequireOpenBusinessDay(repository.Setting, repository.DayClose), func(ctx *gin.Context) {
+		if ctx.Param("provider") != repository.Gateway.Name() {
+			errcode.Abort(ctx, http.StatusNotFound, errcode.PY_NOT_FOUND_001, "payment provider not found")
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxWebhookBytes+1))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PY_BAD_REQUEST_001, err.Error())
+			return
+		}
+		if len(body) > maxWebhookBytes {
+			errcode.Abort(ctx, http.StatusRequestEntityTooLarge, errcode.PY_BAD_REQUEST_001, "webhook body too large")
+			return
+		}
+		receiveWebhook(ctx, repository, ctx.Request.Header, body)
+	})
+
+	// ─── Refunds & Voids ────────────────────────────
+	r.POST("/:paymentId/reversals", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
 		id, err := primitive.ObjectIDFromHex(ctx.Param("paymentId"))
 		if err != nil {
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.PY_BAD_REQUEST_001, "invalid paymentId")
 			return
 		}
-		if err := repository.Payment.DeletePayment(id); err != nil {
+		var req request.PaymentReversal
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PY_BAD_REQUEST_001, err.Error())
+			return
+		}
+		if strings.TrimSpace(req.Reason) == "" {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PY_BAD_REQUEST_001, "reason is required")
+			return
+		}
+		payment, err := repository.Payment.GetPaymentById(id)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PY_BAD_REQUEST_002, "payment not found")
+			return
+		}
+		switch {
+		case payment.ReversalOf != nil || payment.Amount <= 0:
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PY_BAD_REQUEST_003, "a reversal cannot be reversed")
+			return
+		case payment.Type == "OUTSTANDING":
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PY_BAD_REQUEST_003, "outstanding payments are settled through creditors")
+			return
+		case payment.Type == "POINTS":
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PY_BAD_REQUEST_003, "points payments cannot be refunded")
+			return
+		}
+		reversible, err := repository.PaymentReversal.GetReversibleAmount(payment)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.PY_INTERNAL_001, err.Error())
+			return
+		}
+		amount := req.Amount
+		if req.Type == "VOID" {
+			// A void takes back the whole payment, so nothing may be reversed yet.
+			if math.Round(reversible*100) != math.Round(payment.Amount*100) {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PY_BAD_REQUEST_003, "payment is already partly reversed, use a refund")
+				return
+			}
+			amount = payment.Amount
+		}
+		if amount <= 0 {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PY_BAD_REQUEST_001, "amount is required for a refund")
+			return
+		}
+		if amount > reversible {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PY_BAD_REQUEST_003, "refund exceeds the refundable amount of "+strconv.FormatFloat(reversible, 'f', 2, 64))
+			return
+		}
+		reversal, err := repository.PaymentReversal.CreatePaymentReversal(entities.PaymentReversal{
+			PaymentId: payment.Id, SessionId: payment.SessionId, Type: req.Type,
+			PaymentType: payment.Type, Amount: amount, Reason: strings.TrimSpace(req.Reason),
+			CreatedBy: ctx.GetString("UserId"),
+		})
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PY_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusCreated, reversal)
+	})
+
+	r.GET("/reversals", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		var paymentId *primitive.ObjectID
+		if v := ctx.Query("paymentId"); v != "" {
+			id, err := primitive.ObjectIDFromHex(v)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PY_BAD_REQUEST_001, "invalid paymentId")
+				return
+			}
+			paymentId = &id
+		}
+		reversals, err := repository.PaymentReversal.GetPaymentReversals(paymentId, ctx.Query("status"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.PY_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, reversals)
+	})
+
+	r.POST("/reversals/:reversalId/approve", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), middlewares.RequireOpenBusinessDay(repository.Setting, repository.DayClose), func(ctx *gin.Context) {
+		id, err := primitive.ObjectIDFromHex(ctx.Param("reversalId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PY_BAD_REQUEST_001, "invalid reversalId")
+			return
+		}
+		var req request.PaymentReversalReview
+		_ = ctx.ShouldBindJSON(&req)
+		entry, err := repository.PaymentReversal.ApprovePaymentReversal(id, ctx.GetString("UserId"), req.PaidOutBy, req.Note)
+		if errors.Is(err, repositories.ErrPaymentReversalStatus) {
+			errcode.Abort(ctx, http.StatusConflict, errcode.PY_CONFLICT_002, err.Error())
+			return
+		}
+		if errors.Is(err, repositories.ErrReversalExceedsAmount) {
+			errcode.Abort(ctx, http.StatusConflict, errcode.PY_CONFLICT_003, err.Error())
+			return
+		}
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PY_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusCreated, entry)
+	})
+
+	r.POST("/reversals/:reversalId/reject", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+		id, err := primitive.ObjectIDFromHex(ctx.Param("reversalId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PY_BAD_REQUEST_001, "invalid reversalId")
+			return
+		}
+		var req request.PaymentReversalReview
+		_ = ctx.ShouldBindJSON(&req)
+		err = repository.PaymentReversal.RejectPaymentReversal(id, ctx.GetString("UserId"), req.Note)
+		if errors.Is(err, repositories.ErrPaymentReversalStatus) {
+			errcode.Abort(ctx, http.StatusConflict, errcode.PY_CONFLICT_002, err.Error())
+			return
+		}
+		if err != nil {
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.PY_BAD_REQUEST_002, err.Error())
 			return
 		}
 		ctx.JSON(http.StatusOK, gin.H{"message": "success"})
 	})
 }
+
+const maxWebhookBytes = 1 << 20
+
+// receiveWebhook verifies a gateway event and applies it once. Only storage
+// failures answer 5xx, so the provider retries them and nothing else.
+func receiveWebhook(ctx *gin.Context, repository *domain.Repository, header http.Header, body []byte) {
+	event, err := repository.Gateway.ParseWebhook(header, body)
+	if errors.Is(err, gateway.ErrInvalidSignature) {
+		errcode.Abort(ctx, http.StatusUnauthorized, errcode.PY_UNAUTHORIZED_001, err.Error())
+		return
+	}
+	if err != nil {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.PY_BAD_REQUEST_001, err.Error())
+		return
+	}
+	result, err := repository.PaymentIntent.ApplyPaymentEvent(entities.PaymentEvent{
+		Provider: repository.Gateway.Name(), EventId: event.Id, Type: event.Type,
+		ProviderRef: event.ProviderRef, Amount: event.Amount,
+	})
+	if errors.Is(err, repositories.ErrDuplicatePaymentEvent) {
+		ctx.JSON(http.StatusOK, gin.H{"message": "duplicate"})
+		return
+	}
+	if err != nil {
+		errcode.Abort(ctx, http.StatusInternalServerError, errcode.PY_INTERNAL_001, err.Error())
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+func getPaymentIntent(ctx *gin.Context, repository *domain.Repository) (entities.PaymentIntent, bool) {
+	id, err := primitive.ObjectIDFromHex(ctx.Param("intentId"))
+	if err != nil {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.PY_BAD_REQUEST_001, "invalid intentId")
+		return entities.PaymentIntent{}, false
+	}
+	intent, err := repository.PaymentIntent.GetPaymentIntentById(id)
+	if err != nil {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.PY_BAD_REQUEST_002, "payment request not found")
+		return intent, false
+	}
+	return intent, true
+}
@@ -0,0 +1,174 @@
+package price_list
+
+import (
+	"errors"
+	"net/http"
+	"snook/app/core/billing"
+	"snook/app/core/constant"
+	"snook/app/core/errcode"
+	"snook/app/data/entities"
+	"snook/app/data/repositories"
+	"snook/app/domain"
+	"snook/app/domain/request"
+	"snook/middlewares"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func ApplyPriceListAPI(route *gin.RouterGroup, repository *domain.Repository) {
+	r := route.Group("price-lists")
+
+	r.GET("", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		lists, err := repository.PriceList.GetPriceLists()
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.PL_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, lists)
+	})
+
+	r.GET("/current", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		now := time.Now()
+		lists, err := repository.PriceList.GetCurrentPriceLists(now)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.PL_INTERNAL_001, err.Error())
+			return
+		}
+		current := []entities.PriceList{}
+		for _, list := range lists {
+			if billing.PriceListInEffect(list, now) {
+				current = append(current, list)
+			}
+		}
+		ctx.JSON(http.StatusOK, current)
+	})
+
+	r.GET("/:priceListId", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		id, err := primitive.ObjectIDFromHex(ctx.Param("priceListId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PL_BAD_REQUEST_001, "invalid priceListId")
+			return
+		}
+		list, err := repository.PriceList.GetPriceListById(id)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PL_BAD_REQUEST_002, "price list not found")
+			return
+		}
+		ctx.JSON(http.StatusOK, list)
+	})
+
+	r.POST("", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			var req request.PriceList
+			if err := ctx.ShouldBindJSON(&req); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PL_BAD_REQUEST_001, err.Error())
+				return
+			}
+			list, err := toPriceList(repository.MenuItem, req)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PL_BAD_REQUEST_001, err.Error())
+				return
+			}
+			if list.Status == "" {
+				list.Status = "ACTIVE"
+			}
+			list.CreatedBy = ctx.GetString("UserId")
+			result, err := repository.PriceList.CreatePriceList(list)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PL_BAD_REQUEST_002, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusCreated, result)
+		})
+
+	r.PUT("/:priceListId", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			id, err := primitive.ObjectIDFromHex(ctx.Param("priceListId"))
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PL_BAD_REQUEST_001, "invalid priceListId")
+				return
+			}
+			var req request.PriceList
+			if err := ctx.ShouldBindJSON(&req); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PL_BAD_REQUEST_001, err.Error())
+				return
+			}
+			list, err := toPriceList(repository.MenuItem, req)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PL_BAD_REQUEST_001, err.Error())
+				return
+			}
+			list.UpdatedBy = ctx.GetString("UserId")
+			if err := repository.PriceList.UpdatePriceListById(id, list); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PL_BAD_REQUEST_002, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusOK, gin.H{"message": "success"})
+		})
+
+	r.DELETE("/:priceListId", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			id, err := primitive.ObjectIDFromHex(ctx.Param("priceListId"))
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PL_BAD_REQUEST_001, "invalid priceListId")
+				return
+			}
+			if err := repository.PriceList.DeletePriceListById(id); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PL_BAD_REQUEST_002, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusOK, gin.H{"message": "success"})
+		})
+}
+
+func toPriceList(menuItemEntity repositories.IMenuItem, req request.PriceList) (entities.PriceList, error) {
+	if err := billing.ValidateDaysOfWeek(req.DaysOfWeek); err != nil {
+		return entities.PriceList{}, err
+	}
+	if err := billing.ValidateTimeWindow(req.StartTimeOfDay, req.EndTimeOfDay); err != nil {
+		return entities.PriceList{}, err
+	}
+	list := entities.PriceList{
+		Name: req.Name, DaysOfWeek: req.DaysOfWeek,
+		StartTimeOfDay: req.StartTimeOfDay, EndTimeOfDay: req.EndTimeOfDay,
+		Priority: req.Priority, Status: req.Status,
+	}
+	if req.StartDate != "" {
+		startDate, err := time.ParseInLocation("2006-01-02", req.StartDate, time.Local)
+		if err != nil {
+			return list, errors.New("invalid startDate")
+		}
+		list.StartDate = &startDate
+	}
+	if req.EndDate != "" {
+		endDate, err := time.ParseInLocation("2006-01-02", req.EndDate, time.Local)
+		if err != nil {
+			return list, errors.New("invalid endDate")
+		}
+		// The list runs to the end of its last day; dates are stored to the
+		// millisecond.
+		endDate = endDate.AddDate(0, 0, 1).Add(-time.Millisecond)
+		list.EndDate = &endDate
+	}
+	if list.StartDate != nil && list.EndDate != nil && list.EndDate.Before(*list.StartDate) {
+		return list, errors.New("endDate must not be before startDate")
+	}
+	seen := map[primitive.ObjectID]bool{}
+	for _, line := range req.Items {
+		menuItemId, err := primitive.ObjectIDFromHex(line.MenuItemId)
+		if err != nil {
+			return list, errors.New("invalid menuItemId")
+		}
+		if seen[menuItemId] {
+			return list, errors.New("each menu item can only be listed once")
+		}
+		seen[menuItemId] = true
+		if _, err := menuItemEntity.GetMenuItemById(menuItemId); err != nil {
+			return list, errors.New("menu item not found")
+		}
+		list.Items = append(list.Items, entities.PriceListItem{MenuItemId: menuItemId, Price: line.Price})
+	}
+	return list, nil
+}
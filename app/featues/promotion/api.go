@@ -1,7 +1,9 @@
 package promotion
 
 import (
+	"errors"
 	"net/http"
+	"snook/app/core/billing"
 	"snook/app/core/constant"
 	"snook/app/core/errcode"
 	"snook/app/data/entities"
@@ -57,19 +59,15 @@ func ApplyPromotionAPI(route *gin.RouterGroup, repository *domain.Repository) {
 				errcode.Abort(ctx, http.StatusBadRequest, errcode.PM_BAD_REQUEST_001, err.Error())
 				return
 			}
-			startDate, _ := time.Parse("2006-01-02", req.StartDate)
-			endDate, _ := time.Parse("2006-01-02", req.EndDate)
-			status := req.Status
-			if status == "" {
-				status = "ACTIVE"
+			promo, err := toPromotion(req)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PM_BAD_REQUEST_001, err.Error())
+				return
 			}
-			promo := entities.Promotion{
-				Name: req.Name, Description: req.Description, Type: req.Type,
-				PlayHours: req.PlayHours, FreeHours: req.FreeHours,
-				DiscountPct: req.DiscountPct, DiscountAmt: req.DiscountAmt,
-				TableTypes: req.TableTypes, StartDate: startDate, EndDate: endDate,
-				Status: status, CreatedBy: ctx.GetString("UserId"),
+			if promo.Status == "" {
+				promo.Status = "ACTIVE"
 			}
+			promo.CreatedBy = ctx.GetString("UserId")
 			result, err := repository.Promotion.CreatePromotion(promo)
 			if err != nil {
 				errcode.Abort(ctx, http.StatusBadRequest, errcode.PM_BAD_REQUEST_002, err.Error())
@@ -90,15 +88,12 @@ func ApplyPromotionAPI(route *gin.RouterGroup, repository *domain.Repository) {
 				errcode.Abort(ctx, http.StatusBadRequest, errcode.PM_BAD_REQUEST_001, err.Error())
 				return
 			}
-			startDate, _ := time.Parse("2006-01-02", req.StartDate)
-			endDate, _ := time.Parse("2006-01-02", req.EndDate)
-			promo := entities.Promotion{
-				Name: req.Name, Description: req.Description, Type: req.Type,
-				PlayHours: req.PlayHours, FreeHours: req.FreeHours,
-				DiscountPct: req.DiscountPct, DiscountAmt: req.DiscountAmt,
-				TableTypes: req.TableTypes, StartDate: startDate, EndDate: endDate,
-				Status: req.Status, UpdatedBy: ctx.GetString("UserId"),
+			promo, err := toPromotion(req)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PM_BAD_REQUEST_001, err.Error())
+				return
 			}
+			promo.UpdatedBy = ctx.GetString("UserId")
 			if err := repository.Promotion.UpdatePromotionById(id, promo); err != nil {
 				errcode.Abort(ctx, http.StatusBadRequest, errcode.PM_BAD_REQUEST_002, err.Error())
 				return
@@ -120,3 +115,75 @@ func ApplyPromotionAPI(route *gin.RouterGroup, repository *domain.Repository) {
 			ctx.JSON(http.StatusOK, gin.H{"message": "success"})
 		})
 }
+
+func toPromotion(req request.Promotion) (entities.Promotion, error) {
+	if err := billing.ValidateDaysOfWeek(req.DaysOfWeek); err != nil {
+		return entities.Promotion{}, err
+	}
+	if err := billing.ValidateTimeWindow(req.StartTimeOfDay, req.EndTimeOfDay); err != nil {
+		return entities.Promotion{}, err
+	}
+	if req.MinSpend < 0 || req.MaxUses < 0 || req.MaxUsesPerCustomer < 0 {
+		return entities.Promotion{}, errors.New("limits must not be negative")
+	}
+	startDate, err := time.ParseInLocation("2006-01-02", req.StartDate, time.Local)
+	if err != nil {
+		return entities.Promotion{}, errors.New("startDate must be in YYYY-MM-DD format")
+	}
+	endDate, err := time.ParseInLocation("2006-01-02", req.EndDate, time.Local)
+	if err != nil {
+		return entities.Promotion{}, errors.New("endDate must be in YYYY-MM-DD format")
+	}
+	promo := entities.Promotion{
+		Name: req.Name, Description: req.Description, Type: req.Type,
+		PlayHours: req.PlayHours, FreeHours: req.FreeHours,
+		DiscountPct: req.DiscountPct, DiscountAmt: req.DiscountAmt,
+		TableTypes: req.TableTypes, StartDate: startDate, EndDate: endDate,
+		DaysOfWeek: req.DaysOfWeek, StartTimeOfDay: req.StartTimeOfDay, EndTimeOfDay: req.EndTimeOfDay,
+		MemberOnly: req.MemberOnly, MinSpend: req.MinSpend,
+		MaxUses: req.MaxUses, MaxUsesPerCustomer: req.MaxUsesPerCustomer,
+		Priority: req.Priority, Stackable: req.Stackable, AutoApply: req.AutoApply,
+		BuyQuantity: req.BuyQuantity, GetQuantity: req.GetQuantity,
+		Category: req.Category, BundlePrice: req.BundlePrice,
+		Status: req.Status,
+	}
+	if req.MenuItemId != "" {
+		menuItemId, err := primitive.ObjectIDFromHex(req.MenuItemId)
+		if err != nil {
+			return promo, errors.New("invalid menuItemId")
+		}
+		promo.MenuItemId = &menuItemId
+	}
+	for _, item := range req.BundleItems {
+		menuItemId, err := primitive.ObjectIDFromHex(item.MenuItemId)
+		if err != nil {
+			return promo, errors.New("invalid bundle menuItemId")
+		}
+		if item.Quantity <= 0 {
+			return promo, errors.New("bundle quantity must be greater than 0")
+		}
+		promo.BundleItems = append(promo.BundleItems, entities.BundleItem{MenuItemId: menuItemId, Quantity: item.Quantity})
+	}
+	switch promo.Type {
+	case "FREE_HOURS", "DISCOUNT_PCT", "DISCOUNT_AMT":
+	case "HAPPY_HOUR":
+		if promo.StartTimeOfDay == "" || promo.DiscountPct <= 0 || promo.DiscountPct > 100 {
+			return promo, errors.New("time window and discountPct between 0 and 100 are required")
+		}
+	case "BUY_X_GET_Y":
+		if promo.MenuItemId == nil || promo.BuyQuantity <= 0 || promo.GetQuantity <= 0 {
+			return promo, errors.New("menuItemId, buyQuantity and getQuantity are required")
+		}
+	case "CATEGORY_PCT":
+		if promo.Category == "" || promo.DiscountPct <= 0 {
+			return promo, errors.New("category and discountPct are required")
+		}
+	case "BUNDLE":
+		if promo.BundlePrice <= 0 || (promo.PlayHours <= 0 && len(promo.BundleItems) == 0) {
+			return promo, errors.New("bundlePrice and playHours or bundleItems are required")
+		}
+	default:
+		return promo, errors.New("invalid promotion type")
+	}
+	return promo, nil
+}
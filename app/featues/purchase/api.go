@@ -0,0 +1,329 @@
+package purchase
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"snook/app/core/constant"
+	"snook/app/core/errcode"
+	"snook/app/data/entities"
+	"snook/app/data/repositories"
+	"snook/app/domain"
+	"snook/app/domain/request"
+	"snook/middlewares"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func ApplyPurchaseAPI(route *gin.RouterGroup, repository *domain.Repository) {
+	// ─── Suppliers ──────────────────────────────────
+	supplierRoute := route.Group("suppliers")
+
+	supplierRoute.GET("", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		suppliers, err := repository.Purchase.GetSuppliers(ctx.Query("status"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.SP_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, suppliers)
+	})
+
+	supplierRoute.GET("/:supplierId", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		id, err := primitive.ObjectIDFromHex(ctx.Param("supplierId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SP_BAD_REQUEST_001, "invalid supplierId")
+			return
+		}
+		supplier, err := repository.Purchase.GetSupplierById(id)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SP_BAD_REQUEST_002, "supplier not found")
+			return
+		}
+		ctx.JSON(http.StatusOK, supplier)
+	})
+
+	supplierRoute.POST("", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			var req request.Supplier
+			if err := ctx.ShouldBindJSON(&req); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.SP_BAD_REQUEST_001, err.Error())
+				return
+			}
+			status := req.Status
+			if status == "" {
+				status = "ACTIVE"
+			}
+			supplier := entities.Supplier{
+				Name: req.Name, ContactName: req.ContactName, Phone: req.Phone,
+				Email: req.Email, Address: req.Address, TaxId: req.TaxId,
+				Note: req.Note, Status: status, CreatedBy: ctx.GetString("UserId"),
+			}
+			result, err := repository.Purchase.CreateSupplier(supplier)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.SP_BAD_REQUEST_002, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusCreated, result)
+		})
+
+	supplierRoute.PUT("/:supplierId", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			id, err := primitive.ObjectIDFromHex(ctx.Param("supplierId"))
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.SP_BAD_REQUEST_001, "invalid supplierId")
+				return
+			}
+			var req request.Supplier
+			if err := ctx.ShouldBindJSON(&req); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.SP_BAD_REQUEST_001, err.Error())
+				return
+			}
+			supplier := entities.Supplier{
+				Name: req.Name, ContactName: req.ContactName, Phone: req.Phone,
+				Email: req.Email, Address: req.Address, TaxId: req.TaxId,
+				Note: req.Note, Status: req.Status, UpdatedBy: ctx.GetString("UserId"),
+			}
+			if err := repository.Purchase.UpdateSupplierById(id, supplier); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.SP_BAD_REQUEST_002, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusOK, gin.H{"message": "success"})
+		})
+
+	// ─── Purchase Orders ────────────────────────────
+	poRoute := route.Group("purchase-orders")
+
+	poRoute.GET("", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		var supplierId *primitive.ObjectID
+		if s := ctx.Query("supplierId"); s != "" {
+			id, err := primitive.ObjectIDFromHex(s)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PO_BAD_REQUEST_001, "invalid supplierId")
+				return
+			}
+			supplierId = &id
+		}
+		orders, err := repository.Purchase.GetPurchaseOrders(ctx.Query("status"), supplierId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.PO_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, orders)
+	})
+
+	poRoute.GET("/:poId", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		id, err := primitive.ObjectIDFromHex(ctx.Param("poId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PO_BAD_REQUEST_001, "invalid poId")
+			return
+		}
+		po, err := repository.Purchase.GetPurchaseOrderById(id)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PO_BAD_REQUEST_002, "purchase order not found")
+			return
+		}
+		ctx.JSON(http.StatusOK, po)
+	})
+
+	poRoute.POST("", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			var req request.PurchaseOrder
+			if err := ctx.ShouldBindJSON(&req); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PO_BAD_REQUEST_001, err.Error())
+				return
+			}
+			po, err := toPurchaseOrder(repository, req)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PO_BAD_REQUEST_001, err.Error())
+				return
+			}
+			if po.Status == "" {
+				po.Status = "DRAFT"
+			}
+			if po.Status != "DRAFT" && po.Status != "ORDERED" {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PO_BAD_REQUEST_001, "status must be DRAFT or ORDERED")
+				return
+			}
+			po.CreatedBy = ctx.GetString("UserId")
+			result, err := repository.Purchase.CreatePurchaseOrder(po)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PO_BAD_REQUEST_002, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusCreated, result)
+		})
+
+	poRoute.PUT("/:poId", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			id, err := primitive.ObjectIDFromHex(ctx.Param("poId"))
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PO_BAD_REQUEST_001, "invalid poId")
+				return
+			}
+			var req request.PurchaseOrder
+			if err := ctx.ShouldBindJSON(&req); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PO_BAD_REQUEST_001, err.Error())
+				return
+			}
+			current, err := repository.Purchase.GetPurchaseOrderById(id)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PO_BAD_REQUEST_002, "purchase order not found")
+				return
+			}
+			if current.Status != "DRAFT" && current.Status != "ORDERED" {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PO_BAD_REQUEST_002, "only draft or ordered purchase orders can be edited")
+				return
+			}
+			po, err := toPurchaseOrder(repository, req)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PO_BAD_REQUEST_001, err.Error())
+				return
+			}
+			if po.Status == "" {
+				po.Status = current.Status
+			}
+			if po.Status != "DRAFT" && po.Status != "ORDERED" {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PO_BAD_REQUEST_001, "status must be DRAFT or ORDERED")
+				return
+			}
+			po.UpdatedBy = ctx.GetString("UserId")
+			err = repository.Purchase.UpdatePurchaseOrderById(id, po)
+			if errors.Is(err, repositories.ErrPurchaseOrderNotEditable) {
+				errcode.Abort(ctx, http.StatusConflict, errcode.PO_CONFLICT_001, err.Error())
+				return
+			}
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PO_BAD_REQUEST_002, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusOK, gin.H{"message": "success"})
+		})
+
+	poRoute.POST("/:poId/cancel", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			id, err := primitive.ObjectIDFromHex(ctx.Param("poId"))
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PO_BAD_REQUEST_001, "invalid poId")
+				return
+			}
+			po, err := repository.Purchase.GetPurchaseOrderById(id)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PO_BAD_REQUEST_002, "purchase order not found")
+				return
+			}
+			if po.Status != "DRAFT" && po.Status != "ORDERED" {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PO_BAD_REQUEST_002, "only draft or ordered purchase orders can be cancelled")
+				return
+			}
+			po.Status = "CANCELLED"
+			po.UpdatedBy = ctx.GetString("UserId")
+			err = repository.Purchase.UpdatePurchaseOrderById(id, po)
+			if errors.Is(err, repositories.ErrPurchaseOrderNotEditable) {
+				errcode.Abort(ctx, http.StatusConflict, errcode.PO_CONFLICT_001, err.Error())
+				return
+			}
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PO_BAD_REQUEST_002, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusOK, gin.H{"message": "success"})
+		})
+
+	poRoute.POST("/:poId/receive", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			id, err := primitive.ObjectIDFromHex(ctx.Param("poId"))
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PO_BAD_REQUEST_001, "invalid poId")
+				return
+			}
+			var req request.ReceivePurchaseOrder
+			if err := ctx.ShouldBindJSON(&req); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PO_BAD_REQUEST_001, err.Error())
+				return
+			}
+			po, err := repository.Purchase.GetPurchaseOrderById(id)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PO_BAD_REQUEST_002, "purchase order not found")
+				return
+			}
+			// Without lines the whole outstanding quantity is received.
+			var items []entities.PurchaseOrderItem
+			for _, line := range po.Items {
+				if len(req.Items) == 0 && line.Quantity > line.ReceivedQuantity {
+					items = append(items, entities.PurchaseOrderItem{MenuItemId: line.MenuItemId, Quantity: line.Quantity - line.ReceivedQuantity})
+				}
+			}
+			for _, line := range req.Items {
+				menuItemId, err := primitive.ObjectIDFromHex(line.MenuItemId)
+				if err != nil {
+					errcode.Abort(ctx, http.StatusBadRequest, errcode.PO_BAD_REQUEST_001, "invalid menuItemId")
+					return
+				}
+				items = append(items, entities.PurchaseOrderItem{MenuItemId: menuItemId, Quantity: line.Quantity, UnitCost: line.UnitCost})
+			}
+			if len(items) == 0 {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PO_BAD_REQUEST_002, "nothing left to receive")
+				return
+			}
+			receipt, err := repository.Purchase.ReceivePurchaseOrder(id, items, ctx.GetString("UserId"))
+			if errors.Is(err, repositories.ErrPurchaseOrderNotReceivable) {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PO_BAD_REQUEST_002, "only ordered purchase orders can be received")
+				return
+			}
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PO_BAD_REQUEST_002, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusOK, receipt)
+		})
+}
+
+func toPurchaseOrder(repository *domain.Repository, req request.PurchaseOrder) (entities.PurchaseOrder, error) {
+	supplierId, err := primitive.ObjectIDFromHex(req.SupplierId)
+	if err != nil {
+		return entities.PurchaseOrder{}, errors.New("invalid supplierId")
+	}
+	supplier, err := repository.Purchase.GetSupplierById(supplierId)
+	if err != nil {
+		return entities.PurchaseOrder{}, errors.New("supplier not found")
+	}
+	po := entities.PurchaseOrder{
+		SupplierId: supplier.Id, SupplierName: supplier.Name,
+		Note: req.Note, Status: req.Status,
+	}
+	if req.ExpectedDate != "" {
+		expectedDate, err := time.Parse("2006-01-02", req.ExpectedDate)
+		if err != nil {
+			return po, errors.New("invalid expectedDate")
+		}
+		po.ExpectedDate = &expectedDate
+	}
+	seen := map[primitive.ObjectID]bool{}
+	for _, line := range req.Items {
+		menuItemId, err := primitive.ObjectIDFromHex(line.MenuItemId)
+		if err != nil {
+			return po, errors.New("invalid menuItemId")
+		}
+		if seen[menuItemId] {
+			return po, errors.New("each menu item can only appear once")
+		}
+		seen[menuItemId] = true
+		if line.Quantity <= 0 || line.UnitCost < 0 {
+			return po, errors.New("quantity must be greater than 0 and unitCost must not be negative")
+		}
+		item, err := repository.MenuItem.GetMenuItemById(menuItemId)
+		if err != nil {
+			return po, errors.New("menu item not found")
+		}
+		total := math.Round(float64(line.Quantity)*line.UnitCost*100) / 100
+		po.Items = append(po.Items, entities.PurchaseOrderItem{
+			MenuItemId: menuItemId, Name: item.Name,
+			Quantity: line.Quantity, UnitCost: line.UnitCost, Total: total,
+		})
+		po.Total += total
+	}
+	po.Total = math.Round(po.Total*100) / 100
+	return po, nil
+}
@@ -2,7 +2,9 @@ package report
 
 import (
 	"net/http"
+	"snook/app/core/businessday"
 	"snook/app/core/errcode"
+	"snook/app/data/entities"
 	"snook/app/domain"
 	"snook/middlewares"
 	"time"
@@ -21,16 +23,23 @@ func ApplyReportAPI(route *gin.RouterGroup, repository *domain.Repository) {
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.RP_BAD_REQUEST_001, "startDate and endDate required")
 			return
 		}
-		start, _ := time.Parse("2006-01-02", startDate)
-		end, _ := time.Parse("2006-01-02", endDate)
-		end = end.Add(24*time.Hour - time.Nanosecond)
+		// The dates are business days, so a session played past midnight
+		// counts towards the day it started on.
+		setting, _ := repository.Setting.GetSetting()
+		start, end, err := businessday.Span(startDate, endDate, setting.CutoverHour)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.RP_BAD_REQUEST_001, err.Error())
+			return
+		}
 
 		sessions, err := repository.TableSession.GetTableSessions(start, end)
 		if err != nil {
 			errcode.Abort(ctx, http.StatusInternalServerError, errcode.RP_INTERNAL_001, err.Error())
 			return
 		}
-		expenses, err := repository.Expense.GetExpenses(start, end)
+		firstDay, _ := time.Parse(businessday.Layout, startDate)
+		lastDay, _ := time.Parse(businessday.Layout, endDate)
+		expenses, err := repository.Expense.GetExpenses(firstDay, lastDay.Add(24*time.Hour-time.Nanosecond))
 		if err != nil {
 			errcode.Abort(ctx, http.StatusInternalServerError, errcode.RP_INTERNAL_001, err.Error())
 			return
@@ -46,6 +55,22 @@ func ApplyReportAPI(route *gin.RouterGroup, repository *domain.Repository) {
 				totalFoodIncome += s.FoodTotal
 			}
 		}
+		// Approved refunds and voids are negative payments booked on the day
+		// they were approved, whatever day the session closed.
+		payments, err := repository.Payment.GetPaymentsByDateRange(start, end)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.RP_INTERNAL_001, err.Error())
+			return
+		}
+		refunds := []entities.Payment{}
+		totalRefund := 0.0
+		for _, p := range payments {
+			if p.ReversalOf != nil {
+				refunds = append(refunds, p)
+				totalRefund += p.Amount
+			}
+		}
+		totalIncome += totalRefund
 		totalExpense := 0.0
 		for _, e := range expenses {
 			totalExpense += e.Amount
@@ -58,10 +83,12 @@ func ApplyReportAPI(route *gin.RouterGroup, repository *domain.Repository) {
 			"totalIncome":      totalIncome,
 			"totalTableCharge": totalTableCharge,
 			"totalFoodIncome":  totalFoodIncome,
+			"totalRefund":      totalRefund,
 			"totalExpense":     totalExpense,
 			"netProfit":        totalIncome - totalExpense,
 			"sessions":         sessions,
 			"expenses":         expenses,
+			"refunds":          refunds,
 		})
 	})
 
@@ -2,6 +2,7 @@ package setting
 
 import (
 	"net/http"
+	"snook/app/core/billing"
 	"snook/app/core/constant"
 	"snook/app/core/errcode"
 	"snook/app/data/entities"
@@ -43,4 +44,71 @@ func ApplySettingAPI(route *gin.RouterGroup, repository *domain.Repository) {
 			}
 			ctx.JSON(http.StatusOK, gin.H{"message": "success"})
 		})
+
+	r.GET("/payment-methods", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		s, _ := repository.Setting.GetSetting()
+		ctx.JSON(http.StatusOK, billing.PaymentMethods(s))
+	})
+
+	r.PUT("/payment-methods", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			var req request.PaymentMethods
+			if err := ctx.ShouldBindJSON(&req); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.SE_BAD_REQUEST_001, err.Error())
+				return
+			}
+			methods := make([]entities.PaymentMethod, len(req.Methods))
+			for i, m := range req.Methods {
+				methods[i] = entities.PaymentMethod{
+					Code: m.Code, Label: m.Label, Active: m.Active,
+					CashInDrawer: m.CashInDrawer, FeePct: m.FeePct,
+				}
+			}
+			methods, err := billing.ValidatePaymentMethods(methods)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.SE_BAD_REQUEST_001, err.Error())
+				return
+			}
+			if err := repository.Setting.UpdatePaymentMethods(methods, ctx.GetString("UserId")); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.SE_BAD_REQUEST_002, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusOK, methods)
+		})
+
+	r.PUT("/business-day", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			var req request.BusinessDay
+			if err := ctx.ShouldBindJSON(&req); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.SE_BAD_REQUEST_001, err.Error())
+				return
+			}
+			if err := repository.Setting.UpdateCutoverHour(*req.CutoverHour, ctx.GetString("UserId")); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.SE_BAD_REQUEST_002, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusOK, gin.H{"message": "success"})
+		})
+
+	r.PUT("/loyalty", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			var req request.LoyaltySetting
+			if err := ctx.ShouldBindJSON(&req); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.SE_BAD_REQUEST_001, err.Error())
+				return
+			}
+			if req.TablePointsPerBaht < 0 || req.FoodPointsPerBaht < 0 || req.PointValue < 0 || req.ExpiryDays < 0 {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.SE_BAD_REQUEST_001, "loyalty settings must not be negative")
+				return
+			}
+			loyalty := entities.LoyaltySetting{
+				TablePointsPerBaht: req.TablePointsPerBaht, FoodPointsPerBaht: req.FoodPointsPerBaht,
+				PointValue: req.PointValue, ExpiryDays: req.ExpiryDays,
+			}
+			if err := repository.Setting.UpdateLoyaltySetting(loyalty, ctx.GetString("UserId")); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.SE_BAD_REQUEST_002, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusOK, gin.H{"message": "success"})
+		})
 }
@@ -1,15 +1,24 @@
 package table_order
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"snook/app/core/billing"
+	"snook/app/core/businessday"
+	"snook/app/core/constant"
 	"snook/app/core/errcode"
 	"snook/app/data/entities"
+	"snook/app/data/repositories"
 	"snook/app/domain"
 	"snook/app/domain/request"
 	"snook/middlewares"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func ApplyTableOrderAPI(route *gin.RouterGroup, repository *domain.Repository) {
@@ -29,58 +38,266 @@ func ApplyTableOrderAPI(route *gin.RouterGroup, repository *domain.Repository) {
 		ctx.JSON(http.StatusOK, orders)
 	})
 
-	r.POST("", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+	r.POST("", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), middlewares.RequireOpenBusinessDay(repository.Setting, repository.DayClose), func(ctx *gin.Context) {
 		var req request.TableOrder
 		if err := ctx.ShouldBindJSON(&req); err != nil {
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.TO_BAD_REQUEST_001, err.Error())
 			return
 		}
-		sessionId, _ := primitive.ObjectIDFromHex(req.SessionId)
-		menuItemId, _ := primitive.ObjectIDFromHex(req.MenuItemId)
-		menuItem, err := repository.MenuItem.GetMenuItemById(menuItemId)
+		sessionId, err := primitive.ObjectIDFromHex(req.SessionId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TO_BAD_REQUEST_001, "invalid sessionId")
+			return
+		}
+		session, err := repository.TableSession.GetTableSessionById(sessionId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TO_BAD_REQUEST_002, "session not found")
+			return
+		}
+		if session.Status == "CLOSED" {
+			errcode.Abort(ctx, http.StatusConflict, errcode.TO_CONFLICT_002, "session is closed")
+			return
+		}
+		var menuItem entities.MenuItem
+		if req.MenuItemId != "" {
+			menuItemId, _ := primitive.ObjectIDFromHex(req.MenuItemId)
+			menuItem, err = repository.MenuItem.GetMenuItemById(menuItemId)
+		} else {
+			code := strings.TrimSpace(req.Barcode)
+			if code == "" {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.TO_BAD_REQUEST_001, "menuItemId or barcode is required")
+				return
+			}
+			menuItem, err = repository.MenuItem.GetMenuItemByCode(code)
+		}
 		if err != nil {
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.TO_BAD_REQUEST_002, "menu item not found")
 			return
 		}
-		if menuItem.Quantity < req.Quantity {
-			errcode.Abort(ctx, http.StatusBadRequest, errcode.TO_BAD_REQUEST_002, "insufficient stock")
+		if variants, _ := repository.MenuItem.GetMenuItemVariants(menuItem.Id); len(variants) > 0 {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TO_BAD_REQUEST_001, "choose a variant of "+menuItem.Name)
 			return
 		}
-		total := (menuItem.Price * float64(req.Quantity)) - req.Discount
+		category := orderCategory(repository, menuItem.CategoryId, menuItem.Category)
+		now := time.Now()
+		if !billing.AvailableAt(menuItem.Availability, now) {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TO_BAD_REQUEST_001, menuItem.Name+" is not available at this time")
+			return
+		}
+		lists, _ := repository.PriceList.GetCurrentPriceLists(now)
+		listPrice, priceListId := billing.MenuItemPrice(menuItem, lists, now)
+		name := menuItem.Name
+		if menuItem.VariantName != "" {
+			name += " - " + menuItem.VariantName
+		}
+		modifiers, delta, err := resolveModifiers(menuItem, req.Modifiers)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TO_BAD_REQUEST_001, err.Error())
+			return
+		}
+		price := math.Max(listPrice+delta, 0)
+		total := (price * float64(req.Quantity)) - req.Discount
 		if total < 0 {
 			total = 0
 		}
 		order := entities.TableOrder{
-			SessionId: sessionId, MenuItemId: menuItemId,
-			Name: menuItem.Name, Price: menuItem.Price, CostPrice: menuItem.CostPrice,
-			Quantity: req.Quantity, Discount: req.Discount, Total: total,
+			SessionId: sessionId, MenuItemId: menuItem.Id,
+			Name: name, BasePrice: listPrice, Price: price, PriceListId: priceListId, CostPrice: menuItem.CostPrice,
+			CategoryId: menuItem.CategoryId, Category: menuItem.Category, TaxClass: category.TaxClass,
+			Modifiers: modifiers,
+			Quantity:  req.Quantity, Discount: req.Discount, Total: total,
 			CreatedBy: ctx.GetString("UserId"),
 		}
-		result, err := repository.TableOrder.CreateTableOrder(order)
+		result, err := repository.TableOrder.PlaceTableOrder(order)
+		if errors.Is(err, repositories.ErrOutOfStock) {
+			errcode.Abort(ctx, http.StatusConflict, errcode.TO_CONFLICT_001, "insufficient stock")
+			return
+		}
 		if err != nil {
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.TO_BAD_REQUEST_002, err.Error())
 			return
 		}
-		_ = repository.MenuItem.UpdateMenuItemQuantity(menuItemId, -req.Quantity)
+		queueOrderTicket(repository, result)
 		ctx.JSON(http.StatusCreated, result)
 	})
 
-	r.DELETE("/:orderId", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
-		orderId, err := primitive.ObjectIDFromHex(ctx.Param("orderId"))
-		if err != nil {
-			errcode.Abort(ctx, http.StatusBadRequest, errcode.TO_BAD_REQUEST_001, "invalid orderId")
+	r.PATCH("/:orderId", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), middlewares.RequireOpenBusinessDay(repository.Setting, repository.DayClose), func(ctx *gin.Context) {
+		order, ok := getOpenOrder(ctx, repository)
+		if !ok {
 			return
 		}
-		order, err := repository.TableOrder.GetTableOrderById(orderId)
-		if err != nil {
-			errcode.Abort(ctx, http.StatusBadRequest, errcode.TO_BAD_REQUEST_002, "order not found")
+		var req request.UpdateTableOrder
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TO_BAD_REQUEST_001, err.Error())
 			return
 		}
-		if err := repository.TableOrder.DeleteTableOrder(orderId); err != nil {
-			errcode.Abort(ctx, http.StatusBadRequest, errcode.TO_BAD_REQUEST_002, err.Error())
+		if req.Quantity < order.Quantity && req.Reason == "" {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TO_BAD_REQUEST_001, "reason is required when reducing quantity")
 			return
 		}
-		_ = repository.MenuItem.UpdateMenuItemQuantity(order.MenuItemId, order.Quantity)
-		ctx.JSON(http.StatusOK, gin.H{"message": "success"})
+		adjustment := entities.OrderAdjustment{
+			Type: "QUANTITY", ToQuantity: req.Quantity, Discount: req.Discount, Reason: req.Reason,
+		}
+		// Increases at the same discount are new sales; reductions and
+		// discount changes need a manager.
+		adjustOrder(ctx, repository, order, adjustment, req.Quantity > order.Quantity && req.Discount == order.Discount)
 	})
+
+	// Orders are never deleted; DELETE is kept for older clients and requests
+	// a void like POST /void does.
+	voidOrder := func(ctx *gin.Context) {
+		order, ok := getOpenOrder(ctx, repository)
+		if !ok {
+			return
+		}
+		var req request.VoidTableOrder
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TO_BAD_REQUEST_001, err.Error())
+			return
+		}
+		adjustOrder(ctx, repository, order, entities.OrderAdjustment{Type: "VOID", ToQuantity: 0, Reason: req.Reason}, false)
+	}
+	r.POST("/:orderId/void", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), middlewares.RequireOpenBusinessDay(repository.Setting, repository.DayClose), voidOrder)
+	r.DELETE("/:orderId", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), middlewares.RequireOpenBusinessDay(repository.Setting, repository.DayClose), voidOrder)
+
+	r.GET("/adjustments/pending", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			orders, err := repository.TableOrder.GetPendingOrderAdjustments()
+			if err != nil {
+				errcode.Abort(ctx, http.StatusInternalServerError, errcode.TO_INTERNAL_001, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusOK, orders)
+		})
+
+	r.POST("/:orderId/adjustments/:adjustmentId/approve", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN),
+		middlewares.RequireOpenBusinessDay(repository.Setting, repository.DayClose), func(ctx *gin.Context) {
+			order, ok := getOpenOrder(ctx, repository)
+			if !ok {
+				return
+			}
+			adjustmentId, err := primitive.ObjectIDFromHex(ctx.Param("adjustmentId"))
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.TO_BAD_REQUEST_001, "invalid adjustmentId")
+				return
+			}
+			result, err := repository.TableOrder.ApproveOrderAdjustment(order.Id, adjustmentId, ctx.GetString("UserId"))
+			if !abortAdjustmentError(ctx, err) {
+				return
+			}
+			updateOrderTickets(repository, order, result, ctx.GetString("UserId"))
+			ctx.JSON(http.StatusOK, result)
+		})
+
+	r.POST("/:orderId/adjustments/:adjustmentId/reject", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), middlewares.RequireOpenBusinessDay(repository.Setting, repository.DayClose), func(ctx *gin.Context) {
+			order, ok := getOpenOrder(ctx, repository)
+			if !ok {
+				return
+			}
+			adjustmentId, err := primitive.ObjectIDFromHex(ctx.Param("adjustmentId"))
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.TO_BAD_REQUEST_001, "invalid adjustmentId")
+				return
+			}
+			if err := repository.TableOrder.RejectOrderAdjustment(order.Id, adjustmentId, ctx.GetString("UserId")); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.TO_BAD_REQUEST_002, "pending adjustment not found")
+				return
+			}
+			ctx.JSON(http.StatusOK, gin.H{"message": "success"})
+		})
+
+	r.GET("/voids/report", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			startDate := ctx.Query("startDate")
+			endDate := ctx.Query("endDate")
+			if startDate == "" || endDate == "" {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.TO_BAD_REQUEST_001, "startDate and endDate required")
+				return
+			}
+			// Voids after midnight count towards the business day they
+			// were made on.
+			setting, err := repository.Setting.GetSetting()
+			if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+				errcode.Abort(ctx, http.StatusInternalServerError, errcode.TO_INTERNAL_001, err.Error())
+				return
+			}
+			start, end, err := businessday.Span(startDate, endDate, setting.CutoverHour)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.TO_BAD_REQUEST_001, err.Error())
+				return
+			}
+			report, err := repository.TableOrder.GetVoidReport(start, end)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusInternalServerError, errcode.TO_INTERNAL_001, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusOK, report)
+		})
+}
+
+// getOpenOrder loads the order of the orderId param and aborts when it is
+// missing or its session is already closed.
+func getOpenOrder(ctx *gin.Context, repository *domain.Repository) (entities.TableOrder, bool) {
+	orderId, err := primitive.ObjectIDFromHex(ctx.Param("orderId"))
+	if err != nil {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.TO_BAD_REQUEST_001, "invalid orderId")
+		return entities.TableOrder{}, false
+	}
+	order, err := repository.TableOrder.GetTableOrderById(orderId)
+	if err != nil {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.TO_BAD_REQUEST_002, "order not found")
+		return entities.TableOrder{}, false
+	}
+	session, err := repository.TableSession.GetTableSessionById(order.SessionId)
+	if err != nil {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.TO_BAD_REQUEST_002, "session not found")
+		return entities.TableOrder{}, false
+	}
+	if session.Status == "CLOSED" {
+		errcode.Abort(ctx, http.StatusConflict, errcode.TO_CONFLICT_002, "session is closed")
+		return entities.TableOrder{}, false
+	}
+	return order, true
+}
+
+// adjustOrder applies the adjustment right away when it needs no approval
+// or the caller is a manager, otherwise it is left pending for a manager.
+func adjustOrder(ctx *gin.Context, repository *domain.Repository, order entities.TableOrder, adjustment entities.OrderAdjustment, selfApproved bool) {
+	userId := ctx.GetString("UserId")
+	role := ctx.GetString("Role")
+	adjustment.RequestedBy = userId
+	adjustment.Status = "PENDING"
+	if selfApproved || role == constant.SUPER || role == constant.ADMIN {
+		adjustment.Status = "APPROVED"
+		adjustment.ApprovedBy = userId
+	}
+	result, err := repository.TableOrder.AdjustTableOrder(order.Id, adjustment)
+	if !abortAdjustmentError(ctx, err) {
+		return
+	}
+	if adjustment.Status == "PENDING" {
+		ctx.JSON(http.StatusAccepted, result)
+		return
+	}
+	updateOrderTickets(repository, order, result, userId)
+	ctx.JSON(http.StatusOK, result)
+}
+
+func abortAdjustmentError(ctx *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, repositories.ErrOutOfStock):
+		errcode.Abort(ctx, http.StatusConflict, errcode.TO_CONFLICT_001, "insufficient stock")
+	case errors.Is(err, repositories.ErrOrderVoided),
+		errors.Is(err, repositories.ErrOrderAdjustmentPending),
+		errors.Is(err, repositories.ErrOrderAdjustmentStale):
+		errcode.Abort(ctx, http.StatusConflict, errcode.TO_CONFLICT_003, err.Error())
+	case errors.Is(err, mongo.ErrNoDocuments):
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.TO_BAD_REQUEST_002, "pending adjustment not found")
+	default:
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.TO_BAD_REQUEST_002, err.Error())
+	}
+	return false
 }
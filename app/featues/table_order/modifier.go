@@ -0,0 +1,57 @@
+package table_order
+
+import (
+	"errors"
+	"snook/app/data/entities"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// resolveModifiers checks the chosen option ids against the item's modifier
+// groups and returns the order modifiers with their summed price delta.
+func resolveModifiers(item entities.MenuItem, optionIds []string) ([]entities.OrderModifier, float64, error) {
+	chosen := map[primitive.ObjectID]bool{}
+	for _, hex := range optionIds {
+		id, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			return nil, 0, errors.New("invalid modifier option id")
+		}
+		if chosen[id] {
+			return nil, 0, errors.New("each modifier option can only be chosen once")
+		}
+		chosen[id] = true
+	}
+	var modifiers []entities.OrderModifier
+	delta := 0.0
+	for _, group := range item.Modifiers {
+		selected := 0
+		for _, option := range group.Options {
+			if !chosen[option.Id] {
+				continue
+			}
+			if option.Status != "ACTIVE" {
+				return nil, 0, errors.New(option.Name + " is not available")
+			}
+			delete(chosen, option.Id)
+			selected++
+			delta += option.PriceDelta
+			modifiers = append(modifiers, entities.OrderModifier{
+				GroupId: group.Id, GroupName: group.Name,
+				OptionId: option.Id, Name: option.Name, PriceDelta: option.PriceDelta,
+			})
+		}
+		if group.Required && selected == 0 {
+			return nil, 0, errors.New(group.Name + " is required")
+		}
+		if group.MaxSelect > 0 && selected > group.MaxSelect {
+			if group.MaxSelect == 1 {
+				return nil, 0, errors.New("only one option can be chosen for " + group.Name)
+			}
+			return nil, 0, errors.New("too many options chosen for " + group.Name)
+		}
+	}
+	if len(chosen) > 0 {
+		return nil, 0, errors.New("modifier option does not belong to this menu item")
+	}
+	return modifiers, delta, nil
+}
@@ -0,0 +1,78 @@
+package table_order
+
+import (
+	"snook/app/data/entities"
+	"snook/app/domain"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// orderCategory looks up the category of an order line by id, or by name for
+// items not yet linked to a category. A missing category yields the zero
+// value.
+func orderCategory(repository *domain.Repository, categoryId *primitive.ObjectID, name string) entities.MenuCategory {
+	var category entities.MenuCategory
+	if categoryId != nil {
+		category, _ = repository.MenuCategory.GetMenuCategoryById(*categoryId)
+	} else {
+		category, _ = repository.MenuCategory.GetMenuCategoryByName(name)
+	}
+	return category
+}
+
+// queueOrderTicket routes the order line to the station of its menu
+// category. Categories without a station go to the kitchen and NONE skips
+// preparation. The order is already saved, so failures are only logged.
+func queueOrderTicket(repository *domain.Repository, order entities.TableOrder) {
+	station := "KITCHEN"
+	if category := orderCategory(repository, order.CategoryId, order.Category); category.Station != "" {
+		station = category.Station
+	}
+	if station == "NONE" {
+		return
+	}
+	ticket := entities.OrderTicket{
+		OrderId: order.Id, SessionId: order.SessionId, MenuItemId: order.MenuItemId,
+		Name: order.Name, Category: order.Category, Station: station,
+		Quantity: order.Quantity, Modifiers: order.Modifiers, CreatedBy: order.CreatedBy,
+	}
+	if session, err := repository.TableSession.GetTableSessionById(order.SessionId); err == nil {
+		ticket.TableName = session.TableName
+	}
+	result, err := repository.OrderTicket.CreateOrderTicket(ticket)
+	if err != nil {
+		logrus.Error("failed to queue ticket for order ", order.Id.Hex(), ": ", err)
+		return
+	}
+	if err := repository.OrderTicket.PublishOrderTicket(result); err != nil {
+		logrus.Error("failed to publish ticket ", result.Id.Hex(), ": ", err)
+	}
+}
+
+func cancelOrderTickets(repository *domain.Repository, order entities.TableOrder, userId string) {
+	tickets, err := repository.OrderTicket.CancelOrderTickets(order.Id, userId)
+	if err != nil {
+		logrus.Error("failed to cancel tickets of order ", order.Id.Hex(), ": ", err)
+		return
+	}
+	for _, ticket := range tickets {
+		if err := repository.OrderTicket.PublishOrderTicket(ticket); err != nil {
+			logrus.Error("failed to publish ticket ", ticket.Id.Hex(), ": ", err)
+		}
+	}
+}
+
+// updateOrderTickets follows an applied adjustment: a void cancels the open
+// tickets and an increase queues the extra quantity.
+func updateOrderTickets(repository *domain.Repository, before, after entities.TableOrder, userId string) {
+	if after.Status == "VOID" {
+		cancelOrderTickets(repository, after, userId)
+		return
+	}
+	if after.Quantity > before.Quantity {
+		extra := after
+		extra.Quantity = after.Quantity - before.Quantity
+		queueOrderTicket(repository, extra)
+	}
+}
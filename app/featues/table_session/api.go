@@ -35,36 +35,90 @@ func ApplyTableSessionAPI(
 	sessionRoute.POST("/open",
 		middlewares.RequireAuthenticated(),
 		middlewares.RequireSession(repository.Session),
+		middlewares.RequireOpenBusinessDay(repository.Setting, repository.DayClose),
 		usecase.OpenTable(repository.TableSession, repository.Table),
 	)
 
 	sessionRoute.POST("/:sessionId/close",
 		middlewares.RequireAuthenticated(),
 		middlewares.RequireSession(repository.Session),
-		usecase.CloseTable(repository.TableSession, repository.Table, repository.TableOrder, repository.Payment, repository.Promotion),
+		middlewares.RequireOpenBusinessDay(repository.Setting, repository.DayClose),
+		usecase.CloseTable(repository.TableSession, repository.TableOrder, repository.Payment, repository.Promotion, repository.Loyalty, repository.Setting, repository.Voucher),
+	)
+
+	sessionRoute.POST("/:sessionId/checkout",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireOpenBusinessDay(repository.Setting, repository.DayClose),
+		usecase.Checkout(repository.TableSession, repository.TableOrder, repository.Payment, repository.Promotion, repository.Loyalty, repository.Setting, repository.Voucher),
+	)
+
+	sessionRoute.POST("/:sessionId/promptpay",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireOpenBusinessDay(repository.Setting, repository.DayClose),
+		usecase.CreatePromptPayQr(repository.TableSession, repository.TableOrder, repository.Payment, repository.Promotion, repository.Setting, repository.PaymentIntent),
+	)
+
+	sessionRoute.POST("/:sessionId/gateway",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireOpenBusinessDay(repository.Setting, repository.DayClose),
+		usecase.CreateGatewayCharge(repository.TableSession, repository.TableOrder, repository.Payment, repository.Promotion, repository.Setting, repository.PaymentIntent, repository.Gateway),
 	)
 
 	sessionRoute.POST("/:sessionId/pause",
 		middlewares.RequireAuthenticated(),
 		middlewares.RequireSession(repository.Session),
+		middlewares.RequireOpenBusinessDay(repository.Setting, repository.DayClose),
 		usecase.PauseTable(repository.TableSession),
 	)
 
 	sessionRoute.POST("/:sessionId/resume",
 		middlewares.RequireAuthenticated(),
 		middlewares.RequireSession(repository.Session),
+		middlewares.RequireOpenBusinessDay(repository.Setting, repository.DayClose),
 		usecase.ResumeTable(repository.TableSession),
 	)
 
 	sessionRoute.POST("/:sessionId/transfer",
 		middlewares.RequireAuthenticated(),
 		middlewares.RequireSession(repository.Session),
+		middlewares.RequireOpenBusinessDay(repository.Setting, repository.DayClose),
 		usecase.TransferTable(repository.TableSession, repository.Table),
 	)
 
 	sessionRoute.POST("/:sessionId/apply-promotion",
 		middlewares.RequireAuthenticated(),
 		middlewares.RequireSession(repository.Session),
-		usecase.ApplyPromotionToSession(repository.TableSession, repository.Promotion),
+		middlewares.RequireOpenBusinessDay(repository.Setting, repository.DayClose),
+		usecase.ApplyPromotionToSession(repository.TableSession, repository.Promotion, repository.TableOrder),
+	)
+
+	sessionRoute.POST("/:sessionId/redeem-voucher",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireOpenBusinessDay(repository.Setting, repository.DayClose),
+		usecase.RedeemVoucherToSession(repository.TableSession, repository.Promotion, repository.TableOrder, repository.Voucher),
+	)
+
+	sessionRoute.DELETE("/:sessionId/promotions/:promotionId",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireOpenBusinessDay(repository.Setting, repository.DayClose),
+		usecase.RemovePromotionFromSession(repository.TableSession, repository.Promotion, repository.TableOrder, repository.Voucher),
+	)
+
+	sessionRoute.GET("/:sessionId/promotions/:promotionId/eligibility",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		usecase.CheckSessionPromotion(repository.TableSession, repository.Promotion, repository.TableOrder),
+	)
+
+	sessionRoute.POST("/:sessionId/member",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireOpenBusinessDay(repository.Setting, repository.DayClose),
+		usecase.AssignMember(repository.TableSession, repository.Loyalty),
 	)
 }
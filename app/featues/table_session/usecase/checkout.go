@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"snook/app/core/billing"
+	"snook/app/core/errcode"
+	"snook/app/data/entities"
+	"snook/app/data/repositories"
+	"snook/app/domain/request"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Checkout closes a session against one or more tenders. The tenders must
+// cover what is still due after earlier payments; cash may exceed it and the
+// difference is returned as change. OUTSTANDING tenders are owed by the
+// customer named in their note and open a creditor. The session, payments,
+// creditors and table are written in one transaction.
+func Checkout(sessionEntity repositories.ITableSession, orderEntity repositories.ITableOrder, paymentEntity repositories.IPayment, promotionEntity repositories.IPromotion, loyaltyEntity repositories.ILoyalty, settingEntity repositories.ISetting, voucherEntity repositories.IVoucher) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		sessionId, err := primitive.ObjectIDFromHex(ctx.Param("sessionId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_001, "invalid sessionId")
+			return
+		}
+		var req request.Checkout
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_001, err.Error())
+			return
+		}
+		setting, _ := settingEntity.GetSetting()
+		methods := make([]entities.PaymentMethod, len(req.Payments))
+		for i, p := range req.Payments {
+			method, err := billing.ResolvePaymentMethod(billing.PaymentMethods(setting), p.Type)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_001, err.Error())
+				return
+			}
+			if method.Code == "OUTSTANDING" && strings.TrimSpace(p.Note) == "" {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_001, "outstanding payments need the customer name as note")
+				return
+			}
+			methods[i] = method
+		}
+		session, err := sessionEntity.GetTableSessionById(sessionId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "session not found")
+			return
+		}
+		if session.Status == "CLOSED" {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "session already closed")
+			return
+		}
+		session.Discount = req.Discount
+		session.Note = req.Note
+		userId := ctx.GetString("UserId")
+		if req.MemberId != "" {
+			if err := attachMember(loyaltyEntity, &session, req.MemberId); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, err.Error())
+				return
+			}
+		}
+		now := time.Now()
+		released := priceSession(sessionEntity, orderEntity, promotionEntity, &session, req.TableCharge, now)
+
+		payments, _ := paymentEntity.GetPaymentsBySessionId(sessionId)
+		paidTotal, pointsPayment := 0.0, 0.0
+		for _, p := range payments {
+			paidTotal += p.Amount
+			if p.Type == "POINTS" {
+				pointsPayment += p.Amount
+			}
+		}
+
+		if req.RedeemPoints > 0 {
+			if session.MemberId == nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "member required to redeem points")
+				return
+			}
+			points, value, err := redeemPoints(loyaltyEntity, setting.Loyalty, session, req.RedeemPoints, session.GrandTotal-paidTotal)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, err.Error())
+				return
+			}
+			session.PointsRedeemed = points
+			session.PointsDiscount = value
+			session.GrandTotal = math.Round((session.GrandTotal-value)*100) / 100
+		}
+
+		tenders := make([]billing.Tender, len(req.Payments))
+		for i, p := range req.Payments {
+			tenders[i] = billing.Tender{Type: methods[i].Code, Cash: methods[i].CashInDrawer, Tendered: p.Amount}
+		}
+		settled, change, err := billing.SettleTenders(session.GrandTotal-paidTotal, tenders)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_004, err.Error())
+			return
+		}
+		var newPayments []entities.Payment
+		var creditors []entities.Creditor
+		for i, t := range settled {
+			if t.Amount <= 0 {
+				continue
+			}
+			payment := entities.Payment{
+				SessionId: sessionId, Type: t.Type, Amount: t.Amount,
+				Fee: billing.PaymentFee(methods[i], t.Amount), Note: req.Payments[i].Note, CreatedBy: userId,
+			}
+			if t.Cash {
+				payment.Tendered = t.Tendered
+				payment.Change = math.Round((t.Tendered-t.Amount)*100) / 100
+			}
+			newPayments = append(newPayments, payment)
+			if t.Type == "OUTSTANDING" {
+				creditors = append(creditors, entities.Creditor{
+					SessionId: sessionId, CustomerName: strings.TrimSpace(req.Payments[i].Note),
+					Amount: t.Amount, Remaining: t.Amount, Status: "PENDING",
+					CreatedBy: userId, UpdatedBy: userId,
+				})
+			}
+		}
+
+		session.PointsEarned = earnedPoints(setting.Loyalty, session, pointsPayment)
+		session.Status = "CLOSED"
+		session.UpdatedBy = userId
+		created, err := sessionEntity.CheckoutTableSession(session, newPayments, creditors, loyaltyEntries(setting.Loyalty, session, userId))
+		if err != nil {
+			if errors.Is(err, repositories.ErrSessionClosed) {
+				errcode.Abort(ctx, http.StatusConflict, errcode.TS_CONFLICT_001, err.Error())
+				return
+			}
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, err.Error())
+			return
+		}
+		releaseVouchers(voucherEntity, released)
+		ctx.JSON(http.StatusOK, entities.CheckoutResult{
+			TableSession: session,
+			Payments:     append(payments, created...),
+			Change:       change,
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"net/http"
+	"snook/app/core/billing"
+	"snook/app/core/errcode"
+	"snook/app/core/gateway"
+	"snook/app/data/entities"
+	"snook/app/data/repositories"
+	"snook/app/domain/request"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CreateGatewayCharge asks the payment gateway to collect the remaining
+// balance of a session by card or QR. The charge is confirmed by the
+// gateway's webhook, not by staff.
+func CreateGatewayCharge(sessionEntity repositories.ITableSession, orderEntity repositories.ITableOrder, paymentEntity repositories.IPayment, promotionEntity repositories.IPromotion, settingEntity repositories.ISetting, intentEntity repositories.IPaymentIntent, provider gateway.Provider) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		sessionId, err := primitive.ObjectIDFromHex(ctx.Param("sessionId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_001, "invalid sessionId")
+			return
+		}
+		var req request.GatewayCharge
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_001, err.Error())
+			return
+		}
+		session, err := sessionEntity.GetTableSessionById(sessionId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "session not found")
+			return
+		}
+		if session.Status == "CLOSED" {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "session already closed")
+			return
+		}
+		setting, _ := settingEntity.GetSetting()
+		method, err := billing.ResolvePaymentMethod(billing.PaymentMethods(setting), req.Method)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, err.Error())
+			return
+		}
+		if !billing.GatewayChargeable(method) {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "payment method "+method.Code+" cannot be charged through the gateway")
+			return
+		}
+		remaining := sessionBalance(sessionEntity, orderEntity, paymentEntity, promotionEntity, &session)
+		if remaining <= 0 {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "session has no balance to pay")
+			return
+		}
+		intentId := primitive.NewObjectID()
+		charge, err := provider.CreateCharge(ctx, gateway.Charge{
+			Reference: intentId.Hex(), Amount: remaining, Currency: "THB", Method: method.Code,
+		})
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadGateway, errcode.PY_BAD_GATEWAY_001, "payment gateway: "+err.Error())
+			return
+		}
+		intent, err := intentEntity.CreatePaymentIntent(entities.PaymentIntent{
+			SessionId: sessionId, Method: method.Code, Provider: provider.Name(), ProviderRef: charge.ProviderRef,
+			Amount: remaining, Fee: billing.PaymentFee(method, remaining), Payload: charge.Payload, RedirectUrl: charge.RedirectUrl,
+			Note: method.Code + " " + session.TableName, CreatedBy: ctx.GetString("UserId"),
+		})
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusCreated, intent)
+	}
+}
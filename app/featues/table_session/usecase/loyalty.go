@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"snook/app/core/errcode"
+	"snook/app/data/entities"
+	"snook/app/data/repositories"
+	"snook/app/domain/request"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func AssignMember(sessionEntity repositories.ITableSession, loyaltyEntity repositories.ILoyalty) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		sessionId, err := primitive.ObjectIDFromHex(ctx.Param("sessionId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_001, "invalid sessionId")
+			return
+		}
+		var req request.AssignMember
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_001, err.Error())
+			return
+		}
+		session, err := sessionEntity.GetTableSessionById(sessionId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "session not found")
+			return
+		}
+		if session.Status == "CLOSED" {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "session already closed")
+			return
+		}
+		if err := attachMember(loyaltyEntity, &session, req.MemberId); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, err.Error())
+			return
+		}
+		session.UpdatedBy = ctx.GetString("UserId")
+		if err := sessionEntity.UpdateTableSession(sessionId, session); err != nil {
+			abortSessionUpdate(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, session)
+	}
+}
+
+func attachMember(loyaltyEntity repositories.ILoyalty, session *entities.TableSession, memberIdHex string) error {
+	memberId, err := primitive.ObjectIDFromHex(memberIdHex)
+	if err != nil {
+		return errors.New("invalid memberId")
+	}
+	member, err := loyaltyEntity.GetMemberById(memberId)
+	if err != nil {
+		return errors.New("member not found")
+	}
+	if member.Status != "ACTIVE" {
+		return errors.New("member is not active")
+	}
+	session.MemberId = &member.Id
+	session.MemberName = member.Name
+	return nil
+}
+
+// redeemPoints caps the requested points so the redeemed value never exceeds
+// maxValue and returns the points and their baht value. Lapsed points are
+// expired first; the points are only taken when the session is checked out.
+func redeemPoints(loyaltyEntity repositories.ILoyalty, cfg entities.LoyaltySetting, session entities.TableSession, points int, maxValue float64) (int, float64, error) {
+	if cfg.PointValue <= 0 {
+		return 0, 0, errors.New("points redemption is disabled")
+	}
+	maxPoints := int(math.Floor(maxValue / cfg.PointValue))
+	if points > maxPoints {
+		points = maxPoints
+	}
+	if points <= 0 {
+		return 0, 0, nil
+	}
+	if _, err := loyaltyEntity.ExpirePoints(session.MemberId, time.Now()); err != nil {
+		return 0, 0, err
+	}
+	member, err := loyaltyEntity.GetMemberById(*session.MemberId)
+	if err != nil {
+		return 0, 0, err
+	}
+	if member.Points < points {
+		return 0, 0, repositories.ErrInsufficientPoints
+	}
+	return points, math.Round(float64(points)*cfg.PointValue*100) / 100, nil
+}
+
+// earnedPoints splits the amount actually paid in money between table time
+// and food in proportion to their gross charges and applies each rate.
+func earnedPoints(cfg entities.LoyaltySetting, session entities.TableSession, pointsPayment float64) int {
+	gross := session.TableCharge + session.FoodTotal
+	paid := session.GrandTotal - pointsPayment
+	if gross <= 0 || paid <= 0 {
+		return 0
+	}
+	tablePaid := paid * session.TableCharge / gross
+	foodPaid := paid * session.FoodTotal / gross
+	return int(math.Floor(tablePaid*cfg.TablePointsPerBaht + foodPaid*cfg.FoodPointsPerBaht))
+}
+
+// loyaltyEntries lists the ledger entries a checkout posts for the member:
+// the points redeemed and the points earned on the bill.
+func loyaltyEntries(cfg entities.LoyaltySetting, session entities.TableSession, userId string) []entities.LoyaltyTransaction {
+	if session.MemberId == nil {
+		return nil
+	}
+	var txns []entities.LoyaltyTransaction
+	if session.PointsRedeemed > 0 {
+		txns = append(txns, entities.LoyaltyTransaction{
+			MemberId:  *session.MemberId,
+			SessionId: &session.Id,
+			Type:      "REDEEM",
+			Points:    -session.PointsRedeemed,
+			Reason:    "redeemed at " + session.TableName,
+			CreatedBy: userId,
+		})
+	}
+	if session.PointsEarned > 0 {
+		txn := entities.LoyaltyTransaction{
+			MemberId:  *session.MemberId,
+			SessionId: &session.Id,
+			Type:      "EARN",
+			Points:    session.PointsEarned,
+			Reason:    "earned at " + session.TableName,
+			CreatedBy: userId,
+		}
+		if cfg.ExpiryDays > 0 {
+			expiresAt := time.Now().AddDate(0, 0, cfg.ExpiryDays)
+			txn.ExpiresAt = &expiresAt
+		}
+		txns = append(txns, txn)
+	}
+	return txns
+}
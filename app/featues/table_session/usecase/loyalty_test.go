@@ -0,0 +1,30 @@
+package usecase
+
+import (
+	"snook/app/data/entities"
+	"testing"
+)
+
+func TestEarnedPoints(t *testing.T) {
+	cfg := entities.LoyaltySetting{TablePointsPerBaht: 1, FoodPointsPerBaht: 0.5}
+	tests := []struct {
+		name          string
+		session       entities.TableSession
+		pointsPayment float64
+		want          int
+	}{
+		{"paid in full", entities.TableSession{TableCharge: 100, FoodTotal: 50, GrandTotal: 150}, 0, 125},
+		{"discount lowers both shares", entities.TableSession{TableCharge: 100, FoodTotal: 50, GrandTotal: 120}, 0, 100},
+		{"points payment earns nothing", entities.TableSession{TableCharge: 100, FoodTotal: 50, GrandTotal: 150}, 50, 83},
+		{"paid entirely with points", entities.TableSession{TableCharge: 100, FoodTotal: 50, GrandTotal: 150}, 150, 0},
+		{"table only", entities.TableSession{TableCharge: 80, GrandTotal: 80}, 0, 80},
+		{"nothing charged", entities.TableSession{}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := earnedPoints(cfg, tt.session, tt.pointsPayment); got != tt.want {
+				t.Errorf("earnedPoints() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,12 +1,16 @@
 package usecase
 
 import (
+	"errors"
+	"io"
 	"math"
 	"net/http"
+	"snook/app/core/billing"
 	"snook/app/core/errcode"
 	"snook/app/data/entities"
 	"snook/app/data/repositories"
 	"snook/app/domain/request"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -54,7 +58,7 @@ func OpenTable(sessionEntity repositories.ITableSession, tableEntity repositorie
 	}
 }
 
-func CloseTable(sessionEntity repositories.ITableSession, tableEntity repositories.ITable, orderEntity repositories.ITableOrder, paymentEntity repositories.IPayment, promotionEntity repositories.IPromotion) gin.HandlerFunc {
+func CloseTable(sessionEntity repositories.ITableSession, orderEntity repositories.ITableOrder, paymentEntity repositories.IPayment, promotionEntity repositories.IPromotion, loyaltyEntity repositories.ILoyalty, settingEntity repositories.ISetting, voucherEntity repositories.IVoucher) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		sessionId, err := primitive.ObjectIDFromHex(ctx.Param("sessionId"))
 		if err != nil {
@@ -70,60 +74,23 @@ func CloseTable(sessionEntity repositories.ITableSession, tableEntity repositori
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "session already closed")
 			return
 		}
+		// The body is optional; an empty one closes with the defaults.
 		var req request.CloseTable
-		if err := ctx.ShouldBindJSON(&req); err == nil {
-			session.Discount = req.Discount
-			session.Note = req.Note
-		}
-		now := time.Now()
-		session.EndTime = &now
-		totalMins := now.Sub(session.StartTime).Minutes() - session.TotalPausedMins
-		if totalMins < 0 {
-			totalMins = 0
-		}
-		session.DurationMins = math.Round(totalMins*100) / 100
-
-		// Use frontend-provided tableCharge if available, otherwise calculate
-		if req.TableCharge != nil && *req.TableCharge >= session.RatePerHour {
-			session.TableCharge = math.Round(*req.TableCharge*100) / 100
-		} else {
-			billableMins := totalMins
-			if billableMins < 60 {
-				billableMins = 60
-			}
-			hours := math.Round((billableMins/60)*1e6) / 1e6
-			session.TableCharge = math.Round(math.Ceil(hours)*session.RatePerHour*100) / 100
+		if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_001, err.Error())
+			return
 		}
-
-		// Recalculate promotion discount at close time
-		if session.PromotionId != nil {
-			promo, promoErr := promotionEntity.GetPromotionById(*session.PromotionId)
-			if promoErr == nil {
-				promoDiscount := 0.0
-				switch promo.Type {
-				case "FREE_HOURS":
-					if promo.PlayHours > 0 && (totalMins/60) >= promo.PlayHours {
-						promoDiscount = promo.FreeHours * session.RatePerHour
-					}
-				case "DISCOUNT_PCT":
-					promoDiscount = math.Round(session.TableCharge*promo.DiscountPct) / 100
-				case "DISCOUNT_AMT":
-					promoDiscount = promo.DiscountAmt
-				}
-				session.PromotionDiscount = math.Round(promoDiscount*100) / 100
+		session.Discount = req.Discount
+		session.Note = req.Note
+		userId := ctx.GetString("UserId")
+		if req.MemberId != "" {
+			if err := attachMember(loyaltyEntity, &session, req.MemberId); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, err.Error())
+				return
 			}
 		}
-
-		orders, _ := orderEntity.GetOrdersBySessionId(sessionId)
-		foodTotal := 0.0
-		for _, o := range orders {
-			foodTotal += o.Total
-		}
-		session.FoodTotal = foodTotal
-		session.GrandTotal = session.TableCharge + session.FoodTotal - session.Discount - session.PromotionDiscount
-		if session.GrandTotal < 0 {
-			session.GrandTotal = 0
-		}
+		now := time.Now()
+		released := priceSession(sessionEntity, orderEntity, promotionEntity, &session, req.TableCharge, now)
 
 		// Sum existing payments
 		payments, _ := paymentEntity.GetPaymentsBySessionId(sessionId)
@@ -132,40 +99,119 @@ func CloseTable(sessionEntity repositories.ITableSession, tableEntity repositori
 			paidTotal += p.Amount
 		}
 
-		// Auto-create final payment for remaining balance
-		remaining := math.Round((session.GrandTotal-paidTotal)*100) / 100
-		if remaining > 0 {
-			payType := req.PaymentType
-			if payType == "" {
-				payType = "CASH"
+		setting, _ := settingEntity.GetSetting()
+		methods := billing.PaymentMethods(setting)
+		method, err := billing.DefaultCashMethod(methods)
+		if req.PaymentType != "" {
+			method, err = billing.ResolvePaymentMethod(methods, req.PaymentType)
+		}
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_001, err.Error())
+			return
+		}
+
+		// Redeem loyalty points as a discount or as a payment
+		var newPayments []entities.Payment
+		pointsPayment := 0.0
+		if req.RedeemPoints > 0 {
+			if session.MemberId == nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "member required to redeem points")
+				return
 			}
-			userId := ctx.GetString("UserId")
-			_, payErr := paymentEntity.CreatePayment(entities.Payment{
-				SessionId:   sessionId,
-				Type:        payType,
-				Amount:      remaining,
-				Note:        req.PaymentNote,
-				CreatedBy:   userId,
-				CreatedDate: now,
-			})
-			if payErr != nil {
-				errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "failed to create payment: "+payErr.Error())
+			points, value, err := redeemPoints(loyaltyEntity, setting.Loyalty, session, req.RedeemPoints, session.GrandTotal-paidTotal)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, err.Error())
 				return
 			}
+			session.PointsRedeemed = points
+			if req.RedeemAs == "PAYMENT" {
+				if points > 0 {
+					newPayments = append(newPayments, entities.Payment{
+						SessionId: sessionId,
+						Type:      "POINTS",
+						Amount:    value,
+						Note:      strconv.Itoa(points) + " points",
+						CreatedBy: userId,
+					})
+				}
+				pointsPayment = value
+				paidTotal += value
+			} else {
+				session.PointsDiscount = value
+				session.GrandTotal = math.Round((session.GrandTotal-value)*100) / 100
+			}
 		}
 
+		// Final payment for the remaining balance
+		remaining := math.Round((session.GrandTotal-paidTotal)*100) / 100
+		if remaining > 0 {
+			newPayments = append(newPayments, entities.Payment{
+				SessionId: sessionId,
+				Type:      method.Code,
+				Amount:    remaining,
+				Fee:       billing.PaymentFee(method, remaining),
+				Note:      req.PaymentNote,
+				CreatedBy: userId,
+			})
+		}
+
+		// The payments and points are written with the session, so a failure
+		// leaves no points payment or redemption behind.
+		session.PointsEarned = earnedPoints(setting.Loyalty, session, pointsPayment)
 		session.Status = "CLOSED"
-		userId := ctx.GetString("UserId")
 		session.UpdatedBy = userId
-		if err := sessionEntity.UpdateTableSession(sessionId, session); err != nil {
+		if _, err := sessionEntity.CheckoutTableSession(session, newPayments, nil, loyaltyEntries(setting.Loyalty, session, userId)); err != nil {
+			if errors.Is(err, repositories.ErrSessionClosed) {
+				errcode.Abort(ctx, http.StatusConflict, errcode.TS_CONFLICT_001, err.Error())
+				return
+			}
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, err.Error())
 			return
 		}
-		_ = tableEntity.UpdateTableStatus(session.TableId, "AVAILABLE")
+		releaseVouchers(voucherEntity, released)
 		ctx.JSON(http.StatusOK, session)
 	}
 }
 
+// priceSession works out the final bill of a session ending at now: played
+// time, orders and the promotions still eligible. Vouchers whose promotion
+// no longer applies are dropped and returned, to be released once the
+// session is closed.
+func priceSession(sessionEntity repositories.ITableSession, orderEntity repositories.ITableOrder, promotionEntity repositories.IPromotion, session *entities.TableSession, tableCharge *float64, now time.Time) []entities.SessionVoucher {
+	totalMins := billing.PlayedMinutes(*session, now)
+	session.EndTime = &now
+	session.DurationMins = math.Round(totalMins*100) / 100
+
+	// Use frontend-provided tableCharge if available, otherwise calculate
+	if tableCharge != nil && *tableCharge >= session.RatePerHour {
+		session.TableCharge = math.Round(*tableCharge*100) / 100
+	} else {
+		session.TableCharge = billing.TableCharge(totalMins, session.RatePerHour)
+	}
+
+	orders, _ := orderEntity.GetOrdersBySessionId(session.Id)
+	session.FoodTotal = foodTotal(orders)
+
+	// Re-evaluate eligibility and recalculate promotion discounts at close time
+	bc := billing.BillContext{
+		PlayedMins:  totalMins,
+		TableCharge: session.TableCharge,
+		RatePerHour: session.RatePerHour,
+		Intervals:   billing.PlayedIntervals(*session, now),
+		Orders:      orders,
+	}
+	applied, rejections := evaluatePromotions(sessionEntity, promotionEntity, *session, bc, now)
+	setPromotions(session, applied)
+	session.PromotionRejections = rejections
+	released := dropVouchers(session)
+
+	session.GrandTotal = session.TableCharge + session.FoodTotal - session.Discount - session.PromotionDiscount
+	if session.GrandTotal < 0 {
+		session.GrandTotal = 0
+	}
+	return released
+}
+
 func PauseTable(sessionEntity repositories.ITableSession) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		sessionId, err := primitive.ObjectIDFromHex(ctx.Param("sessionId"))
@@ -184,9 +230,10 @@ func PauseTable(sessionEntity repositories.ITableSession) gin.HandlerFunc {
 		}
 		now := time.Now()
 		session.PausedAt = &now
+		session.Pauses = append(session.Pauses, entities.PauseInterval{Start: now})
 		session.Status = "PAUSED"
 		if err := sessionEntity.UpdateTableSession(sessionId, session); err != nil {
-			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, err.Error())
+			abortSessionUpdate(ctx, err)
 			return
 		}
 		ctx.JSON(http.StatusOK, session)
@@ -209,12 +256,15 @@ func ResumeTable(sessionEntity repositories.ITableSession) gin.HandlerFunc {
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "session is not paused")
 			return
 		}
-		pausedDuration := time.Since(*session.PausedAt).Minutes()
-		session.TotalPausedMins += pausedDuration
+		now := time.Now()
+		session.TotalPausedMins += now.Sub(*session.PausedAt).Minutes()
+		if n := len(session.Pauses); n > 0 && session.Pauses[n-1].End == nil {
+			session.Pauses[n-1].End = &now
+		}
 		session.PausedAt = nil
 		session.Status = "ACTIVE"
 		if err := sessionEntity.UpdateTableSession(sessionId, session); err != nil {
-			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, err.Error())
+			abortSessionUpdate(ctx, err)
 			return
 		}
 		ctx.JSON(http.StatusOK, session)
@@ -260,7 +310,7 @@ func TransferTable(sessionEntity repositories.ITableSession, tableEntity reposit
 		userId := ctx.GetString("UserId")
 		session.UpdatedBy = userId
 		if err := sessionEntity.UpdateTableSession(sessionId, session); err != nil {
-			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, err.Error())
+			abortSessionUpdate(ctx, err)
 			return
 		}
 		_ = tableEntity.UpdateTableStatus(oldTableId, "AVAILABLE")
@@ -269,66 +319,6 @@ func TransferTable(sessionEntity repositories.ITableSession, tableEntity reposit
 	}
 }
 
-func ApplyPromotionToSession(sessionEntity repositories.ITableSession, promotionEntity repositories.IPromotion) gin.HandlerFunc {
-	return func(ctx *gin.Context) {
-		sessionId, err := primitive.ObjectIDFromHex(ctx.Param("sessionId"))
-		if err != nil {
-			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_001, "invalid sessionId")
-			return
-		}
-		var req request.ApplyPromotion
-		if err := ctx.ShouldBindJSON(&req); err != nil {
-			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_001, err.Error())
-			return
-		}
-		promotionId, err := primitive.ObjectIDFromHex(req.PromotionId)
-		if err != nil {
-			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_001, "invalid promotionId")
-			return
-		}
-		session, err := sessionEntity.GetTableSessionById(sessionId)
-		if err != nil {
-			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "session not found")
-			return
-		}
-		if session.Status == "CLOSED" {
-			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "session already closed")
-			return
-		}
-		promo, err := promotionEntity.GetPromotionById(promotionId)
-		if err != nil {
-			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "promotion not found")
-			return
-		}
-		// Calculate promotion discount based on type
-		promoDiscount := 0.0
-		elapsed := time.Since(session.StartTime).Minutes() - session.TotalPausedMins
-		if elapsed < 0 {
-			elapsed = 0
-		}
-		switch promo.Type {
-		case "FREE_HOURS":
-			if promo.PlayHours > 0 && (elapsed/60) >= promo.PlayHours {
-				promoDiscount = promo.FreeHours * session.RatePerHour
-			}
-		case "DISCOUNT_PCT":
-			currentCharge := math.Ceil(math.Round((elapsed/60)*1e6)/1e6) * session.RatePerHour
-			promoDiscount = math.Round(currentCharge*promo.DiscountPct) / 100
-		case "DISCOUNT_AMT":
-			promoDiscount = promo.DiscountAmt
-		}
-		session.PromotionId = &promotionId
-		session.PromotionName = promo.Name
-		session.PromotionDiscount = math.Round(promoDiscount*100) / 100
-		session.UpdatedBy = ctx.GetString("UserId")
-		if err := sessionEntity.UpdateTableSession(sessionId, session); err != nil {
-			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, err.Error())
-			return
-		}
-		ctx.JSON(http.StatusOK, session)
-	}
-}
-
 func GetTableSessions(sessionEntity repositories.ITableSession) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		startDate := ctx.Query("startDate")
@@ -368,16 +358,9 @@ func GetTableSessionById(sessionEntity repositories.ITableSession, orderEntity r
 		orders, _ := orderEntity.GetOrdersBySessionId(sessionId)
 		payments, _ := paymentEntity.GetPaymentsBySessionId(sessionId)
 		detail := entities.TableSessionDetail{
-			Id: session.Id, TableId: session.TableId, TableName: session.TableName,
-			TableType: session.TableType, RatePerHour: session.RatePerHour,
-			Status: session.Status, StartTime: session.StartTime, EndTime: session.EndTime,
-			PausedAt: session.PausedAt, TotalPausedMins: session.TotalPausedMins,
-			DurationMins: session.DurationMins, TableCharge: session.TableCharge,
-			FoodTotal: session.FoodTotal, Discount: session.Discount,
-			PromotionId: session.PromotionId, PromotionName: session.PromotionName,
-			PromotionDiscount: session.PromotionDiscount, GrandTotal: session.GrandTotal,
-			Note: session.Note, CreatedDate: session.CreatedDate,
-			Orders: orders, Payments: payments,
+			TableSession: session,
+			Orders:       orders,
+			Payments:     payments,
 		}
 		ctx.JSON(http.StatusOK, detail)
 	}
@@ -398,3 +381,13 @@ func GetActiveSessionByTableId(sessionEntity repositories.ITableSession) gin.Han
 		ctx.JSON(http.StatusOK, session)
 	}
 }
+
+// abortSessionUpdate answers a failed UpdateTableSession, with a conflict when
+// the session was checked out in the meantime.
+func abortSessionUpdate(ctx *gin.Context, err error) {
+	if errors.Is(err, repositories.ErrSessionClosed) {
+		errcode.Abort(ctx, http.StatusConflict, errcode.TS_CONFLICT_001, err.Error())
+		return
+	}
+	errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, err.Error())
+}
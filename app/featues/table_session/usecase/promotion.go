@@ -0,0 +1,293 @@
+package usecase
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"snook/app/core/billing"
+	"snook/app/core/errcode"
+	"snook/app/data/entities"
+	"snook/app/data/repositories"
+	"snook/app/domain/request"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func ApplyPromotionToSession(sessionEntity repositories.ITableSession, promotionEntity repositories.IPromotion, orderEntity repositories.ITableOrder) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		sessionId, err := primitive.ObjectIDFromHex(ctx.Param("sessionId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_001, "invalid sessionId")
+			return
+		}
+		var req request.ApplyPromotion
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_001, err.Error())
+			return
+		}
+		promotionId, err := primitive.ObjectIDFromHex(req.PromotionId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_001, "invalid promotionId")
+			return
+		}
+		session, err := sessionEntity.GetTableSessionById(sessionId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "session not found")
+			return
+		}
+		if session.Status == "CLOSED" {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "session already closed")
+			return
+		}
+		promo, err := promotionEntity.GetPromotionById(promotionId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "promotion not found")
+			return
+		}
+		reasons, err := addPromotion(sessionEntity, promotionEntity, orderEntity, &session, promo)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, err.Error())
+			return
+		}
+		if len(reasons) > 0 {
+			errcode.AbortWithDetails(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_003, "promotion not eligible", reasons)
+			return
+		}
+		session.UpdatedBy = ctx.GetString("UserId")
+		if err := sessionEntity.UpdateTableSession(sessionId, session); err != nil {
+			abortSessionUpdate(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, session)
+	}
+}
+
+func RemovePromotionFromSession(sessionEntity repositories.ITableSession, promotionEntity repositories.IPromotion, orderEntity repositories.ITableOrder, voucherEntity repositories.IVoucher) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		sessionId, err := primitive.ObjectIDFromHex(ctx.Param("sessionId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_001, "invalid sessionId")
+			return
+		}
+		promotionId, err := primitive.ObjectIDFromHex(ctx.Param("promotionId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_001, "invalid promotionId")
+			return
+		}
+		session, err := sessionEntity.GetTableSessionById(sessionId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "session not found")
+			return
+		}
+		if session.Status == "CLOSED" {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "session already closed")
+			return
+		}
+		var remaining []primitive.ObjectID
+		for _, id := range sessionPromotionIds(session) {
+			if id != promotionId {
+				remaining = append(remaining, id)
+			}
+		}
+		promos := loadPromotions(promotionEntity, remaining)
+		setPromotions(&session, billing.ApplyPromotions(promos, currentBill(orderEntity, session, time.Now())))
+		released := dropVouchers(&session)
+		session.UpdatedBy = ctx.GetString("UserId")
+		if err := sessionEntity.UpdateTableSession(sessionId, session); err != nil {
+			abortSessionUpdate(ctx, err)
+			return
+		}
+		releaseVouchers(voucherEntity, released)
+		ctx.JSON(http.StatusOK, session)
+	}
+}
+
+func CheckSessionPromotion(sessionEntity repositories.ITableSession, promotionEntity repositories.IPromotion, orderEntity repositories.ITableOrder) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		sessionId, err := primitive.ObjectIDFromHex(ctx.Param("sessionId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_001, "invalid sessionId")
+			return
+		}
+		promotionId, err := primitive.ObjectIDFromHex(ctx.Param("promotionId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_001, "invalid promotionId")
+			return
+		}
+		session, err := sessionEntity.GetTableSessionById(sessionId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "session not found")
+			return
+		}
+		promo, err := promotionEntity.GetPromotionById(promotionId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "promotion not found")
+			return
+		}
+		now := time.Now()
+		bc := currentBill(orderEntity, session, now)
+		reasons := billing.CheckEligibility(promo, promotionContext(sessionEntity, session, promo.Id, billTotal(bc), now, now))
+		if !billing.CanStack(promo, session.Promotions) {
+			reasons = append(reasons, "cannot be combined with the promotions already applied")
+		}
+		ctx.JSON(http.StatusOK, gin.H{"eligible": len(reasons) == 0, "reasons": reasons})
+	}
+}
+
+// addPromotion prices promo together with the promotions already on the
+// session. It returns the reasons when promo is not eligible.
+func addPromotion(sessionEntity repositories.ITableSession, promotionEntity repositories.IPromotion, orderEntity repositories.ITableOrder, session *entities.TableSession, promo entities.Promotion) ([]string, error) {
+	if promo.AutoApply {
+		return nil, errors.New("promotion is applied automatically")
+	}
+	ids := sessionPromotionIds(*session)
+	if containsId(ids, promo.Id) {
+		return nil, errors.New("promotion already applied")
+	}
+	now := time.Now()
+	bc := currentBill(orderEntity, *session, now)
+	if reasons := billing.CheckEligibility(promo, promotionContext(sessionEntity, *session, promo.Id, billTotal(bc), now, now)); len(reasons) > 0 {
+		return reasons, nil
+	}
+	if !billing.CanStack(promo, session.Promotions) {
+		return []string{"cannot be combined with the promotions already applied"}, nil
+	}
+	promos := loadPromotions(promotionEntity, ids)
+	setPromotions(session, billing.ApplyPromotions(append(promos, promo), bc))
+	session.PromotionRejections = nil
+	return nil, nil
+}
+
+// evaluatePromotions re-checks every promotion on the session, adds the
+// eligible automatic ones and prices them together. Rejected promotions that
+// staff applied are reported with their reasons.
+func evaluatePromotions(sessionEntity repositories.ITableSession, promotionEntity repositories.IPromotion, session entities.TableSession, bc billing.BillContext, now time.Time) ([]entities.AppliedPromotion, []string) {
+	var eligible []entities.Promotion
+	var rejections []string
+	ids := sessionPromotionIds(session)
+	for _, id := range ids {
+		promo, err := promotionEntity.GetPromotionById(id)
+		if err != nil {
+			rejections = append(rejections, "promotion "+id.Hex()+" not found")
+			continue
+		}
+		// Day and time rules were checked when staff applied the promotion.
+		reasons := billing.CheckEligibility(promo, promotionContext(sessionEntity, session, promo.Id, billTotal(bc), now, time.Time{}))
+		for _, reason := range reasons {
+			rejections = append(rejections, promo.Name+": "+reason)
+		}
+		if len(reasons) == 0 {
+			eligible = append(eligible, promo)
+		}
+	}
+	autoPromos, _ := promotionEntity.GetAutoApplyPromotions()
+	for _, promo := range autoPromos {
+		if containsId(ids, promo.Id) {
+			continue
+		}
+		// Automatic promotions follow the day and hours the session started in.
+		if len(billing.CheckEligibility(promo, promotionContext(sessionEntity, session, promo.Id, billTotal(bc), now, session.StartTime))) == 0 {
+			eligible = append(eligible, promo)
+		}
+	}
+	return billing.ApplyPromotions(eligible, bc), rejections
+}
+
+// sessionPromotionIds returns the promotions staff applied. Automatic ones are
+// left out as they are worked out again on every bill. It also covers sessions
+// opened before multiple promotions were supported, which only carry PromotionId.
+func sessionPromotionIds(session entities.TableSession) []primitive.ObjectID {
+	var ids []primitive.ObjectID
+	for _, p := range session.Promotions {
+		if !p.Auto {
+			ids = append(ids, p.PromotionId)
+		}
+	}
+	if len(session.Promotions) == 0 && session.PromotionId != nil {
+		ids = append(ids, *session.PromotionId)
+	}
+	return ids
+}
+
+func containsId(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+func loadPromotions(promotionEntity repositories.IPromotion, ids []primitive.ObjectID) []entities.Promotion {
+	var promos []entities.Promotion
+	for _, id := range ids {
+		if promo, err := promotionEntity.GetPromotionById(id); err == nil {
+			promos = append(promos, promo)
+		}
+	}
+	return promos
+}
+
+// setPromotions stores the applied promotions and keeps the single-promotion
+// summary fields in step for existing clients and reports.
+func setPromotions(session *entities.TableSession, applied []entities.AppliedPromotion) {
+	session.Promotions = applied
+	session.PromotionId = nil
+	session.PromotionName = ""
+	session.PromotionDiscount = 0
+	var names []string
+	total := 0.0
+	for _, p := range applied {
+		names = append(names, p.Name)
+		total += p.Discount
+	}
+	if len(applied) > 0 {
+		id := applied[0].PromotionId
+		session.PromotionId = &id
+	}
+	session.PromotionName = strings.Join(names, ", ")
+	session.PromotionDiscount = math.Round(total*100) / 100
+}
+
+func promotionContext(sessionEntity repositories.ITableSession, session entities.TableSession, promotionId primitive.ObjectID, spend float64, now, at time.Time) billing.EligibilityContext {
+	ec := billing.EligibilityContext{
+		Now:       now,
+		At:        at,
+		TableType: session.TableType,
+		IsMember:  session.MemberId != nil,
+		Spend:     spend,
+	}
+	ec.TotalUses, _ = sessionEntity.CountPromotionUsage(promotionId, nil)
+	if session.MemberId != nil {
+		ec.MemberUses, _ = sessionEntity.CountPromotionUsage(promotionId, session.MemberId)
+	}
+	return ec
+}
+
+// currentBill prices an open session as if it were closed now.
+func currentBill(orderEntity repositories.ITableOrder, session entities.TableSession, now time.Time) billing.BillContext {
+	played := billing.PlayedMinutes(session, now)
+	orders, _ := orderEntity.GetOrdersBySessionId(session.Id)
+	return billing.BillContext{
+		PlayedMins:  played,
+		TableCharge: billing.TableCharge(played, session.RatePerHour),
+		RatePerHour: session.RatePerHour,
+		Intervals:   billing.PlayedIntervals(session, now),
+		Orders:      orders,
+	}
+}
+
+func billTotal(bc billing.BillContext) float64 {
+	return bc.TableCharge + foodTotal(bc.Orders)
+}
+
+func foodTotal(orders []entities.TableOrder) float64 {
+	total := 0.0
+	for _, o := range orders {
+		total += o.Total
+	}
+	return total
+}
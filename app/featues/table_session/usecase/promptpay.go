@@ -0,0 +1,79 @@
+package usecase
+
+import (
+	"math"
+	"net/http"
+	"snook/app/core/billing"
+	"snook/app/core/errcode"
+	"snook/app/core/promptpay"
+	"snook/app/data/entities"
+	"snook/app/data/repositories"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CreatePromptPayQr asks for the remaining balance of a session through a
+// PromptPay QR code. The bill is priced as if the session closed now, so the
+// code should be made just before the customer pays. The QR image is served
+// by the payment feature and staff confirm the transfer there.
+func CreatePromptPayQr(sessionEntity repositories.ITableSession, orderEntity repositories.ITableOrder, paymentEntity repositories.IPayment, promotionEntity repositories.IPromotion, settingEntity repositories.ISetting, intentEntity repositories.IPaymentIntent) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		sessionId, err := primitive.ObjectIDFromHex(ctx.Param("sessionId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_001, "invalid sessionId")
+			return
+		}
+		session, err := sessionEntity.GetTableSessionById(sessionId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "session not found")
+			return
+		}
+		if session.Status == "CLOSED" {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "session already closed")
+			return
+		}
+		setting, err := settingEntity.GetSetting()
+		if err != nil || setting.PromptPayId == "" {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "PromptPay id is not set in settings")
+			return
+		}
+		method, err := billing.ResolvePaymentMethod(billing.PaymentMethods(setting), "QR")
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, err.Error())
+			return
+		}
+		remaining := sessionBalance(sessionEntity, orderEntity, paymentEntity, promotionEntity, &session)
+		if remaining <= 0 {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "session has no balance to pay")
+			return
+		}
+		payload, err := promptpay.Payload(setting.PromptPayId, remaining)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, err.Error())
+			return
+		}
+		intent, err := intentEntity.CreatePaymentIntent(entities.PaymentIntent{
+			SessionId: sessionId, Method: "PROMPTPAY", Amount: remaining, Fee: billing.PaymentFee(method, remaining), Payload: payload,
+			Note: "PromptPay " + session.TableName, CreatedBy: ctx.GetString("UserId"),
+		})
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusCreated, intent)
+	}
+}
+
+// sessionBalance prices the session as if it closed now and returns what is
+// left to pay after the payments made so far.
+func sessionBalance(sessionEntity repositories.ITableSession, orderEntity repositories.ITableOrder, paymentEntity repositories.IPayment, promotionEntity repositories.IPromotion, session *entities.TableSession) float64 {
+	_ = priceSession(sessionEntity, orderEntity, promotionEntity, session, nil, time.Now())
+	payments, _ := paymentEntity.GetPaymentsBySessionId(session.Id)
+	paidTotal := 0.0
+	for _, p := range payments {
+		paidTotal += p.Amount
+	}
+	return math.Round((session.GrandTotal-paidTotal)*100) / 100
+}
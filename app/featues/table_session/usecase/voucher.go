@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"errors"
+	"net/http"
+	"snook/app/core/errcode"
+	"snook/app/data/entities"
+	"snook/app/data/repositories"
+	"snook/app/domain/request"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func RedeemVoucherToSession(sessionEntity repositories.ITableSession, promotionEntity repositories.IPromotion, orderEntity repositories.ITableOrder, voucherEntity repositories.IVoucher) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		sessionId, err := primitive.ObjectIDFromHex(ctx.Param("sessionId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_001, "invalid sessionId")
+			return
+		}
+		var req request.RedeemVoucher
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_001, err.Error())
+			return
+		}
+		session, err := sessionEntity.GetTableSessionById(sessionId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "session not found")
+			return
+		}
+		if session.Status == "CLOSED" {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "session already closed")
+			return
+		}
+		voucher, err := voucherEntity.GetVoucherByCode(strings.ToUpper(strings.TrimSpace(req.Code)))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "voucher not found")
+			return
+		}
+		if err := checkVoucher(voucher, session, time.Now()); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, err.Error())
+			return
+		}
+		promo, err := promotionEntity.GetPromotionById(voucher.PromotionId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, "promotion not found")
+			return
+		}
+		reasons, err := addPromotion(sessionEntity, promotionEntity, orderEntity, &session, promo)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, err.Error())
+			return
+		}
+		if len(reasons) > 0 {
+			errcode.AbortWithDetails(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_003, "promotion not eligible", reasons)
+			return
+		}
+		userId := ctx.GetString("UserId")
+		redemption, err := voucherEntity.RedeemVoucher(entities.VoucherRedemption{
+			VoucherId:   voucher.Id,
+			Code:        voucher.Code,
+			PromotionId: voucher.PromotionId,
+			SessionId:   sessionId,
+			MemberId:    session.MemberId,
+			CreatedBy:   userId,
+		})
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TS_BAD_REQUEST_002, err.Error())
+			return
+		}
+		session.Vouchers = append(session.Vouchers, entities.SessionVoucher{
+			VoucherId:    voucher.Id,
+			RedemptionId: redemption.Id,
+			Code:         voucher.Code,
+			PromotionId:  voucher.PromotionId,
+		})
+		session.UpdatedBy = userId
+		if err := sessionEntity.UpdateTableSession(sessionId, session); err != nil {
+			releaseVouchers(voucherEntity, []entities.SessionVoucher{session.Vouchers[len(session.Vouchers)-1]})
+			abortSessionUpdate(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, session)
+	}
+}
+
+// checkVoucher rejects a voucher that cannot be used on the session at now.
+// The usage limits are enforced again when the use is claimed.
+func checkVoucher(voucher entities.Voucher, session entities.TableSession, now time.Time) error {
+	if voucher.Status != "ACTIVE" {
+		return errors.New("voucher is not active")
+	}
+	if voucher.ExpiresAt != nil && !now.Before(voucher.ExpiresAt.AddDate(0, 0, 1)) {
+		return errors.New("voucher expired on " + voucher.ExpiresAt.In(time.Local).Format("2006-01-02"))
+	}
+	if voucher.MaxUses > 0 && voucher.UsedCount >= voucher.MaxUses {
+		return errors.New("voucher has been fully used")
+	}
+	if voucher.MaxUsesPerCustomer > 0 && session.MemberId == nil {
+		return errors.New("member required for this voucher")
+	}
+	return nil
+}
+
+// dropVouchers removes the vouchers whose promotion is no longer applied to
+// the session and returns them so their redemptions can be released.
+func dropVouchers(session *entities.TableSession) []entities.SessionVoucher {
+	var kept, dropped []entities.SessionVoucher
+	for _, v := range session.Vouchers {
+		applied := false
+		for _, p := range session.Promotions {
+			if p.PromotionId == v.PromotionId {
+				applied = true
+				break
+			}
+		}
+		if applied {
+			kept = append(kept, v)
+		} else {
+			dropped = append(dropped, v)
+		}
+	}
+	session.Vouchers = kept
+	return dropped
+}
+
+func releaseVouchers(voucherEntity repositories.IVoucher, vouchers []entities.SessionVoucher) {
+	for _, v := range vouchers {
+		_ = voucherEntity.ReleaseVoucherRedemption(v.RedemptionId)
+	}
+}
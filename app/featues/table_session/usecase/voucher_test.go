@@ -0,0 +1,44 @@
+package usecase
+
+import (
+	"snook/app/data/entities"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCheckVoucher(t *testing.T) {
+	now := time.Date(2026, 10, 19, 23, 30, 0, 0, time.Local)
+	today := time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local)
+	yesterday := today.AddDate(0, 0, -1)
+	memberId := primitive.NewObjectID()
+	member := entities.TableSession{MemberId: &memberId}
+	tests := []struct {
+		name    string
+		voucher entities.Voucher
+		session entities.TableSession
+		wantErr string
+	}{
+		{"active", entities.Voucher{Status: "ACTIVE"}, entities.TableSession{}, ""},
+		{"inactive", entities.Voucher{Status: "INACTIVE"}, entities.TableSession{}, "voucher is not active"},
+		{"valid through its expiry day", entities.Voucher{Status: "ACTIVE", ExpiresAt: &today}, entities.TableSession{}, ""},
+		{"expired", entities.Voucher{Status: "ACTIVE", ExpiresAt: &yesterday}, entities.TableSession{}, "voucher expired on 2026-10-18"},
+		{"fully used", entities.Voucher{Status: "ACTIVE", MaxUses: 2, UsedCount: 2}, entities.TableSession{}, "voucher has been fully used"},
+		{"uses left", entities.Voucher{Status: "ACTIVE", MaxUses: 2, UsedCount: 1}, entities.TableSession{}, ""},
+		{"per-customer limit needs a member", entities.Voucher{Status: "ACTIVE", MaxUsesPerCustomer: 1}, entities.TableSession{}, "member required for this voucher"},
+		{"per-customer limit with a member", entities.Voucher{Status: "ACTIVE", MaxUsesPerCustomer: 1}, member, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkVoucher(tt.voucher, tt.session, now)
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tt.wantErr {
+				t.Errorf("checkVoucher() error = %q, want %q", got, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package voucher
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"net/http"
+	"snook/app/core/constant"
+	"snook/app/core/errcode"
+	"snook/app/data/entities"
+	"snook/app/data/repositories"
+	"snook/app/domain"
+	"snook/app/domain/request"
+	"snook/middlewares"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// codeAlphabet leaves out characters that are easily misread on printed vouchers.
+const codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+const maxBatchSize = 5000
+
+func ApplyVoucherAPI(route *gin.RouterGroup, repository *domain.Repository) {
+	r := route.Group("vouchers")
+
+	r.GET("", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		promotionId, err := optionalObjectId(ctx.Query("promotionId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.VC_BAD_REQUEST_001, "invalid promotionId")
+			return
+		}
+		vouchers, err := repository.Voucher.GetVouchers(promotionId, ctx.Query("batchId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.VC_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, vouchers)
+	})
+
+	r.GET("/report", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		promotionId, err := optionalObjectId(ctx.Query("promotionId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.VC_BAD_REQUEST_001, "invalid promotionId")
+			return
+		}
+		report, err := repository.Voucher.GetVoucherReport(promotionId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.VC_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, report)
+	})
+
+	r.GET("/code/:code", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		voucher, err := repository.Voucher.GetVoucherByCode(strings.ToUpper(ctx.Param("code")))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.VC_BAD_REQUEST_002, "voucher not found")
+			return
+		}
+		ctx.JSON(http.StatusOK, voucher)
+	})
+
+	r.GET("/:voucherId", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		id, err := primitive.ObjectIDFromHex(ctx.Param("voucherId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.VC_BAD_REQUEST_001, "invalid voucherId")
+			return
+		}
+		voucher, err := repository.Voucher.GetVoucherById(id)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.VC_BAD_REQUEST_002, "voucher not found")
+			return
+		}
+		ctx.JSON(http.StatusOK, voucher)
+	})
+
+	r.GET("/:voucherId/redemptions", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session), func(ctx *gin.Context) {
+		id, err := primitive.ObjectIDFromHex(ctx.Param("voucherId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.VC_BAD_REQUEST_001, "invalid voucherId")
+			return
+		}
+		redemptions, err := repository.Voucher.GetVoucherRedemptions(id)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.VC_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, redemptions)
+	})
+
+	r.POST("", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			var req request.Voucher
+			if err := ctx.ShouldBindJSON(&req); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.VC_BAD_REQUEST_001, err.Error())
+				return
+			}
+			promo, err := getPromotion(repository.Promotion, req.PromotionId)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.VC_BAD_REQUEST_002, err.Error())
+				return
+			}
+			expiresAt, err := parseExpiry(req.ExpiresAt)
+			if err != nil || req.MaxUses < 0 || req.MaxUsesPerCustomer < 0 {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.VC_BAD_REQUEST_001, "invalid expiresAt or usage limits")
+				return
+			}
+			code := strings.ToUpper(strings.TrimSpace(req.Code))
+			if code == "" {
+				if code, err = generateCode("", 8); err != nil {
+					errcode.Abort(ctx, http.StatusInternalServerError, errcode.VC_INTERNAL_001, err.Error())
+					return
+				}
+			}
+			status := req.Status
+			if status == "" {
+				status = "ACTIVE"
+			}
+			voucher := entities.Voucher{
+				Code: code, PromotionId: promo.Id, PromotionName: promo.Name,
+				MaxUses: req.MaxUses, MaxUsesPerCustomer: req.MaxUsesPerCustomer,
+				ExpiresAt: expiresAt, Status: status, CreatedBy: ctx.GetString("UserId"),
+			}
+			result, err := repository.Voucher.CreateVouchers([]entities.Voucher{voucher})
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.VC_BAD_REQUEST_002, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusCreated, result[0])
+		})
+
+	r.POST("/batch", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			var req request.VoucherBatch
+			if err := ctx.ShouldBindJSON(&req); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.VC_BAD_REQUEST_001, err.Error())
+				return
+			}
+			if req.Quantity <= 0 || req.Quantity > maxBatchSize {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.VC_BAD_REQUEST_001, "quantity must be between 1 and 5000")
+				return
+			}
+			promo, err := getPromotion(repository.Promotion, req.PromotionId)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.VC_BAD_REQUEST_002, err.Error())
+				return
+			}
+			expiresAt, err := parseExpiry(req.ExpiresAt)
+			if err != nil || req.MaxUses < 0 || req.MaxUsesPerCustomer < 0 {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.VC_BAD_REQUEST_001, "invalid expiresAt or usage limits")
+				return
+			}
+			// Marketing batches are single-use unless stated otherwise.
+			maxUses := req.MaxUses
+			if maxUses == 0 {
+				maxUses = 1
+			}
+			prefix := strings.ToUpper(strings.TrimSpace(req.Prefix))
+			batchId := primitive.NewObjectID().Hex()
+			userId := ctx.GetString("UserId")
+			seen := map[string]bool{}
+			var vouchers []entities.Voucher
+			for len(vouchers) < req.Quantity {
+				code, err := generateCode(prefix, 8)
+				if err != nil {
+					errcode.Abort(ctx, http.StatusInternalServerError, errcode.VC_INTERNAL_001, err.Error())
+					return
+				}
+				if seen[code] {
+					continue
+				}
+				seen[code] = true
+				vouchers = append(vouchers, entities.Voucher{
+					Code: code, PromotionId: promo.Id, PromotionName: promo.Name, BatchId: batchId,
+					MaxUses: maxUses, MaxUsesPerCustomer: req.MaxUsesPerCustomer,
+					ExpiresAt: expiresAt, Status: "ACTIVE", CreatedBy: userId,
+				})
+			}
+			result, err := repository.Voucher.CreateVouchers(vouchers)
+			if errors.Is(err, repositories.ErrDuplicateVoucherCode) {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.VC_BAD_REQUEST_002, "generated code collided with an existing voucher, please retry")
+				return
+			}
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.VC_BAD_REQUEST_002, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusCreated, gin.H{"batchId": batchId, "vouchers": result})
+		})
+
+	r.PUT("/:voucherId", middlewares.RequireAuthenticated(), middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.SUPER, constant.ADMIN), func(ctx *gin.Context) {
+			id, err := primitive.ObjectIDFromHex(ctx.Param("voucherId"))
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.VC_BAD_REQUEST_001, "invalid voucherId")
+				return
+			}
+			var req request.Voucher
+			if err := ctx.ShouldBindJSON(&req); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.VC_BAD_REQUEST_001, err.Error())
+				return
+			}
+			expiresAt, err := parseExpiry(req.ExpiresAt)
+			if err != nil || req.MaxUses < 0 || req.MaxUsesPerCustomer < 0 {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.VC_BAD_REQUEST_001, "invalid expiresAt or usage limits")
+				return
+			}
+			voucher := entities.Voucher{
+				MaxUses: req.MaxUses, MaxUsesPerCustomer: req.MaxUsesPerCustomer,
+				ExpiresAt: expiresAt, Status: req.Status, UpdatedBy: ctx.GetString("UserId"),
+			}
+			if err := repository.Voucher.UpdateVoucherById(id, voucher); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.VC_BAD_REQUEST_002, err.Error())
+				return
+			}
+			ctx.JSON(http.StatusOK, gin.H{"message": "success"})
+		})
+}
+
+func getPromotion(promotionEntity repositories.IPromotion, promotionId string) (entities.Promotion, error) {
+	id, err := primitive.ObjectIDFromHex(promotionId)
+	if err != nil {
+		return entities.Promotion{}, errors.New("invalid promotionId")
+	}
+	promo, err := promotionEntity.GetPromotionById(id)
+	if err != nil {
+		return promo, errors.New("promotion not found")
+	}
+	if promo.AutoApply {
+		return promo, errors.New("promotion is applied automatically")
+	}
+	return promo, nil
+}
+
+func optionalObjectId(value string) (*primitive.ObjectID, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := primitive.ObjectIDFromHex(value)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func parseExpiry(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func generateCode(prefix string, length int) (string, error) {
+	var sb strings.Builder
+	sb.WriteString(prefix)
+	max := big.NewInt(int64(len(codeAlphabet)))
+	for i := 0; i < length; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteByte(codeAlphabet[n.Int64()])
+	}
+	return sb.String(), nil
+}
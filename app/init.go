@@ -2,19 +2,27 @@ package app
 
 import (
 	"os"
+	"snook/app/core/storage"
 	"snook/app/domain"
 	"snook/app/featues/booking"
+	"snook/app/featues/cash_shift"
 	"snook/app/featues/creditor"
 	"snook/app/featues/dashboard"
+	"snook/app/featues/day_close"
 	"snook/app/featues/expense"
+	"snook/app/featues/loyalty"
 	"snook/app/featues/menu"
+	"snook/app/featues/order_ticket"
 	"snook/app/featues/payment"
+	"snook/app/featues/price_list"
 	"snook/app/featues/promotion"
+	"snook/app/featues/purchase"
 	"snook/app/featues/report"
 	"snook/app/featues/setting"
 	"snook/app/featues/table"
 	"snook/app/featues/table_order"
 	"snook/app/featues/table_session"
+	"snook/app/featues/voucher"
 	"snook/db"
 	"snook/middlewares"
 
@@ -46,18 +54,30 @@ func (app Routes) StartGin() {
 
 	repository := domain.InitRepository(resource)
 
+	// Uploads kept on local disk are served by the API itself.
+	if local, ok := repository.Storage.(*storage.LocalStorage); ok {
+		r.Static(storage.LocalRoute, local.Dir)
+	}
+
 	table.ApplyTableAPI(publicRoute, repository)
 	table_session.ApplyTableSessionAPI(publicRoute, repository)
 	booking.ApplyBookingAPI(publicRoute, repository)
 	menu.ApplyMenuAPI(publicRoute, repository)
+	price_list.ApplyPriceListAPI(publicRoute, repository)
 	table_order.ApplyTableOrderAPI(publicRoute, repository)
+	order_ticket.ApplyOrderTicketAPI(publicRoute, repository)
 	payment.ApplyPaymentAPI(publicRoute, repository)
 	creditor.ApplyCreditorAPI(publicRoute, repository)
+	cash_shift.ApplyCashShiftAPI(publicRoute, repository)
 	promotion.ApplyPromotionAPI(publicRoute, repository)
 	expense.ApplyExpenseAPI(publicRoute, repository)
 	setting.ApplySettingAPI(publicRoute, repository)
 	dashboard.ApplyDashboardAPI(publicRoute, repository)
 	report.ApplyReportAPI(publicRoute, repository)
+	day_close.ApplyDayCloseAPI(publicRoute, repository)
+	loyalty.ApplyLoyaltyAPI(publicRoute, repository)
+	voucher.ApplyVoucherAPI(publicRoute, repository)
+	purchase.ApplyPurchaseAPI(publicRoute, repository)
 
 	r.NoRoute(middlewares.NoRoute())
 
@@ -36,6 +36,21 @@ func (r *Resource) Close() {
 	}
 }
 
+// WithTransaction runs fn inside a MongoDB transaction, committing when fn
+// succeeds and aborting when it returns an error. Transactions require the
+// database to run as a replica set.
+func (r *Resource) WithTransaction(ctx context.Context, fn func(sc mongo.SessionContext) error) error {
+	session, err := r.mongoClient.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
+
 func InitResource() (*Resource, error) {
 	err := godotenv.Load(".env")
 	if err != nil {
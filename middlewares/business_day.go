@@ -0,0 +1,37 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"snook/app/core/businessday"
+	"snook/app/core/errcode"
+	"snook/app/data/repositories"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RequireOpenBusinessDay refuses writes once the current business day has
+// been closed, so nothing is added to a day after its Z report.
+func RequireOpenBusinessDay(settingEntity repositories.ISetting, dayCloseEntity repositories.IDayClose) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// Without saved settings the day starts at midnight.
+		setting, err := settingEntity.GetSetting()
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.DC_INTERNAL_001, err.Error())
+			return
+		}
+		date := businessday.Date(time.Now(), setting.CutoverHour)
+		closed, err := dayCloseEntity.IsDayClosed(date)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.DC_INTERNAL_001, err.Error())
+			return
+		}
+		if closed {
+			errcode.Abort(ctx, http.StatusConflict, errcode.DC_CONFLICT_001, "business day "+date+" is closed")
+			return
+		}
+		ctx.Next()
+	}
+}